@@ -1,12 +1,34 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
 
+	"github.com/abc_valera/flugo/internal/database"
 	"github.com/abc_valera/flugo/internal/server"
+	cnfg "github.com/abc_valera/flugo/internal/utils/config"
 )
 
+const migrateUsage = `usage: flugo migrate <command>
+
+commands:
+  up          apply all the pending migrations
+  down N      revert the last N migrations
+  status      show the current version and the migrations
+  force V     set the version to V without running migrations, after a failed one is fixed by hand`
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	s, err := server.NewServer()
 	if err != nil {
 		log.Fatal(err)
@@ -14,3 +36,60 @@ func main() {
 
 	s.Start()
 }
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	c, err := cnfg.LoadConfig(".")
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(c.DatabaseUrl)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return migrator.Up()
+	case args[0] == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid number of migrations: %w", err)
+		}
+		return migrator.Down(n)
+	case args[0] == "force" && len(args) == 2:
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version: %w", err)
+		}
+		return migrator.Force(version)
+	case args[0] == "status" && len(args) == 1:
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(status)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func printMigrationStatus(status database.MigrationStatus) {
+	fmt.Printf("version: %d", status.Version)
+	if status.Dirty {
+		fmt.Print(" (dirty, fix the schema and run \"flugo migrate force V\")")
+	}
+	fmt.Println()
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		fmt.Printf("%06d %-24s %s\n", m.Version, m.Name, state)
+	}
+}
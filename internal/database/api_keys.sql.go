@@ -0,0 +1,153 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: api_keys.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    user_id,
+    name,
+    prefix,
+    key_hash,
+    scopes
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, user_id, name, prefix, key_hash, is_revoked, last_used_at, created_at, scopes
+`
+
+type CreateAPIKeyParams struct {
+	UserID  int32    `json:"user_id"`
+	Name    string   `json:"name"`
+	Prefix  string   `json:"prefix"`
+	KeyHash string   `json:"key_hash"`
+	Scopes  []string `json:"scopes"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createAPIKey,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		pq.Array(arg.Scopes),
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.IsRevoked,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		pq.Array(&i.Scopes),
+	)
+	return i, err
+}
+
+const listAPIKeysByUser = `-- name: ListAPIKeysByUser :many
+
+SELECT id, user_id, name, prefix, key_hash, is_revoked, last_used_at, created_at, scopes FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+// GET QUERIES
+func (q *Queries) ListAPIKeysByUser(ctx context.Context, userID int32) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeysByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.IsRevoked,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			pq.Array(&i.Scopes),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeOwnedAPIKey = `-- name: RevokeOwnedAPIKey :execrows
+UPDATE api_keys
+SET is_revoked = true
+WHERE id = $1 AND user_id = $2
+`
+
+type RevokeOwnedAPIKeyParams struct {
+	ID     int64 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) RevokeOwnedAPIKey(ctx context.Context, arg RevokeOwnedAPIKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeOwnedAPIKey, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useAPIKey = `-- name: UseAPIKey :one
+
+UPDATE api_keys
+SET last_used_at = now()
+FROM users
+WHERE api_keys.key_hash = $1
+    AND api_keys.is_revoked = false
+    AND users.id = api_keys.user_id
+RETURNING api_keys.id, api_keys.user_id, api_keys.scopes, users.username, users.email, users.role, users.is_banned
+`
+
+type UseAPIKeyRow struct {
+	ID       int64    `json:"id"`
+	UserID   int32    `json:"user_id"`
+	Scopes   []string `json:"scopes"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Role     string   `json:"role"`
+	IsBanned bool     `json:"is_banned"`
+}
+
+// UPDATE QUERIES
+// Finds the active key with its user and marks it as used
+func (q *Queries) UseAPIKey(ctx context.Context, keyHash string) (UseAPIKeyRow, error) {
+	row := q.db.QueryRowContext(ctx, useAPIKey, keyHash)
+	var i UseAPIKeyRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		pq.Array(&i.Scopes),
+		&i.Username,
+		&i.Email,
+		&i.Role,
+		&i.IsBanned,
+	)
+	return i, err
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomAPIKey(t *testing.T, userID int32) ApiKey {
+	arg := CreateAPIKeyParams{
+		UserID:  userID,
+		Name:    random.RandomString(10),
+		Prefix:  "flugo_" + random.RandomString(8),
+		KeyHash: random.RandomString(64),
+	}
+
+	key, err := testQueries.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	require.Equal(t, arg.UserID, key.UserID)
+	require.Equal(t, arg.Name, key.Name)
+	require.Equal(t, arg.Prefix, key.Prefix)
+	require.Equal(t, arg.KeyHash, key.KeyHash)
+	require.False(t, key.IsRevoked)
+	require.False(t, key.LastUsedAt.Valid)
+	require.NotZero(t, key.ID)
+
+	return key
+}
+
+func TestUseAPIKey(t *testing.T) {
+	user := CreateRandomUser(t)
+	key := CreateRandomAPIKey(t, user.ID)
+
+	row, err := testQueries.UseAPIKey(context.Background(), key.KeyHash)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, row.ID)
+	require.Equal(t, user.ID, row.UserID)
+	require.Equal(t, user.Username, row.Username)
+	require.Equal(t, user.Email, row.Email)
+	require.Equal(t, user.Role, row.Role)
+
+	keys, err := testQueries.ListAPIKeysByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.True(t, keys[0].LastUsedAt.Valid)
+
+	_, err = testQueries.UseAPIKey(context.Background(), random.RandomString(64))
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestRevokeOwnedAPIKey(t *testing.T) {
+	user := CreateRandomUser(t)
+	key := CreateRandomAPIKey(t, user.ID)
+
+	// Keys of other users can't be revoked
+	rows, err := testQueries.RevokeOwnedAPIKey(context.Background(), RevokeOwnedAPIKeyParams{ID: key.ID, UserID: CreateRandomUser(t).ID})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.RevokeOwnedAPIKey(context.Background(), RevokeOwnedAPIKeyParams{ID: key.ID, UserID: user.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = testQueries.UseAPIKey(context.Background(), key.KeyHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: audit_events.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+
+INSERT INTO audit_events (
+    type,
+    outcome,
+    user_id,
+    actor_id,
+    client_ip,
+    user_agent,
+    details
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+`
+
+type CreateAuditEventParams struct {
+	Type      string        `json:"type"`
+	Outcome   string        `json:"outcome"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	ActorID   sql.NullInt32 `json:"actor_id"`
+	ClientIp  string        `json:"client_ip"`
+	UserAgent string        `json:"user_agent"`
+	Details   string        `json:"details"`
+}
+
+// Audit events are append-only, there are no update or delete queries
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuditEvent,
+		arg.Type,
+		arg.Outcome,
+		arg.UserID,
+		arg.ActorID,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.Details,
+	)
+	return err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, type, outcome, user_id, actor_id, client_ip, user_agent, details, created_at FROM audit_events
+WHERE ($1::integer IS NULL OR user_id = $1)
+    AND ($2::varchar IS NULL OR type = $2)
+    AND ($3::timestamptz IS NULL OR created_at >= $3)
+    AND ($4::timestamptz IS NULL OR created_at < $4)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+OFFSET $5
+`
+
+type ListAuditEventsParams struct {
+	UserID sql.NullInt32  `json:"user_id"`
+	Type   sql.NullString `json:"type"`
+	Since  sql.NullTime   `json:"since"`
+	Until  sql.NullTime   `json:"until"`
+	Offset int32          `json:"offset_"`
+	Limit  int32          `json:"limit_"`
+}
+
+// Every filter is optional, NULL matches all events
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.UserID,
+		arg.Type,
+		arg.Since,
+		arg.Until,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Outcome,
+			&i.UserID,
+			&i.ActorID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEventsByUser = `-- name: ListAuditEventsByUser :many
+
+SELECT id, type, outcome, user_id, actor_id, client_ip, user_agent, details, created_at FROM audit_events
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListAuditEventsByUserParams struct {
+	UserID sql.NullInt32 `json:"user_id"`
+	Limit  int32         `json:"limit"`
+	Offset int32         `json:"offset"`
+}
+
+// GET QUERIES
+func (q *Queries) ListAuditEventsByUser(ctx context.Context, arg ListAuditEventsByUserParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEventsByUser, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Outcome,
+			&i.UserID,
+			&i.ActorID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
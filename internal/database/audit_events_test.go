@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomAuditEvent(t *testing.T, userID int32, eventType string) {
+	err := testQueries.CreateAuditEvent(context.Background(), CreateAuditEventParams{
+		Type:      eventType,
+		Outcome:   "success",
+		UserID:    sql.NullInt32{Int32: userID, Valid: true},
+		ActorID:   sql.NullInt32{Int32: userID, Valid: true},
+		ClientIp:  "127.0.0.1",
+		UserAgent: "Mozilla/5.0",
+	})
+	require.NoError(t, err)
+}
+
+func TestListAuditEventsByUser(t *testing.T) {
+	user := CreateRandomUser(t)
+	CreateRandomAuditEvent(t, user.ID, "login")
+	CreateRandomAuditEvent(t, user.ID, "password_change")
+
+	events, err := testQueries.ListAuditEventsByUser(context.Background(), ListAuditEventsByUserParams{
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	// Newest first
+	require.Equal(t, "password_change", events[0].Type)
+	require.Equal(t, "login", events[1].Type)
+	require.Equal(t, "127.0.0.1", events[0].ClientIp)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	user := CreateRandomUser(t)
+	CreateRandomAuditEvent(t, user.ID, "login")
+	CreateRandomAuditEvent(t, user.ID, "login")
+	CreateRandomAuditEvent(t, user.ID, "avatar_change")
+
+	events, err := testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Type:   sql.NullString{String: "login", Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	events, err = testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Since:  sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	events, err = testQueries.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Since:  sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+		Until:  sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	user := CreateRandomUser(t)
+	CreateRandomAuditEvent(t, user.ID, "login")
+
+	_, err := testQueries.db.ExecContext(context.Background(), "UPDATE audit_events SET outcome = 'failure' WHERE user_id = $1", user.ID)
+	require.Error(t, err)
+
+	_, err = testQueries.db.ExecContext(context.Background(), "DELETE FROM audit_events WHERE user_id = $1", user.ID)
+	require.Error(t, err)
+
+	// Events outlive the user
+	err = testQueries.DeleteUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	events, err := testQueries.ListAuditEventsByUser(context.Background(), ListAuditEventsByUserParams{
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
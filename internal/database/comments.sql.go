@@ -0,0 +1,322 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: comments.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const createComment = `-- name: CreateComment :one
+INSERT INTO comments (
+    joke_id,
+    author_id,
+    parent_id,
+    root_id,
+    text
+) VALUES (
+    $1, $5::integer, $2, $3, $4
+) RETURNING id, joke_id, author_id, parent_id, root_id, text, created_at, updated_at
+`
+
+type CreateCommentParams struct {
+	JokeID   int32         `json:"joke_id"`
+	ParentID sql.NullInt32 `json:"parent_id"`
+	RootID   sql.NullInt32 `json:"root_id"`
+	Text     string        `json:"text"`
+	AuthorID int32         `json:"author_id"`
+}
+
+func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error) {
+	row := q.db.QueryRowContext(ctx, createComment,
+		arg.JokeID,
+		arg.ParentID,
+		arg.RootID,
+		arg.Text,
+		arg.AuthorID,
+	)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.JokeID,
+		&i.AuthorID,
+		&i.ParentID,
+		&i.RootID,
+		&i.Text,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteEmptyTombstone = `-- name: DeleteEmptyTombstone :one
+DELETE FROM comments
+WHERE comments.id = $1 AND comments.author_id IS NULL
+    AND NOT EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)
+RETURNING comments.parent_id
+`
+
+// Deletes the tombstone if its last reply is gone, returns the comment it answers
+func (q *Queries) DeleteEmptyTombstone(ctx context.Context, id int32) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, deleteEmptyTombstone, id)
+	var parent_id sql.NullInt32
+	err := row.Scan(&parent_id)
+	return parent_id, err
+}
+
+const deleteOwnedComment = `-- name: DeleteOwnedComment :one
+
+DELETE FROM comments
+WHERE comments.id = $1 AND comments.joke_id = $2 AND comments.author_id = $3::integer
+    AND NOT EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)
+RETURNING comments.parent_id
+`
+
+type DeleteOwnedCommentParams struct {
+	ID       int32 `json:"id"`
+	JokeID   int32 `json:"joke_id"`
+	AuthorID int32 `json:"author_id"`
+}
+
+// DELETE QUERIES
+// Deletes the comment if it has no replies, returns the comment it answers
+func (q *Queries) DeleteOwnedComment(ctx context.Context, arg DeleteOwnedCommentParams) (sql.NullInt32, error) {
+	row := q.db.QueryRowContext(ctx, deleteOwnedComment, arg.ID, arg.JokeID, arg.AuthorID)
+	var parent_id sql.NullInt32
+	err := row.Scan(&parent_id)
+	return parent_id, err
+}
+
+const getComment = `-- name: GetComment :one
+
+SELECT id, joke_id, parent_id, root_id, author_id, author, text, created_at, updated_at FROM comments_with_authors
+WHERE id = $1 LIMIT 1
+`
+
+// GET QUERIES
+func (q *Queries) GetComment(ctx context.Context, id int32) (CommentsWithAuthor, error) {
+	row := q.db.QueryRowContext(ctx, getComment, id)
+	var i CommentsWithAuthor
+	err := row.Scan(
+		&i.ID,
+		&i.JokeID,
+		&i.ParentID,
+		&i.RootID,
+		&i.AuthorID,
+		&i.Author,
+		&i.Text,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listCommentReplies = `-- name: ListCommentReplies :many
+SELECT replies.id, replies.joke_id, replies.parent_id, replies.root_id, replies.author_id, replies.author,
+    replies.text, replies.created_at, replies.updated_at
+FROM (
+    SELECT id, joke_id, parent_id, root_id, author_id, author, text, created_at, updated_at, row_number() OVER (PARTITION BY root_id ORDER BY id) AS position
+    FROM comments_with_authors
+    WHERE root_id = ANY($1::integer[])
+) AS replies
+WHERE replies.position <= $2::integer
+ORDER BY replies.id
+`
+
+type ListCommentRepliesParams struct {
+	RootIds []int32 `json:"root_ids"`
+	Limit   int32   `json:"limit_"`
+}
+
+// The oldest replies in each of the threads of the top-level comments, at most limit_ per thread.
+// The parents are older than their replies, so every listed reply answers a listed comment.
+func (q *Queries) ListCommentReplies(ctx context.Context, arg ListCommentRepliesParams) ([]CommentsWithAuthor, error) {
+	rows, err := q.db.QueryContext(ctx, listCommentReplies, pq.Array(arg.RootIds), arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentsWithAuthor
+	for rows.Next() {
+		var i CommentsWithAuthor
+		if err := rows.Scan(
+			&i.ID,
+			&i.JokeID,
+			&i.ParentID,
+			&i.RootID,
+			&i.AuthorID,
+			&i.Author,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listComments = `-- name: ListComments :many
+SELECT id, joke_id, parent_id, root_id, author_id, author, text, created_at, updated_at FROM comments_with_authors
+WHERE joke_id = $1 AND parent_id IS NULL AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListCommentsParams struct {
+	JokeID  int32 `json:"joke_id"`
+	AfterID int32 `json:"after_id"`
+	Limit   int32 `json:"limit_"`
+}
+
+// Top-level comments of the joke after the cursor, oldest first
+func (q *Queries) ListComments(ctx context.Context, arg ListCommentsParams) ([]CommentsWithAuthor, error) {
+	rows, err := q.db.QueryContext(ctx, listComments, arg.JokeID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentsWithAuthor
+	for rows.Next() {
+		var i CommentsWithAuthor
+		if err := rows.Scan(
+			&i.ID,
+			&i.JokeID,
+			&i.ParentID,
+			&i.RootID,
+			&i.AuthorID,
+			&i.Author,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadReplies = `-- name: ListThreadReplies :many
+SELECT id, joke_id, parent_id, root_id, author_id, author, text, created_at, updated_at FROM comments_with_authors
+WHERE root_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListThreadRepliesParams struct {
+	RootID  sql.NullInt32 `json:"root_id"`
+	AfterID int32         `json:"after_id"`
+	Limit   int32         `json:"limit_"`
+}
+
+// Replies in the thread of the top-level comment after the cursor, oldest first
+func (q *Queries) ListThreadReplies(ctx context.Context, arg ListThreadRepliesParams) ([]CommentsWithAuthor, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadReplies, arg.RootID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentsWithAuthor
+	for rows.Next() {
+		var i CommentsWithAuthor
+		if err := rows.Scan(
+			&i.ID,
+			&i.JokeID,
+			&i.ParentID,
+			&i.RootID,
+			&i.AuthorID,
+			&i.Author,
+			&i.Text,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tombstoneOwnedComment = `-- name: TombstoneOwnedComment :execrows
+UPDATE comments
+SET author_id = NULL, updated_at = now()
+WHERE comments.id = $1 AND comments.joke_id = $2 AND comments.author_id = $3::integer
+    AND EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)
+`
+
+type TombstoneOwnedCommentParams struct {
+	ID       int32 `json:"id"`
+	JokeID   int32 `json:"joke_id"`
+	AuthorID int32 `json:"author_id"`
+}
+
+// Turns the comment into a tombstone if it has replies, the trigger clears the text
+func (q *Queries) TombstoneOwnedComment(ctx context.Context, arg TombstoneOwnedCommentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, tombstoneOwnedComment, arg.ID, arg.JokeID, arg.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateOwnedCommentText = `-- name: UpdateOwnedCommentText :one
+
+UPDATE comments
+SET text = $3, updated_at = now()
+WHERE id = $1 AND joke_id = $2 AND author_id = $4::integer
+RETURNING id, joke_id, author_id, parent_id, root_id, text, created_at, updated_at
+`
+
+type UpdateOwnedCommentTextParams struct {
+	ID       int32  `json:"id"`
+	JokeID   int32  `json:"joke_id"`
+	Text     string `json:"text"`
+	AuthorID int32  `json:"author_id"`
+}
+
+// UPDATE QUERIES
+func (q *Queries) UpdateOwnedCommentText(ctx context.Context, arg UpdateOwnedCommentTextParams) (Comment, error) {
+	row := q.db.QueryRowContext(ctx, updateOwnedCommentText,
+		arg.ID,
+		arg.JokeID,
+		arg.Text,
+		arg.AuthorID,
+	)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.JokeID,
+		&i.AuthorID,
+		&i.ParentID,
+		&i.RootID,
+		&i.Text,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
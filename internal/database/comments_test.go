@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomComment(t *testing.T, authorID int32, jokeID int32, parent *Comment) Comment {
+	arg := CreateCommentParams{
+		JokeID:   jokeID,
+		AuthorID: authorID,
+		Text:     "good one",
+	}
+	if parent != nil {
+		arg.ParentID = sql.NullInt32{Int32: parent.ID, Valid: true}
+		arg.RootID = parent.RootID
+		if !parent.RootID.Valid {
+			arg.RootID = arg.ParentID
+		}
+	}
+
+	comment, err := testQueries.CreateComment(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.JokeID, comment.JokeID)
+	require.Equal(t, arg.AuthorID, comment.AuthorID.Int32)
+	require.Equal(t, arg.ParentID, comment.ParentID)
+	require.Equal(t, arg.RootID, comment.RootID)
+	require.Equal(t, arg.Text, comment.Text)
+	require.NotZero(t, comment.ID)
+	require.NotZero(t, comment.CreatedAt)
+
+	return comment
+}
+
+func TestGetComment(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	comment1 := CreateRandomComment(t, user.ID, joke.ID, nil)
+
+	comment2, err := testQueries.GetComment(context.Background(), comment1.ID)
+	require.NoError(t, err)
+	require.Equal(t, comment1.ID, comment2.ID)
+	require.Equal(t, user.Username, comment2.Author.String)
+	require.Equal(t, comment1.Text, comment2.Text)
+}
+
+func TestListComments(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	var roots []Comment
+	for i := 0; i < 3; i++ {
+		root := CreateRandomComment(t, user.ID, joke.ID, nil)
+		CreateRandomComment(t, user.ID, joke.ID, &root)
+		roots = append(roots, root)
+	}
+
+	// Replies aren't listed with the top-level comments
+	comments, err := testQueries.ListComments(context.Background(), ListCommentsParams{
+		JokeID:  joke.ID,
+		AfterID: roots[0].ID,
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	require.Equal(t, roots[1].ID, comments[0].ID)
+	require.Equal(t, roots[2].ID, comments[1].ID)
+}
+
+func TestListCommentReplies(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	root := CreateRandomComment(t, user.ID, joke.ID, nil)
+	reply := CreateRandomComment(t, user.ID, joke.ID, &root)
+	nestedReply := CreateRandomComment(t, user.ID, joke.ID, &reply)
+	CreateRandomComment(t, user.ID, joke.ID, &root)
+	other := CreateRandomComment(t, user.ID, joke.ID, nil)
+	otherReply := CreateRandomComment(t, user.ID, joke.ID, &other)
+
+	// The oldest replies of every thread up to the limit
+	replies, err := testQueries.ListCommentReplies(context.Background(), ListCommentRepliesParams{
+		RootIds: []int32{root.ID, other.ID},
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, replies, 3)
+	require.Equal(t, reply.ID, replies[0].ID)
+	require.Equal(t, nestedReply.ID, replies[1].ID)
+	require.Equal(t, root.ID, replies[1].RootID.Int32)
+	require.Equal(t, otherReply.ID, replies[2].ID)
+
+	replies, err = testQueries.ListThreadReplies(context.Background(), ListThreadRepliesParams{
+		RootID:  sql.NullInt32{Int32: root.ID, Valid: true},
+		AfterID: reply.ID,
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, replies, 2)
+	require.Equal(t, nestedReply.ID, replies[0].ID)
+}
+
+func TestUpdateOwnedCommentText(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	user2 := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user1.ID)
+	comment := CreateRandomComment(t, user1.ID, joke.ID, nil)
+
+	_, err := testQueries.UpdateOwnedCommentText(context.Background(), UpdateOwnedCommentTextParams{
+		ID:       comment.ID,
+		JokeID:   joke.ID,
+		AuthorID: user2.ID,
+		Text:     "new text",
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	updated, err := testQueries.UpdateOwnedCommentText(context.Background(), UpdateOwnedCommentTextParams{
+		ID:       comment.ID,
+		JokeID:   joke.ID,
+		AuthorID: user1.ID,
+		Text:     "new text",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new text", updated.Text)
+}
+
+func TestDeleteOwnedCommentKeepsReplies(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	root := CreateRandomComment(t, user.ID, joke.ID, nil)
+	reply := CreateRandomComment(t, user.ID, joke.ID, &root)
+	nestedReply := CreateRandomComment(t, user.ID, joke.ID, &reply)
+
+	arg := DeleteOwnedCommentParams{
+		ID:       reply.ID,
+		JokeID:   joke.ID,
+		AuthorID: user.ID,
+	}
+	// The comment with a reply isn't deleted but turned into a tombstone
+	_, err := testQueries.DeleteOwnedComment(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	rows, err := testQueries.TombstoneOwnedComment(context.Background(), TombstoneOwnedCommentParams(arg))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	tombstone, err := testQueries.GetComment(context.Background(), reply.ID)
+	require.NoError(t, err)
+	require.False(t, tombstone.AuthorID.Valid)
+	require.False(t, tombstone.Author.Valid)
+	require.Empty(t, tombstone.Text)
+	_, err = testQueries.GetComment(context.Background(), nestedReply.ID)
+	require.NoError(t, err)
+
+	jokeWithAuthor, err := testQueries.GetJoke(context.Background(), joke.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), jokeWithAuthor.CommentCount)
+
+	// Comments without replies are deleted and the tombstones go once their last reply does
+	parentID, err := testQueries.DeleteOwnedComment(context.Background(), DeleteOwnedCommentParams{
+		ID:       nestedReply.ID,
+		JokeID:   joke.ID,
+		AuthorID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, reply.ID, parentID.Int32)
+	parentID, err = testQueries.DeleteEmptyTombstone(context.Background(), reply.ID)
+	require.NoError(t, err)
+	require.Equal(t, root.ID, parentID.Int32)
+	_, err = testQueries.DeleteEmptyTombstone(context.Background(), root.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDeleteUserKeepsCommentTombstones(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	user2 := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user1.ID)
+	root := CreateRandomComment(t, user2.ID, joke.ID, nil)
+	reply := CreateRandomComment(t, user1.ID, joke.ID, &root)
+
+	err := testQueries.DeleteUser(context.Background(), user2.ID)
+	require.NoError(t, err)
+
+	tombstone, err := testQueries.GetComment(context.Background(), root.ID)
+	require.NoError(t, err)
+	require.False(t, tombstone.AuthorID.Valid)
+	require.Empty(t, tombstone.Text)
+	_, err = testQueries.GetComment(context.Background(), reply.ID)
+	require.NoError(t, err)
+}
+
+func TestDeleteJokeDeletesComments(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	comment := CreateRandomComment(t, user.ID, joke.ID, nil)
+
+	err := testQueries.DeleteJoke(context.Background(), joke.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetComment(context.Background(), comment.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
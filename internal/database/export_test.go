@@ -0,0 +1,6 @@
+package database
+
+// QueriesForTest gives the tests of package database_test the connection set up by TestMain
+func QueriesForTest() *Queries {
+	return testQueries
+}
@@ -0,0 +1,167 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: joke_reactions.sql
+
+package database
+
+import (
+	"context"
+)
+
+const addJokeReactionCounts = `-- name: AddJokeReactionCounts :exec
+
+UPDATE jokes
+SET
+    laugh_count = laugh_count + $1,
+    groan_count = groan_count + $2,
+    love_count = love_count + $3
+WHERE id = $4
+`
+
+type AddJokeReactionCountsParams struct {
+	Laughs int32 `json:"laughs"`
+	Groans int32 `json:"groans"`
+	Loves  int32 `json:"loves"`
+	ID     int32 `json:"id"`
+}
+
+// The counters are changed in the transactions that change the reactions
+func (q *Queries) AddJokeReactionCounts(ctx context.Context, arg AddJokeReactionCountsParams) error {
+	_, err := q.db.ExecContext(ctx, addJokeReactionCounts,
+		arg.Laughs,
+		arg.Groans,
+		arg.Loves,
+		arg.ID,
+	)
+	return err
+}
+
+const createJokeReaction = `-- name: CreateJokeReaction :one
+INSERT INTO joke_reactions (
+    user_id,
+    joke_id,
+    reaction
+) VALUES (
+    $1, $2, $3
+) RETURNING user_id, joke_id, reaction, created_at
+`
+
+type CreateJokeReactionParams struct {
+	UserID   int32  `json:"user_id"`
+	JokeID   int32  `json:"joke_id"`
+	Reaction string `json:"reaction"`
+}
+
+func (q *Queries) CreateJokeReaction(ctx context.Context, arg CreateJokeReactionParams) (JokeReaction, error) {
+	row := q.db.QueryRowContext(ctx, createJokeReaction, arg.UserID, arg.JokeID, arg.Reaction)
+	var i JokeReaction
+	err := row.Scan(
+		&i.UserID,
+		&i.JokeID,
+		&i.Reaction,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteJokeReaction = `-- name: DeleteJokeReaction :one
+
+DELETE FROM joke_reactions
+WHERE user_id = $1 AND joke_id = $2
+RETURNING user_id, joke_id, reaction, created_at
+`
+
+type DeleteJokeReactionParams struct {
+	UserID int32 `json:"user_id"`
+	JokeID int32 `json:"joke_id"`
+}
+
+// DELETE QUERIES
+func (q *Queries) DeleteJokeReaction(ctx context.Context, arg DeleteJokeReactionParams) (JokeReaction, error) {
+	row := q.db.QueryRowContext(ctx, deleteJokeReaction, arg.UserID, arg.JokeID)
+	var i JokeReaction
+	err := row.Scan(
+		&i.UserID,
+		&i.JokeID,
+		&i.Reaction,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getJokeReactionForUpdate = `-- name: GetJokeReactionForUpdate :one
+
+SELECT user_id, joke_id, reaction, created_at FROM joke_reactions
+WHERE user_id = $1 AND joke_id = $2
+FOR UPDATE
+`
+
+type GetJokeReactionForUpdateParams struct {
+	UserID int32 `json:"user_id"`
+	JokeID int32 `json:"joke_id"`
+}
+
+// GET QUERIES
+func (q *Queries) GetJokeReactionForUpdate(ctx context.Context, arg GetJokeReactionForUpdateParams) (JokeReaction, error) {
+	row := q.db.QueryRowContext(ctx, getJokeReactionForUpdate, arg.UserID, arg.JokeID)
+	var i JokeReaction
+	err := row.Scan(
+		&i.UserID,
+		&i.JokeID,
+		&i.Reaction,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const removeUserReactionCounts = `-- name: RemoveUserReactionCounts :exec
+UPDATE jokes
+SET
+    laugh_count = laugh_count - counts.laughs,
+    groan_count = groan_count - counts.groans,
+    love_count = love_count - counts.loves
+FROM (
+    SELECT
+        joke_id,
+        count(*) FILTER (WHERE reaction = 'laugh')::integer AS laughs,
+        count(*) FILTER (WHERE reaction = 'groan')::integer AS groans,
+        count(*) FILTER (WHERE reaction = 'love')::integer AS loves
+    FROM joke_reactions
+    WHERE user_id = $1
+    GROUP BY joke_id
+) AS counts
+WHERE jokes.id = counts.joke_id
+`
+
+func (q *Queries) RemoveUserReactionCounts(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, removeUserReactionCounts, userID)
+	return err
+}
+
+const updateJokeReaction = `-- name: UpdateJokeReaction :one
+
+UPDATE joke_reactions
+SET reaction = $3, created_at = now()
+WHERE user_id = $1 AND joke_id = $2
+RETURNING user_id, joke_id, reaction, created_at
+`
+
+type UpdateJokeReactionParams struct {
+	UserID   int32  `json:"user_id"`
+	JokeID   int32  `json:"joke_id"`
+	Reaction string `json:"reaction"`
+}
+
+// UPDATE QUERIES
+func (q *Queries) UpdateJokeReaction(ctx context.Context, arg UpdateJokeReactionParams) (JokeReaction, error) {
+	row := q.db.QueryRowContext(ctx, updateJokeReaction, arg.UserID, arg.JokeID, arg.Reaction)
+	var i JokeReaction
+	err := row.Scan(
+		&i.UserID,
+		&i.JokeID,
+		&i.Reaction,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomJokeReaction(t *testing.T, userID, jokeID int32, reaction string) JokeReaction {
+	arg := CreateJokeReactionParams{
+		UserID:   userID,
+		JokeID:   jokeID,
+		Reaction: reaction,
+	}
+
+	jokeReaction, err := testQueries.CreateJokeReaction(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.UserID, jokeReaction.UserID)
+	require.Equal(t, arg.JokeID, jokeReaction.JokeID)
+	require.Equal(t, arg.Reaction, jokeReaction.Reaction)
+	require.NotZero(t, jokeReaction.CreatedAt)
+
+	return jokeReaction
+}
+
+func TestCreateJokeReactionOncePerUser(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	CreateRandomJokeReaction(t, user.ID, joke.ID, "laugh")
+
+	_, err := testQueries.CreateJokeReaction(context.Background(), CreateJokeReactionParams{
+		UserID:   user.ID,
+		JokeID:   joke.ID,
+		Reaction: "love",
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateJokeReaction(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	CreateRandomJokeReaction(t, user.ID, joke.ID, "laugh")
+
+	jokeReaction, err := testQueries.UpdateJokeReaction(context.Background(), UpdateJokeReactionParams{
+		UserID:   user.ID,
+		JokeID:   joke.ID,
+		Reaction: "groan",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "groan", jokeReaction.Reaction)
+
+	jokeReaction, err = testQueries.GetJokeReactionForUpdate(context.Background(), GetJokeReactionForUpdateParams{
+		UserID: user.ID,
+		JokeID: joke.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "groan", jokeReaction.Reaction)
+}
+
+func TestDeleteJokeReaction(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	CreateRandomJokeReaction(t, user.ID, joke.ID, "love")
+
+	arg := DeleteJokeReactionParams{UserID: user.ID, JokeID: joke.ID}
+	jokeReaction, err := testQueries.DeleteJokeReaction(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "love", jokeReaction.Reaction)
+
+	_, err = testQueries.DeleteJokeReaction(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAddJokeReactionCounts(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+
+	err := testQueries.AddJokeReactionCounts(context.Background(), AddJokeReactionCountsParams{
+		ID:     joke.ID,
+		Laughs: 2,
+		Loves:  1,
+	})
+	require.NoError(t, err)
+
+	jokeWithAuthor, err := testQueries.GetJoke(context.Background(), joke.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), jokeWithAuthor.LaughCount)
+	require.Zero(t, jokeWithAuthor.GroanCount)
+	require.Equal(t, int32(1), jokeWithAuthor.LoveCount)
+}
+
+func TestRemoveUserReactionCounts(t *testing.T) {
+	author := CreateRandomUser(t)
+	user := CreateRandomUser(t)
+	joke1 := CreateRandomJoke(t, author.ID)
+	joke2 := CreateRandomJoke(t, author.ID)
+
+	for _, jokeID := range []int32{joke1.ID, joke2.ID} {
+		err := testQueries.AddJokeReactionCounts(context.Background(), AddJokeReactionCountsParams{ID: jokeID, Laughs: 2})
+		require.NoError(t, err)
+	}
+	CreateRandomJokeReaction(t, user.ID, joke1.ID, "laugh")
+	CreateRandomJokeReaction(t, author.ID, joke2.ID, "laugh")
+
+	err := testQueries.RemoveUserReactionCounts(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	jokeWithAuthor, err := testQueries.GetJoke(context.Background(), joke1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), jokeWithAuthor.LaughCount)
+
+	jokeWithAuthor, err = testQueries.GetJoke(context.Background(), joke2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), jokeWithAuthor.LaughCount)
+}
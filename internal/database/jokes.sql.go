@@ -7,42 +7,52 @@ package database
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
 const createJoke = `-- name: CreateJoke :one
 INSERT INTO jokes (
-    author,
+    author_id,
     title,
     text,
-    explanation
+    explanation,
+    language
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING id, author, title, text, explanation, created_at, updated_at
+    $1, $2, $3, $4, $5
+) RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
 `
 
 type CreateJokeParams struct {
-	Author      string `json:"author"`
+	AuthorID    int32  `json:"author_id"`
 	Title       string `json:"title"`
 	Text        string `json:"text"`
 	Explanation string `json:"explanation"`
+	Language    string `json:"language"`
 }
 
 func (q *Queries) CreateJoke(ctx context.Context, arg CreateJokeParams) (Joke, error) {
 	row := q.db.QueryRowContext(ctx, createJoke,
-		arg.Author,
+		arg.AuthorID,
 		arg.Title,
 		arg.Text,
 		arg.Explanation,
+		arg.Language,
 	)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
-		&i.Author,
 		&i.Title,
 		&i.Text,
 		&i.Explanation,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
 	)
 	return i, err
 }
@@ -70,65 +80,116 @@ func (q *Queries) DeleteJoke(ctx context.Context, id int32) error {
 
 const deleteJokesByAuthor = `-- name: DeleteJokesByAuthor :exec
 DELETE FROM jokes
-WHERE author = $1
+WHERE author_id = $1
 `
 
-func (q *Queries) DeleteJokesByAuthor(ctx context.Context, author string) error {
-	_, err := q.db.ExecContext(ctx, deleteJokesByAuthor, author)
+func (q *Queries) DeleteJokesByAuthor(ctx context.Context, authorID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteJokesByAuthor, authorID)
 	return err
 }
 
+const deleteOwnedJoke = `-- name: DeleteOwnedJoke :execrows
+DELETE FROM jokes
+WHERE id = $1 AND author_id = $2
+`
+
+type DeleteOwnedJokeParams struct {
+	ID       int32 `json:"id"`
+	AuthorID int32 `json:"author_id"`
+}
+
+func (q *Queries) DeleteOwnedJoke(ctx context.Context, arg DeleteOwnedJokeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOwnedJoke, arg.ID, arg.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getJoke = `-- name: GetJoke :one
 
-SELECT id, author, title, text, explanation, created_at, updated_at FROM jokes
+SELECT id, author_id, author, title, text, explanation, created_at, updated_at, laugh_count, groan_count, love_count, comment_count, tags, language FROM jokes_with_authors
 WHERE id = $1 LIMIT 1
 `
 
 // GET QUERIES
-func (q *Queries) GetJoke(ctx context.Context, id int32) (Joke, error) {
+func (q *Queries) GetJoke(ctx context.Context, id int32) (JokesWithAuthor, error) {
 	row := q.db.QueryRowContext(ctx, getJoke, id)
-	var i Joke
+	var i JokesWithAuthor
 	err := row.Scan(
 		&i.ID,
+		&i.AuthorID,
 		&i.Author,
 		&i.Title,
 		&i.Text,
 		&i.Explanation,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.CommentCount,
+		pq.Array(&i.Tags),
+		&i.Language,
 	)
 	return i, err
 }
 
 const listJokes = `-- name: ListJokes :many
-SELECT id, author, title, text, explanation, created_at, updated_at FROM jokes
+SELECT id, author_id, author, title, text, explanation, created_at, updated_at, laugh_count, groan_count, love_count, comment_count, tags, language FROM jokes_with_authors
+WHERE (
+    coalesce(cardinality($1::varchar[]), 0) = 0 OR EXISTS (
+        SELECT 1 FROM joke_tags
+        JOIN tags ON tags.id = joke_tags.tag_id
+        WHERE joke_tags.joke_id = jokes_with_authors.id AND tags.name = ANY($1::varchar[])
+    )
+) AND (
+    SELECT count(*) FROM joke_tags
+    JOIN tags ON tags.id = joke_tags.tag_id
+    WHERE joke_tags.joke_id = jokes_with_authors.id AND tags.name = ANY($2::varchar[])
+) = coalesce(cardinality($2::varchar[]), 0)
 ORDER BY id
-LIMIT $1
-OFFSET $2
+LIMIT $4
+OFFSET $3
 `
 
 type ListJokesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AnyTags []string `json:"any_tags"`
+	AllTags []string `json:"all_tags"`
+	Offset  int32    `json:"offset_"`
+	Limit   int32    `json:"limit_"`
 }
 
-func (q *Queries) ListJokes(ctx context.Context, arg ListJokesParams) ([]Joke, error) {
-	rows, err := q.db.QueryContext(ctx, listJokes, arg.Limit, arg.Offset)
+// Jokes with any of any_tags and all of all_tags, empty or NULL arrays match all jokes
+func (q *Queries) ListJokes(ctx context.Context, arg ListJokesParams) ([]JokesWithAuthor, error) {
+	rows, err := q.db.QueryContext(ctx, listJokes,
+		pq.Array(arg.AnyTags),
+		pq.Array(arg.AllTags),
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Joke
+	var items []JokesWithAuthor
 	for rows.Next() {
-		var i Joke
+		var i JokesWithAuthor
 		if err := rows.Scan(
 			&i.ID,
+			&i.AuthorID,
 			&i.Author,
 			&i.Title,
 			&i.Text,
 			&i.Explanation,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LaughCount,
+			&i.GroanCount,
+			&i.LoveCount,
+			&i.CommentCount,
+			pq.Array(&i.Tags),
+			&i.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -144,7 +205,7 @@ func (q *Queries) ListJokes(ctx context.Context, arg ListJokesParams) ([]Joke, e
 }
 
 const listJokesByAuthor = `-- name: ListJokesByAuthor :many
-SELECT id, author, title, text, explanation, created_at, updated_at FROM jokes
+SELECT id, author_id, author, title, text, explanation, created_at, updated_at, laugh_count, groan_count, love_count, comment_count, tags, language FROM jokes_with_authors
 WHERE author = $1
 ORDER BY id
 LIMIT $2
@@ -157,23 +218,30 @@ type ListJokesByAuthorParams struct {
 	Offset int32  `json:"offset"`
 }
 
-func (q *Queries) ListJokesByAuthor(ctx context.Context, arg ListJokesByAuthorParams) ([]Joke, error) {
+func (q *Queries) ListJokesByAuthor(ctx context.Context, arg ListJokesByAuthorParams) ([]JokesWithAuthor, error) {
 	rows, err := q.db.QueryContext(ctx, listJokesByAuthor, arg.Author, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Joke
+	var items []JokesWithAuthor
 	for rows.Next() {
-		var i Joke
+		var i JokesWithAuthor
 		if err := rows.Scan(
 			&i.ID,
+			&i.AuthorID,
 			&i.Author,
 			&i.Title,
 			&i.Text,
 			&i.Explanation,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.LaughCount,
+			&i.GroanCount,
+			&i.LoveCount,
+			&i.CommentCount,
+			pq.Array(&i.Tags),
+			&i.Language,
 		); err != nil {
 			return nil, err
 		}
@@ -192,7 +260,7 @@ const updateJokeExplanation = `-- name: UpdateJokeExplanation :one
 UPDATE jokes
 SET explanation = $2
 WHERE id = $1
-RETURNING id, author, title, text, explanation, created_at, updated_at
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
 `
 
 type UpdateJokeExplanationParams struct {
@@ -205,12 +273,17 @@ func (q *Queries) UpdateJokeExplanation(ctx context.Context, arg UpdateJokeExpla
 	var i Joke
 	err := row.Scan(
 		&i.ID,
-		&i.Author,
 		&i.Title,
 		&i.Text,
 		&i.Explanation,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
 	)
 	return i, err
 }
@@ -219,7 +292,7 @@ const updateJokeText = `-- name: UpdateJokeText :one
 UPDATE jokes
 SET text = $2
 WHERE id = $1
-RETURNING id, author, title, text, explanation, created_at, updated_at
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
 `
 
 type UpdateJokeTextParams struct {
@@ -232,12 +305,17 @@ func (q *Queries) UpdateJokeText(ctx context.Context, arg UpdateJokeTextParams)
 	var i Joke
 	err := row.Scan(
 		&i.ID,
-		&i.Author,
 		&i.Title,
 		&i.Text,
 		&i.Explanation,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
 	)
 	return i, err
 }
@@ -247,7 +325,7 @@ const updateJokeTitle = `-- name: UpdateJokeTitle :one
 UPDATE jokes
 SET title = $2
 WHERE id = $1
-RETURNING id, author, title, text, explanation, created_at, updated_at
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
 `
 
 type UpdateJokeTitleParams struct {
@@ -261,12 +339,149 @@ func (q *Queries) UpdateJokeTitle(ctx context.Context, arg UpdateJokeTitleParams
 	var i Joke
 	err := row.Scan(
 		&i.ID,
-		&i.Author,
 		&i.Title,
 		&i.Text,
 		&i.Explanation,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const updateOwnedJokeExplanation = `-- name: UpdateOwnedJokeExplanation :one
+UPDATE jokes
+SET explanation = $3
+WHERE id = $1 AND author_id = $2
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
+`
+
+type UpdateOwnedJokeExplanationParams struct {
+	ID          int32  `json:"id"`
+	AuthorID    int32  `json:"author_id"`
+	Explanation string `json:"explanation"`
+}
+
+func (q *Queries) UpdateOwnedJokeExplanation(ctx context.Context, arg UpdateOwnedJokeExplanationParams) (Joke, error) {
+	row := q.db.QueryRowContext(ctx, updateOwnedJokeExplanation, arg.ID, arg.AuthorID, arg.Explanation)
+	var i Joke
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Text,
+		&i.Explanation,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const updateOwnedJokeLanguage = `-- name: UpdateOwnedJokeLanguage :one
+UPDATE jokes
+SET language = $3
+WHERE id = $1 AND author_id = $2
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
+`
+
+type UpdateOwnedJokeLanguageParams struct {
+	ID       int32  `json:"id"`
+	AuthorID int32  `json:"author_id"`
+	Language string `json:"language"`
+}
+
+func (q *Queries) UpdateOwnedJokeLanguage(ctx context.Context, arg UpdateOwnedJokeLanguageParams) (Joke, error) {
+	row := q.db.QueryRowContext(ctx, updateOwnedJokeLanguage, arg.ID, arg.AuthorID, arg.Language)
+	var i Joke
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Text,
+		&i.Explanation,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const updateOwnedJokeText = `-- name: UpdateOwnedJokeText :one
+UPDATE jokes
+SET text = $3
+WHERE id = $1 AND author_id = $2
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
+`
+
+type UpdateOwnedJokeTextParams struct {
+	ID       int32  `json:"id"`
+	AuthorID int32  `json:"author_id"`
+	Text     string `json:"text"`
+}
+
+func (q *Queries) UpdateOwnedJokeText(ctx context.Context, arg UpdateOwnedJokeTextParams) (Joke, error) {
+	row := q.db.QueryRowContext(ctx, updateOwnedJokeText, arg.ID, arg.AuthorID, arg.Text)
+	var i Joke
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Text,
+		&i.Explanation,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const updateOwnedJokeTitle = `-- name: UpdateOwnedJokeTitle :one
+UPDATE jokes
+SET title = $3
+WHERE id = $1 AND author_id = $2
+RETURNING id, title, text, explanation, created_at, updated_at, author_id, laugh_count, groan_count, love_count, language, search_vector
+`
+
+type UpdateOwnedJokeTitleParams struct {
+	ID       int32  `json:"id"`
+	AuthorID int32  `json:"author_id"`
+	Title    string `json:"title"`
+}
+
+func (q *Queries) UpdateOwnedJokeTitle(ctx context.Context, arg UpdateOwnedJokeTitleParams) (Joke, error) {
+	row := q.db.QueryRowContext(ctx, updateOwnedJokeTitle, arg.ID, arg.AuthorID, arg.Title)
+	var i Joke
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Text,
+		&i.Explanation,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.LaughCount,
+		&i.GroanCount,
+		&i.LoveCount,
+		&i.Language,
+		&i.SearchVector,
 	)
 	return i, err
 }
@@ -6,25 +6,28 @@ import (
 	"testing"
 	"time"
 
+	"github.com/abc_valera/flugo/internal/utils/search"
 	"github.com/stretchr/testify/require"
 )
 
-func CreateRandomJoke(t *testing.T, username string) Joke {
+func CreateRandomJoke(t *testing.T, authorID int32) Joke {
 	arg := CreateJokeParams{
-		Author:      username,
+		AuthorID:    authorID,
 		Title:       "my joke",
 		Text:        "funny joke :o",
 		Explanation: "pretty obvious",
+		Language:    search.English,
 	}
 
 	joke, err := testQueries.CreateJoke(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, joke)
 
-	require.Equal(t, arg.Author, joke.Author)
+	require.Equal(t, arg.AuthorID, joke.AuthorID)
 	require.Equal(t, arg.Title, joke.Title)
 	require.Equal(t, arg.Text, joke.Text)
 	require.Equal(t, arg.Explanation, joke.Explanation)
+	require.Equal(t, arg.Language, joke.Language)
 	require.NotZero(t, joke.ID)
 	require.NotZero(t, joke.CreatedAt)
 
@@ -33,17 +36,19 @@ func CreateRandomJoke(t *testing.T, username string) Joke {
 
 func TestCreateJoke(t *testing.T) {
 	user := CreateRandomUser(t)
-	CreateRandomJoke(t, user.Username)
+	CreateRandomJoke(t, user.ID)
 }
 
 func TestGetJoke(t *testing.T) {
 	user := CreateRandomUser(t)
-	joke1 := CreateRandomJoke(t, user.Username)
+	joke1 := CreateRandomJoke(t, user.ID)
 	joke2, err := testQueries.GetJoke(context.Background(), joke1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, joke2)
 
 	require.Equal(t, joke1.ID, joke2.ID)
+	require.Equal(t, user.ID, joke2.AuthorID)
+	require.Equal(t, user.Username, joke2.Author)
 	require.Equal(t, joke1.Title, joke2.Title)
 	require.Equal(t, joke1.Text, joke2.Text)
 	require.Equal(t, joke1.Explanation, joke2.Explanation)
@@ -55,7 +60,7 @@ func TestListJokesByAuthor(t *testing.T) {
 	user := CreateRandomUser(t)
 
 	for i := 0; i < 15; i++ {
-		CreateRandomJoke(t, user.Username)
+		CreateRandomJoke(t, user.ID)
 	}
 
 	arg := ListJokesByAuthorParams{
@@ -71,12 +76,13 @@ func TestListJokesByAuthor(t *testing.T) {
 	for _, joke := range jokes {
 		require.NotEmpty(t, joke)
 		require.Equal(t, joke.Author, user.Username)
+		require.Equal(t, joke.AuthorID, user.ID)
 	}
 }
 
 func TestDeleteJoke(t *testing.T) {
 	user := CreateRandomUser(t)
-	joke1 := CreateRandomJoke(t, user.Username)
+	joke1 := CreateRandomJoke(t, user.ID)
 	err := testQueries.DeleteJoke(context.Background(), joke1.ID)
 	require.NoError(t, err)
 
@@ -90,13 +96,13 @@ func TestDeleteJokesByAuthor(t *testing.T) {
 	user := CreateRandomUser(t)
 
 	for i := 0; i < 10; i++ {
-		CreateRandomJoke(t, user.Username)
+		CreateRandomJoke(t, user.ID)
 	}
 
-	err := testQueries.DeleteJokesByAuthor(context.Background(), user.Username)
+	err := testQueries.DeleteJokesByAuthor(context.Background(), user.ID)
 	require.NoError(t, err)
 
-	err = testQueries.DeleteJokesByAuthor(context.Background(), user.Username)
+	err = testQueries.DeleteJokesByAuthor(context.Background(), user.ID)
 	require.NoError(t, err)
 
 	arg := ListJokesByAuthorParams{
@@ -108,3 +114,60 @@ func TestDeleteJokesByAuthor(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, jokes)
 }
+
+func TestUpdateOwnedJokeTitle(t *testing.T) {
+	author := CreateRandomUser(t)
+	joke1 := CreateRandomJoke(t, author.ID)
+
+	joke2, err := testQueries.UpdateOwnedJokeTitle(context.Background(), UpdateOwnedJokeTitleParams{
+		ID:       joke1.ID,
+		AuthorID: author.ID,
+		Title:    "new title",
+	})
+	require.NoError(t, err)
+	require.Equal(t, joke1.ID, joke2.ID)
+	require.Equal(t, "new title", joke2.Title)
+
+	stranger := CreateRandomUser(t)
+	joke3, err := testQueries.UpdateOwnedJokeTitle(context.Background(), UpdateOwnedJokeTitleParams{
+		ID:       joke1.ID,
+		AuthorID: stranger.ID,
+		Title:    "stolen title",
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.Empty(t, joke3)
+}
+
+func TestDeleteOwnedJoke(t *testing.T) {
+	author := CreateRandomUser(t)
+	stranger := CreateRandomUser(t)
+	joke1 := CreateRandomJoke(t, author.ID)
+
+	rows, err := testQueries.DeleteOwnedJoke(context.Background(), DeleteOwnedJokeParams{
+		ID:       joke1.ID,
+		AuthorID: stranger.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.DeleteOwnedJoke(context.Background(), DeleteOwnedJokeParams{
+		ID:       joke1.ID,
+		AuthorID: author.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = testQueries.GetJoke(context.Background(), joke1.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestDeleteUserDeletesJokes(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke1 := CreateRandomJoke(t, user.ID)
+
+	err := testQueries.DeleteUser(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetJoke(context.Background(), joke1.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,16 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/lockout"
+	"github.com/abc_valera/flugo/internal/utils/lockout/lockouttest"
+)
+
+// The Postgres tracker lives in package lockout, which imports database, so the test is external
+func TestPostgresTracker(t *testing.T) {
+	lockouttest.TestTracker(t, func(t *testing.T) lockout.Tracker {
+		return lockout.NewPostgresTracker(database.QueriesForTest(), lockouttest.Policy)
+	})
+}
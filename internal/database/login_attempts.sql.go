@@ -0,0 +1,107 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: login_attempts.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const deleteLoginAttempt = `-- name: DeleteLoginAttempt :exec
+
+DELETE FROM login_attempts
+WHERE key = $1
+`
+
+// DELETE QUERIES
+func (q *Queries) DeleteLoginAttempt(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginAttempt, key)
+	return err
+}
+
+const deleteStaleLoginAttempts = `-- name: DeleteStaleLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE last_failed_at < $1 AND locked_until < $1
+`
+
+func (q *Queries) DeleteStaleLoginAttempts(ctx context.Context, lastFailedAt time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteStaleLoginAttempts, lastFailedAt)
+	return err
+}
+
+const getLoginAttempt = `-- name: GetLoginAttempt :one
+
+SELECT key, failures, last_failed_at, locked_until FROM login_attempts
+WHERE key = $1 LIMIT 1
+`
+
+// GET QUERIES
+func (q *Queries) GetLoginAttempt(ctx context.Context, key string) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, getLoginAttempt, key)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const lockLoginAttempt = `-- name: LockLoginAttempt :exec
+UPDATE login_attempts
+SET locked_until = GREATEST(locked_until, $1)
+WHERE key = $2
+`
+
+type LockLoginAttemptParams struct {
+	LockedUntil time.Time `json:"locked_until"`
+	Key         string    `json:"key"`
+}
+
+func (q *Queries) LockLoginAttempt(ctx context.Context, arg LockLoginAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, lockLoginAttempt, arg.LockedUntil, arg.Key)
+	return err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+
+INSERT INTO login_attempts (
+    key,
+    failures,
+    last_failed_at,
+    locked_until
+) VALUES (
+    $1, 1, $2, $2
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_attempts.last_failed_at < $3 THEN 1
+        ELSE login_attempts.failures + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at
+RETURNING key, failures, last_failed_at, locked_until
+`
+
+type RecordLoginFailureParams struct {
+	Key         string    `json:"key"`
+	FailedAt    time.Time `json:"failed_at"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+// UPDATE QUERIES
+// The counter starts over if the last failure is older than the window
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Key, arg.FailedAt, arg.WindowStart)
+	var i LoginAttempt
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
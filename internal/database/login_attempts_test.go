@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := "account:" + random.RandomEmail()
+	now := time.Now().Truncate(time.Microsecond)
+
+	for i := 1; i <= 3; i++ {
+		attempt, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+			Key:         key,
+			FailedAt:    now,
+			WindowStart: now.Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), attempt.Failures)
+		require.WithinDuration(t, now, attempt.LastFailedAt, time.Second)
+	}
+
+	// The counter starts over once the last failure is out of the window
+	later := now.Add(2 * time.Hour)
+	attempt, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    later,
+		WindowStart: later.Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.Failures)
+}
+
+func TestLockLoginAttempt(t *testing.T) {
+	key := "ip:" + random.RandomString(12)
+	now := time.Now()
+
+	_, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    now,
+		WindowStart: now.Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.LockLoginAttempt(context.Background(), LockLoginAttemptParams{Key: key, LockedUntil: now.Add(time.Minute)})
+	require.NoError(t, err)
+
+	// Shorter lockouts don't cut the longer one
+	err = testQueries.LockLoginAttempt(context.Background(), LockLoginAttemptParams{Key: key, LockedUntil: now.Add(time.Second)})
+	require.NoError(t, err)
+
+	attempt, err := testQueries.GetLoginAttempt(context.Background(), key)
+	require.NoError(t, err)
+	require.WithinDuration(t, now.Add(time.Minute), attempt.LockedUntil, time.Second)
+
+	err = testQueries.DeleteLoginAttempt(context.Background(), key)
+	require.NoError(t, err)
+	_, err = testQueries.GetLoginAttempt(context.Background(), key)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
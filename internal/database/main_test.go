@@ -6,18 +6,21 @@ import (
 	"os"
 	"testing"
 
-	"github.com/abc_valera/flugo/internal/config"
+	"github.com/abc_valera/flugo/internal/utils/config"
 	_ "github.com/lib/pq"
 )
 
 var testQueries *Queries
+var testStore Store
+var testDatabaseURL string
 
 func TestMain(m *testing.M) {
-	config, err := config.LoadConfig("./..")
+	config, err := config.LoadConfig("../..")
 	if err != nil {
 		log.Fatal("cannot load config: ", err)
 	}
 
+	testDatabaseURL = config.DatabaseUrl
 	conn, err := sql.Open(config.DatabaseDriver, config.DatabaseUrl)
 	if err != nil {
 		log.Fatal("cannot connect to db: ", err)
@@ -27,7 +30,18 @@ func TestMain(m *testing.M) {
 		log.Fatal("cannot connect to db: ", err)
 	}
 
+	migrator, err := NewMigrator(config.DatabaseUrl)
+	if err != nil {
+		log.Fatal("cannot init migrations: ", err)
+	}
+	err = migrator.Up()
+	if err != nil {
+		log.Fatal("cannot migrate db: ", err)
+	}
+	migrator.Close()
+
 	testQueries = New(conn)
+	testStore = NewStore(conn)
 
 	os.Exit(m.Run())
 }
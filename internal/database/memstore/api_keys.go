@@ -0,0 +1,95 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error) {
+	defer s.lock()()
+
+	for _, k := range s.t.apiKeys {
+		if k.Prefix == arg.Prefix {
+			return database.ApiKey{}, uniqueViolation("api_keys", "api_keys_prefix_key")
+		}
+		if k.KeyHash == arg.KeyHash {
+			return database.ApiKey{}, uniqueViolation("api_keys", "api_keys_key_hash_key")
+		}
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.ApiKey{}, foreignKeyViolation("api_keys", "api_keys_user_id_fkey")
+	}
+
+	s.seq.apiKeys++
+	apiKey := database.ApiKey{
+		ID:        s.seq.apiKeys,
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		CreatedAt: now(),
+		Scopes:    cloneStrings(arg.Scopes),
+	}
+	s.t.apiKeys = append(s.t.apiKeys, apiKey)
+	return cloneAPIKey(apiKey), nil
+}
+
+func cloneAPIKey(k database.ApiKey) database.ApiKey {
+	k.Scopes = cloneStrings(k.Scopes)
+	return k
+}
+
+// GET QUERIES
+
+func (s *Store) ListAPIKeysByUser(ctx context.Context, userID int32) ([]database.ApiKey, error) {
+	defer s.lock()()
+	var apiKeys []database.ApiKey
+	for _, k := range s.t.apiKeys {
+		if k.UserID == userID {
+			apiKeys = append(apiKeys, cloneAPIKey(k))
+		}
+	}
+	return newestFirst(apiKeys, func(k database.ApiKey) time.Time { return k.CreatedAt }), nil
+}
+
+// UPDATE QUERIES
+
+func (s *Store) UseAPIKey(ctx context.Context, keyHash string) (database.UseAPIKeyRow, error) {
+	defer s.lock()()
+	for i := range s.t.apiKeys {
+		k := &s.t.apiKeys[i]
+		if k.KeyHash != keyHash || k.IsRevoked {
+			continue
+		}
+		user, err := s.t.findUser(func(u database.User) bool { return u.ID == k.UserID })
+		if err != nil {
+			return database.UseAPIKeyRow{}, err
+		}
+		k.LastUsedAt = sql.NullTime{Time: now(), Valid: true}
+		return database.UseAPIKeyRow{
+			ID:       k.ID,
+			UserID:   k.UserID,
+			Scopes:   cloneStrings(k.Scopes),
+			Username: user.Username,
+			Email:    user.Email,
+			Role:     user.Role,
+			IsBanned: user.IsBanned,
+		}, nil
+	}
+	return database.UseAPIKeyRow{}, sql.ErrNoRows
+}
+
+func (s *Store) RevokeOwnedAPIKey(ctx context.Context, arg database.RevokeOwnedAPIKeyParams) (int64, error) {
+	defer s.lock()()
+	for i := range s.t.apiKeys {
+		k := &s.t.apiKeys[i]
+		if k.ID == arg.ID && k.UserID == arg.UserID {
+			k.IsRevoked = true
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,55 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+// Audit events are append-only, there are no update or delete queries
+
+func (s *Store) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	defer s.lock()()
+
+	s.seq.auditEvents++
+	s.t.auditEvents = append(s.t.auditEvents, database.AuditEvent{
+		ID:        s.seq.auditEvents,
+		Type:      arg.Type,
+		Outcome:   arg.Outcome,
+		UserID:    arg.UserID,
+		ActorID:   arg.ActorID,
+		ClientIp:  arg.ClientIp,
+		UserAgent: arg.UserAgent,
+		Details:   arg.Details,
+		CreatedAt: now(),
+	})
+	return nil
+}
+
+// GET QUERIES
+
+// Returns the matching events sorted by created_at and id, newest first
+func (t *tables) listAuditEvents(match func(database.AuditEvent) bool, limit, offset int32) []database.AuditEvent {
+	// Ids grow with the insertion
+	events := newestFirst(filter(t.auditEvents, match), func(e database.AuditEvent) time.Time { return e.CreatedAt })
+	return page(events, limit, offset)
+}
+
+func (s *Store) ListAuditEventsByUser(ctx context.Context, arg database.ListAuditEventsByUserParams) ([]database.AuditEvent, error) {
+	defer s.lock()()
+	// NULL user_id matches no events
+	return s.t.listAuditEvents(func(e database.AuditEvent) bool {
+		return arg.UserID.Valid && e.UserID.Valid && e.UserID.Int32 == arg.UserID.Int32
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	defer s.lock()()
+	return s.t.listAuditEvents(func(e database.AuditEvent) bool {
+		return (!arg.UserID.Valid || e.UserID.Valid && e.UserID.Int32 == arg.UserID.Int32) &&
+			(!arg.Type.Valid || e.Type == arg.Type.String) &&
+			(!arg.Since.Valid || !e.CreatedAt.Before(arg.Since.Time)) &&
+			(!arg.Until.Valid || e.CreatedAt.Before(arg.Until.Time))
+	}, arg.Limit, arg.Offset), nil
+}
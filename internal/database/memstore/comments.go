@@ -0,0 +1,197 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateComment(ctx context.Context, arg database.CreateCommentParams) (database.Comment, error) {
+	defer s.lock()()
+
+	if !s.t.jokeExists(arg.JokeID) {
+		return database.Comment{}, foreignKeyViolation("comments", "comments_joke_id_fkey")
+	}
+	if !s.t.userExists(arg.AuthorID) {
+		return database.Comment{}, foreignKeyViolation("comments", "comments_author_id_fkey")
+	}
+	if arg.ParentID.Valid && !s.t.commentExists(arg.ParentID.Int32) {
+		return database.Comment{}, foreignKeyViolation("comments", "comments_parent_id_fkey")
+	}
+	if arg.RootID.Valid && !s.t.commentExists(arg.RootID.Int32) {
+		return database.Comment{}, foreignKeyViolation("comments", "comments_root_id_fkey")
+	}
+
+	s.seq.comments++
+	createdAt := now()
+	comment := database.Comment{
+		ID:        s.seq.comments,
+		JokeID:    arg.JokeID,
+		AuthorID:  sql.NullInt32{Int32: arg.AuthorID, Valid: true},
+		ParentID:  arg.ParentID,
+		RootID:    arg.RootID,
+		Text:      arg.Text,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	s.t.comments = append(s.t.comments, comment)
+	return comment, nil
+}
+
+func (t *tables) commentExists(id int32) bool {
+	for _, c := range t.comments {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GET QUERIES
+
+// Returns the rows of the comments_with_authors view, sorted by id.
+// Tombstones have no author.
+func (t *tables) commentsWithAuthors(match func(database.Comment) bool) []database.CommentsWithAuthor {
+	usernames := make(map[int32]string, len(t.users))
+	for _, u := range t.users {
+		usernames[u.ID] = u.Username
+	}
+
+	var rows []database.CommentsWithAuthor
+	for _, c := range t.comments {
+		if !match(c) {
+			continue
+		}
+		var author sql.NullString
+		if c.AuthorID.Valid {
+			author = sql.NullString{String: usernames[c.AuthorID.Int32], Valid: true}
+		}
+		rows = append(rows, database.CommentsWithAuthor{
+			ID:        c.ID,
+			JokeID:    c.JokeID,
+			ParentID:  c.ParentID,
+			RootID:    c.RootID,
+			AuthorID:  c.AuthorID,
+			Author:    author,
+			Text:      c.Text,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+		})
+	}
+	return rows
+}
+
+func (s *Store) GetComment(ctx context.Context, id int32) (database.CommentsWithAuthor, error) {
+	defer s.lock()()
+	rows := s.t.commentsWithAuthors(func(c database.Comment) bool { return c.ID == id })
+	if len(rows) == 0 {
+		return database.CommentsWithAuthor{}, sql.ErrNoRows
+	}
+	return rows[0], nil
+}
+
+func (s *Store) ListComments(ctx context.Context, arg database.ListCommentsParams) ([]database.CommentsWithAuthor, error) {
+	defer s.lock()()
+	rows := s.t.commentsWithAuthors(func(c database.Comment) bool {
+		return c.JokeID == arg.JokeID && !c.ParentID.Valid && c.ID > arg.AfterID
+	})
+	return page(rows, arg.Limit, 0), nil
+}
+
+func (s *Store) ListCommentReplies(ctx context.Context, arg database.ListCommentRepliesParams) ([]database.CommentsWithAuthor, error) {
+	defer s.lock()()
+	roots := make(map[int32]int32, len(arg.RootIds))
+	for _, id := range arg.RootIds {
+		roots[id] = 0
+	}
+	return s.t.commentsWithAuthors(func(c database.Comment) bool {
+		if !c.RootID.Valid {
+			return false
+		}
+		listed, ok := roots[c.RootID.Int32]
+		if !ok || listed >= arg.Limit {
+			return false
+		}
+		roots[c.RootID.Int32]++
+		return true
+	}), nil
+}
+
+func (s *Store) ListThreadReplies(ctx context.Context, arg database.ListThreadRepliesParams) ([]database.CommentsWithAuthor, error) {
+	defer s.lock()()
+	rows := s.t.commentsWithAuthors(func(c database.Comment) bool {
+		return c.RootID == arg.RootID && c.ID > arg.AfterID
+	})
+	return page(rows, arg.Limit, 0), nil
+}
+
+// UPDATE QUERIES
+
+func (s *Store) UpdateOwnedCommentText(ctx context.Context, arg database.UpdateOwnedCommentTextParams) (database.Comment, error) {
+	defer s.lock()()
+	for i := range s.t.comments {
+		c := &s.t.comments[i]
+		if c.ID == arg.ID && c.JokeID == arg.JokeID && c.AuthorID.Valid && c.AuthorID.Int32 == arg.AuthorID {
+			c.Text = arg.Text
+			c.UpdatedAt = now()
+			return *c, nil
+		}
+	}
+	return database.Comment{}, sql.ErrNoRows
+}
+
+func (t *tables) hasReplies(id int32) bool {
+	for _, c := range t.comments {
+		if c.ParentID.Valid && c.ParentID.Int32 == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Turns the comment into a tombstone, like setting the author to NULL does with the trigger
+func tombstone(c *database.Comment) {
+	c.AuthorID = sql.NullInt32{}
+	c.Text = ""
+}
+
+func (s *Store) TombstoneOwnedComment(ctx context.Context, arg database.TombstoneOwnedCommentParams) (int64, error) {
+	defer s.lock()()
+	for i := range s.t.comments {
+		c := &s.t.comments[i]
+		if c.ID == arg.ID && c.JokeID == arg.JokeID && c.AuthorID.Valid && c.AuthorID.Int32 == arg.AuthorID && s.t.hasReplies(c.ID) {
+			tombstone(c)
+			c.UpdatedAt = now()
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// DELETE QUERIES
+
+// Deletes the first matching comment without replies, returns the comment it answers
+func (t *tables) deleteLeafComment(match func(database.Comment) bool) (sql.NullInt32, error) {
+	for i, c := range t.comments {
+		if match(c) && !t.hasReplies(c.ID) {
+			t.comments = append(t.comments[:i:i], t.comments[i+1:]...)
+			return c.ParentID, nil
+		}
+	}
+	return sql.NullInt32{}, sql.ErrNoRows
+}
+
+func (s *Store) DeleteOwnedComment(ctx context.Context, arg database.DeleteOwnedCommentParams) (sql.NullInt32, error) {
+	defer s.lock()()
+	return s.t.deleteLeafComment(func(c database.Comment) bool {
+		return c.ID == arg.ID && c.JokeID == arg.JokeID && c.AuthorID.Valid && c.AuthorID.Int32 == arg.AuthorID
+	})
+}
+
+func (s *Store) DeleteEmptyTombstone(ctx context.Context, id int32) (sql.NullInt32, error) {
+	defer s.lock()()
+	return s.t.deleteLeafComment(func(c database.Comment) bool {
+		return c.ID == id && !c.AuthorID.Valid
+	})
+}
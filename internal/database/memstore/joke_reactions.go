@@ -0,0 +1,117 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateJokeReaction(ctx context.Context, arg database.CreateJokeReactionParams) (database.JokeReaction, error) {
+	defer s.lock()()
+
+	if _, ok := s.t.findJokeReaction(arg.UserID, arg.JokeID); ok {
+		return database.JokeReaction{}, uniqueViolation("joke_reactions", "joke_reactions_pkey")
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.JokeReaction{}, foreignKeyViolation("joke_reactions", "joke_reactions_user_id_fkey")
+	}
+	if !s.t.jokeExists(arg.JokeID) {
+		return database.JokeReaction{}, foreignKeyViolation("joke_reactions", "joke_reactions_joke_id_fkey")
+	}
+
+	reaction := database.JokeReaction{
+		UserID:    arg.UserID,
+		JokeID:    arg.JokeID,
+		Reaction:  arg.Reaction,
+		CreatedAt: now(),
+	}
+	s.t.jokeReactions = append(s.t.jokeReactions, reaction)
+	return reaction, nil
+}
+
+// GET QUERIES
+
+// Returns the index of the reaction of the user to the joke
+func (t *tables) findJokeReaction(userID, jokeID int32) (int, bool) {
+	for i, r := range t.jokeReactions {
+		if r.UserID == userID && r.JokeID == jokeID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Rows aren't locked, as transactions hold the lock of the whole store
+func (s *Store) GetJokeReactionForUpdate(ctx context.Context, arg database.GetJokeReactionForUpdateParams) (database.JokeReaction, error) {
+	defer s.lock()()
+	i, ok := s.t.findJokeReaction(arg.UserID, arg.JokeID)
+	if !ok {
+		return database.JokeReaction{}, sql.ErrNoRows
+	}
+	return s.t.jokeReactions[i], nil
+}
+
+// UPDATE QUERIES
+
+func (s *Store) UpdateJokeReaction(ctx context.Context, arg database.UpdateJokeReactionParams) (database.JokeReaction, error) {
+	defer s.lock()()
+	i, ok := s.t.findJokeReaction(arg.UserID, arg.JokeID)
+	if !ok {
+		return database.JokeReaction{}, sql.ErrNoRows
+	}
+	r := &s.t.jokeReactions[i]
+	r.Reaction = arg.Reaction
+	r.CreatedAt = now()
+	return *r, nil
+}
+
+func (s *Store) AddJokeReactionCounts(ctx context.Context, arg database.AddJokeReactionCountsParams) error {
+	defer s.lock()()
+	for i := range s.t.jokes {
+		j := &s.t.jokes[i]
+		if j.ID == arg.ID {
+			j.LaughCount += arg.Laughs
+			j.GroanCount += arg.Groans
+			j.LoveCount += arg.Loves
+		}
+	}
+	return nil
+}
+
+func (s *Store) RemoveUserReactionCounts(ctx context.Context, userID int32) error {
+	defer s.lock()()
+	reactions := make(map[int32]string)
+	for _, r := range s.t.jokeReactions {
+		if r.UserID == userID {
+			reactions[r.JokeID] = r.Reaction
+		}
+	}
+	for i := range s.t.jokes {
+		j := &s.t.jokes[i]
+		switch reactions[j.ID] {
+		case "laugh":
+			j.LaughCount--
+		case "groan":
+			j.GroanCount--
+		case "love":
+			j.LoveCount--
+		}
+	}
+	return nil
+}
+
+// DELETE QUERIES
+
+func (s *Store) DeleteJokeReaction(ctx context.Context, arg database.DeleteJokeReactionParams) (database.JokeReaction, error) {
+	defer s.lock()()
+	i, ok := s.t.findJokeReaction(arg.UserID, arg.JokeID)
+	if !ok {
+		return database.JokeReaction{}, sql.ErrNoRows
+	}
+	reaction := s.t.jokeReactions[i]
+	s.t.jokeReactions = filter(s.t.jokeReactions, func(r database.JokeReaction) bool {
+		return r.UserID != arg.UserID || r.JokeID != arg.JokeID
+	})
+	return reaction, nil
+}
@@ -0,0 +1,203 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/search"
+)
+
+func (s *Store) CreateJoke(ctx context.Context, arg database.CreateJokeParams) (database.Joke, error) {
+	defer s.lock()()
+
+	if !search.IsLanguage(arg.Language) {
+		return database.Joke{}, undefinedTextSearchConfig(arg.Language)
+	}
+	if !s.t.userExists(arg.AuthorID) {
+		return database.Joke{}, foreignKeyViolation("jokes", "jokes_author_id_fkey")
+	}
+
+	s.seq.jokes++
+	createdAt := now()
+	joke := database.Joke{
+		ID:          s.seq.jokes,
+		Title:       arg.Title,
+		Text:        arg.Text,
+		Explanation: arg.Explanation,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+		AuthorID:    arg.AuthorID,
+		Language:    arg.Language,
+	}
+	s.t.jokes = append(s.t.jokes, joke)
+	return joke, nil
+}
+
+// GET QUERIES
+
+// Returns the rows of the jokes_with_authors view, sorted by id
+func (t *tables) jokesWithAuthors() []database.JokesWithAuthor {
+	usernames := make(map[int32]string, len(t.users))
+	for _, u := range t.users {
+		usernames[u.ID] = u.Username
+	}
+
+	comments := make(map[int32]int32)
+	for _, c := range t.comments {
+		// Tombstones aren't counted
+		if c.AuthorID.Valid {
+			comments[c.JokeID]++
+		}
+	}
+	tags := t.jokeTagNames()
+
+	var rows []database.JokesWithAuthor
+	for _, j := range t.jokes {
+		author, ok := usernames[j.AuthorID]
+		if !ok {
+			continue
+		}
+		rows = append(rows, database.JokesWithAuthor{
+			ID:           j.ID,
+			AuthorID:     j.AuthorID,
+			Author:       author,
+			Title:        j.Title,
+			Text:         j.Text,
+			Explanation:  j.Explanation,
+			CreatedAt:    j.CreatedAt,
+			UpdatedAt:    j.UpdatedAt,
+			LaughCount:   j.LaughCount,
+			GroanCount:   j.GroanCount,
+			LoveCount:    j.LoveCount,
+			CommentCount: comments[j.ID],
+			// ARRAY() of no rows is an empty array, not NULL
+			Tags:     append([]string{}, tags[j.ID]...),
+			Language: j.Language,
+		})
+	}
+	return rows
+}
+
+func (s *Store) GetJoke(ctx context.Context, id int32) (database.JokesWithAuthor, error) {
+	defer s.lock()()
+	for _, j := range s.t.jokesWithAuthors() {
+		if j.ID == id {
+			return j, nil
+		}
+	}
+	return database.JokesWithAuthor{}, sql.ErrNoRows
+}
+
+func (s *Store) ListJokes(ctx context.Context, arg database.ListJokesParams) ([]database.JokesWithAuthor, error) {
+	defer s.lock()()
+	rows := filter(s.t.jokesWithAuthors(), func(j database.JokesWithAuthor) bool {
+		has := make(map[string]bool, len(j.Tags))
+		for _, tag := range j.Tags {
+			has[tag] = true
+		}
+		for _, tag := range arg.AllTags {
+			if !has[tag] {
+				return false
+			}
+		}
+		for _, tag := range arg.AnyTags {
+			if has[tag] {
+				return true
+			}
+		}
+		return len(arg.AnyTags) == 0
+	})
+	return page(rows, arg.Limit, arg.Offset), nil
+}
+
+func (s *Store) ListJokesByAuthor(ctx context.Context, arg database.ListJokesByAuthorParams) ([]database.JokesWithAuthor, error) {
+	defer s.lock()()
+	rows := filter(s.t.jokesWithAuthors(), func(j database.JokesWithAuthor) bool { return j.Author == arg.Author })
+	return page(rows, arg.Limit, arg.Offset), nil
+}
+
+// UPDATE QUERIES
+
+// Changes the joke with the given id if it is written by the author, or by anyone if authorID is zero
+func (s *Store) updateJoke(id, authorID int32, set func(*database.Joke)) (database.Joke, error) {
+	defer s.lock()()
+	for i := range s.t.jokes {
+		j := &s.t.jokes[i]
+		if j.ID == id && (authorID == 0 || j.AuthorID == authorID) {
+			set(j)
+			return *j, nil
+		}
+	}
+	return database.Joke{}, sql.ErrNoRows
+}
+
+func (s *Store) UpdateJokeTitle(ctx context.Context, arg database.UpdateJokeTitleParams) (database.Joke, error) {
+	return s.updateJoke(arg.ID, 0, func(j *database.Joke) { j.Title = arg.Title })
+}
+
+func (s *Store) UpdateJokeText(ctx context.Context, arg database.UpdateJokeTextParams) (database.Joke, error) {
+	return s.updateJoke(arg.ID, 0, func(j *database.Joke) { j.Text = arg.Text })
+}
+
+func (s *Store) UpdateJokeExplanation(ctx context.Context, arg database.UpdateJokeExplanationParams) (database.Joke, error) {
+	return s.updateJoke(arg.ID, 0, func(j *database.Joke) { j.Explanation = arg.Explanation })
+}
+
+func (s *Store) UpdateOwnedJokeTitle(ctx context.Context, arg database.UpdateOwnedJokeTitleParams) (database.Joke, error) {
+	return s.updateJoke(arg.ID, arg.AuthorID, func(j *database.Joke) { j.Title = arg.Title })
+}
+
+func (s *Store) UpdateOwnedJokeText(ctx context.Context, arg database.UpdateOwnedJokeTextParams) (database.Joke, error) {
+	return s.updateJoke(arg.ID, arg.AuthorID, func(j *database.Joke) { j.Text = arg.Text })
+}
+
+func (s *Store) UpdateOwnedJokeExplanation(ctx context.Context, arg database.UpdateOwnedJokeExplanationParams) (database.Joke, error) {
+	return s.updateJoke(arg.ID, arg.AuthorID, func(j *database.Joke) { j.Explanation = arg.Explanation })
+}
+
+func (s *Store) UpdateOwnedJokeLanguage(ctx context.Context, arg database.UpdateOwnedJokeLanguageParams) (database.Joke, error) {
+	if !search.IsLanguage(arg.Language) {
+		return database.Joke{}, undefinedTextSearchConfig(arg.Language)
+	}
+	return s.updateJoke(arg.ID, arg.AuthorID, func(j *database.Joke) { j.Language = arg.Language })
+}
+
+// DELETE QUERIES
+
+// Deletes the matching jokes along with the rows that reference them, returns the number of deleted jokes
+func (t *tables) deleteJokes(match func(database.Joke) bool) int {
+	deleted := make(map[int32]bool)
+	t.jokes = filter(t.jokes, func(j database.Joke) bool {
+		if match(j) {
+			deleted[j.ID] = true
+			return false
+		}
+		return true
+	})
+	t.cascadeJokes(deleted)
+	return len(deleted)
+}
+
+func (s *Store) DeleteJoke(ctx context.Context, id int32) error {
+	defer s.lock()()
+	s.t.deleteJokes(func(j database.Joke) bool { return j.ID == id })
+	return nil
+}
+
+func (s *Store) DeleteOwnedJoke(ctx context.Context, arg database.DeleteOwnedJokeParams) (int64, error) {
+	defer s.lock()()
+	return int64(s.t.deleteJokes(func(j database.Joke) bool { return j.ID == arg.ID && j.AuthorID == arg.AuthorID })), nil
+}
+
+func (s *Store) DeleteJokesByAuthor(ctx context.Context, authorID int32) error {
+	defer s.lock()()
+	s.t.deleteJokes(func(j database.Joke) bool { return j.AuthorID == authorID })
+	return nil
+}
+
+func (s *Store) DeleteAllJokes(ctx context.Context) error {
+	defer s.lock()()
+	s.t.deleteJokes(func(j database.Joke) bool { return true })
+	return nil
+}
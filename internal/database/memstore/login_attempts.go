@@ -0,0 +1,77 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+// GET QUERIES
+
+func (s *Store) GetLoginAttempt(ctx context.Context, key string) (database.LoginAttempt, error) {
+	defer s.lock()()
+	for _, a := range s.t.loginAttempts {
+		if a.Key == key {
+			return a, nil
+		}
+	}
+	return database.LoginAttempt{}, sql.ErrNoRows
+}
+
+// UPDATE QUERIES
+
+func (s *Store) RecordLoginFailure(ctx context.Context, arg database.RecordLoginFailureParams) (database.LoginAttempt, error) {
+	defer s.lock()()
+
+	for i := range s.t.loginAttempts {
+		a := &s.t.loginAttempts[i]
+		if a.Key != arg.Key {
+			continue
+		}
+		if a.LastFailedAt.Before(arg.WindowStart) {
+			a.Failures = 1
+		} else {
+			a.Failures++
+		}
+		a.LastFailedAt = arg.FailedAt
+		return *a, nil
+	}
+
+	attempt := database.LoginAttempt{
+		Key:          arg.Key,
+		Failures:     1,
+		LastFailedAt: arg.FailedAt,
+		LockedUntil:  arg.FailedAt,
+	}
+	s.t.loginAttempts = append(s.t.loginAttempts, attempt)
+	return attempt, nil
+}
+
+func (s *Store) LockLoginAttempt(ctx context.Context, arg database.LockLoginAttemptParams) error {
+	defer s.lock()()
+	for i := range s.t.loginAttempts {
+		a := &s.t.loginAttempts[i]
+		if a.Key == arg.Key && arg.LockedUntil.After(a.LockedUntil) {
+			a.LockedUntil = arg.LockedUntil
+		}
+	}
+	return nil
+}
+
+// DELETE QUERIES
+
+func (s *Store) DeleteLoginAttempt(ctx context.Context, key string) error {
+	defer s.lock()()
+	s.t.loginAttempts = filter(s.t.loginAttempts, func(a database.LoginAttempt) bool { return a.Key != key })
+	return nil
+}
+
+func (s *Store) DeleteStaleLoginAttempts(ctx context.Context, lastFailedAt time.Time) error {
+	defer s.lock()()
+	s.t.loginAttempts = filter(s.t.loginAttempts, func(a database.LoginAttempt) bool {
+		return !a.LastFailedAt.Before(lastFailedAt) || !a.LockedUntil.Before(lastFailedAt)
+	})
+	return nil
+}
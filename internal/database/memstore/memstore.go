@@ -0,0 +1,274 @@
+// Package memstore keeps the tables of the database in memory, so the handlers can be tested without Postgres.
+// The queries behave like the generated ones: unique and foreign key constraints are checked
+// and return *pq.Error with the Postgres codes, missing rows are sql.ErrNoRows and deletes cascade.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/lib/pq"
+)
+
+// Store is the in-memory database.Store
+type Store struct {
+	// nil for the stores of transactions, which are locked by the parent store
+	mu  *sync.Mutex
+	seq *sequences
+	t   *tables
+}
+
+var _ database.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		mu:  new(sync.Mutex),
+		seq: new(sequences),
+		t:   new(tables),
+	}
+}
+
+// Sequences of the serial columns. Like in Postgres, they aren't rolled back with transactions.
+type sequences struct {
+	users           int32
+	jokes           int32
+	verifyEmails    int64
+	passwordResets  int64
+	recoveryCodes   int64
+	apiKeys         int64
+	auditEvents     int64
+	usernameHistory int64
+	comments        int32
+	tags            int32
+}
+
+// Rows are kept in the order of insertion
+type tables struct {
+	users           []database.User
+	jokes           []database.Joke
+	sessions        []database.Session
+	verifyEmails    []database.VerifyEmail
+	passwordResets  []database.PasswordReset
+	recoveryCodes   []database.RecoveryCode
+	loginChallenges []database.LoginChallenge
+	loginAttempts   []database.LoginAttempt
+	apiKeys         []database.ApiKey
+	oauthClients    []database.OauthClient
+	oauthCodes      []database.OauthCode
+	auditEvents     []database.AuditEvent
+	usernameHistory []database.UsernameHistory
+	jokeReactions   []database.JokeReaction
+	comments        []database.Comment
+	tags            []database.Tag
+	jokeTags        []database.JokeTag
+}
+
+// Rows are copied by value and their slices are never changed in place, so copying the tables is enough
+func (t *tables) clone() *tables {
+	return &tables{
+		users:           append([]database.User(nil), t.users...),
+		jokes:           append([]database.Joke(nil), t.jokes...),
+		sessions:        append([]database.Session(nil), t.sessions...),
+		verifyEmails:    append([]database.VerifyEmail(nil), t.verifyEmails...),
+		passwordResets:  append([]database.PasswordReset(nil), t.passwordResets...),
+		recoveryCodes:   append([]database.RecoveryCode(nil), t.recoveryCodes...),
+		loginChallenges: append([]database.LoginChallenge(nil), t.loginChallenges...),
+		loginAttempts:   append([]database.LoginAttempt(nil), t.loginAttempts...),
+		apiKeys:         append([]database.ApiKey(nil), t.apiKeys...),
+		oauthClients:    append([]database.OauthClient(nil), t.oauthClients...),
+		oauthCodes:      append([]database.OauthCode(nil), t.oauthCodes...),
+		auditEvents:     append([]database.AuditEvent(nil), t.auditEvents...),
+		usernameHistory: append([]database.UsernameHistory(nil), t.usernameHistory...),
+		jokeReactions:   append([]database.JokeReaction(nil), t.jokeReactions...),
+		comments:        append([]database.Comment(nil), t.comments...),
+		tags:            append([]database.Tag(nil), t.tags...),
+		jokeTags:        append([]database.JokeTag(nil), t.jokeTags...),
+	}
+}
+
+// ExecTx runs fn on a copy of the tables, which replaces them if fn returns nil.
+// Transactions hold the lock of the store till the end, so they are serializable.
+func (s *Store) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	// Nested transactions are a part of the outer one
+	if s.mu == nil {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Store{seq: s.seq, t: s.t.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.t = tx.t
+	return nil
+}
+
+// Locks the store for a single query, returns the unlock function
+func (s *Store) lock() func() {
+	if s.mu == nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func uniqueViolation(table, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func notNullViolation(table, column string) error {
+	return &pq.Error{
+		Severity: "ERROR",
+		Code:     "23502",
+		Message:  fmt.Sprintf("null value in column %q of relation %q violates not-null constraint", column, table),
+		Table:    table,
+		Column:   column,
+	}
+}
+
+func undefinedTextSearchConfig(name string) error {
+	return &pq.Error{
+		Severity: "ERROR",
+		Code:     "42704",
+		Message:  fmt.Sprintf("text search configuration %q does not exist", name),
+	}
+}
+
+// Copies the array, keeping NULL apart from the empty one
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+// Applies LIMIT and OFFSET to the sorted rows
+func page[T any](rows []T, limit, offset int32) []T {
+	if int(offset) >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// Sorts the rows newest first. Rows created at the same time are ordered by the insertion, latest first.
+func newestFirst[T any](rows []T, createdAt func(T) time.Time) []T {
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return createdAt(rows[i]).After(createdAt(rows[j]))
+	})
+	return rows
+}
+
+// Returns the current time of the database
+func now() time.Time {
+	return time.Now()
+}
+
+func (t *tables) userExists(id int32) bool {
+	for _, u := range t.users {
+		if u.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tables) jokeExists(id int32) bool {
+	for _, j := range t.jokes {
+		if j.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tables) oauthClientExists(id string) bool {
+	for _, c := range t.oauthClients {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Deletes the rows that reference the deleted users, like the ON DELETE CASCADE foreign keys
+func (t *tables) cascadeUsers(deleted map[int32]bool) {
+	t.deleteJokes(func(j database.Joke) bool { return deleted[j.AuthorID] })
+	t.sessions = filter(t.sessions, func(s database.Session) bool { return !deleted[s.UserID] })
+	t.verifyEmails = filter(t.verifyEmails, func(v database.VerifyEmail) bool { return !deleted[v.UserID] })
+	t.passwordResets = filter(t.passwordResets, func(p database.PasswordReset) bool { return !deleted[p.UserID] })
+	t.recoveryCodes = filter(t.recoveryCodes, func(r database.RecoveryCode) bool { return !deleted[r.UserID] })
+	t.loginChallenges = filter(t.loginChallenges, func(l database.LoginChallenge) bool { return !deleted[l.UserID] })
+	t.apiKeys = filter(t.apiKeys, func(k database.ApiKey) bool { return !deleted[k.UserID] })
+	t.oauthCodes = filter(t.oauthCodes, func(c database.OauthCode) bool { return !deleted[c.UserID] })
+	t.usernameHistory = filter(t.usernameHistory, func(h database.UsernameHistory) bool { return !deleted[h.UserID] })
+	t.jokeReactions = filter(t.jokeReactions, func(r database.JokeReaction) bool { return !deleted[r.UserID] })
+	// The comments stay in their threads as tombstones, like ON DELETE SET NULL with the trigger
+	for i := range t.comments {
+		if c := &t.comments[i]; c.AuthorID.Valid && deleted[c.AuthorID.Int32] {
+			tombstone(c)
+		}
+	}
+
+	deletedClients := make(map[string]bool)
+	t.oauthClients = filter(t.oauthClients, func(c database.OauthClient) bool {
+		if deleted[c.OwnerID] {
+			deletedClients[c.ID] = true
+			return false
+		}
+		return true
+	})
+	t.cascadeOAuthClients(deletedClients)
+}
+
+// Deletes the rows that reference the deleted jokes
+func (t *tables) cascadeJokes(deleted map[int32]bool) {
+	t.jokeReactions = filter(t.jokeReactions, func(r database.JokeReaction) bool { return !deleted[r.JokeID] })
+	t.comments = filter(t.comments, func(c database.Comment) bool { return !deleted[c.JokeID] })
+	t.jokeTags = filter(t.jokeTags, func(jt database.JokeTag) bool { return !deleted[jt.JokeID] })
+}
+
+// Deletes the codes and the sessions of the deleted OAuth clients
+func (t *tables) cascadeOAuthClients(deleted map[string]bool) {
+	t.oauthCodes = filter(t.oauthCodes, func(c database.OauthCode) bool { return !deleted[c.ClientID] })
+	t.sessions = filter(t.sessions, func(s database.Session) bool { return !s.ClientID.Valid || !deleted[s.ClientID.String] })
+}
+
+// Returns the rows to keep in a new slice, so the copies of the tables aren't changed
+func filter[T any](rows []T, keep func(T) bool) []T {
+	var kept []T
+	for _, row := range rows {
+		if keep(row) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,124 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/search"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomUser(t *testing.T, s *Store) database.User {
+	user, err := s.CreateUser(context.Background(), database.CreateUserParams{
+		Username:       random.RandomUsername(),
+		Email:          random.RandomEmail(),
+		HashedPassword: random.RandomString(60),
+		Fullname:       random.RandomFullname(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestUniqueViolation(t *testing.T) {
+	s := New()
+	user := createRandomUser(t, s)
+
+	_, err := s.CreateUser(context.Background(), database.CreateUserParams{
+		Username:       user.Username,
+		Email:          random.RandomEmail(),
+		HashedPassword: random.RandomString(60),
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, pq.ErrorCode("23505"), pqErr.Code)
+	require.Equal(t, "users_username_key", pqErr.Constraint)
+}
+
+func TestForeignKeyViolation(t *testing.T) {
+	s := New()
+
+	_, err := s.CreateJoke(context.Background(), database.CreateJokeParams{
+		AuthorID: 1,
+		Title:    random.RandomString(10),
+		Text:     random.RandomString(50),
+		Language: search.English,
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, pq.ErrorCode("23503"), pqErr.Code)
+}
+
+func TestNoRows(t *testing.T) {
+	s := New()
+
+	_, err := s.GetUserByID(context.Background(), 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestDeleteUserCascade(t *testing.T) {
+	s := New()
+	user := createRandomUser(t, s)
+	joke, err := s.CreateJoke(context.Background(), database.CreateJokeParams{
+		AuthorID: user.ID,
+		Title:    random.RandomString(10),
+		Text:     random.RandomString(50),
+		Language: search.English,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteUser(context.Background(), user.ID))
+
+	_, err = s.GetJoke(context.Background(), joke.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExecTxCommit(t *testing.T) {
+	s := New()
+	user1 := createRandomUser(t, s)
+
+	err := s.ExecTx(context.Background(), func(q database.Querier) error {
+		_, err := q.UpdateUserBanned(context.Background(), database.UpdateUserBannedParams{
+			ID:       user1.ID,
+			IsBanned: true,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	user2, err := s.GetUserByID(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.True(t, user2.IsBanned)
+}
+
+func TestExecTxRollback(t *testing.T) {
+	s := New()
+	user1 := createRandomUser(t, s)
+	txErr := errors.New("tx error")
+
+	err := s.ExecTx(context.Background(), func(q database.Querier) error {
+		_, err := q.UpdateUserBanned(context.Background(), database.UpdateUserBannedParams{
+			ID:       user1.ID,
+			IsBanned: true,
+		})
+		require.NoError(t, err)
+		return txErr
+	})
+	require.ErrorIs(t, err, txErr)
+
+	user2, err := s.GetUserByID(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.False(t, user2.IsBanned)
+}
+
+// The values are the ones of the pg_trgm documentation
+func TestTrigramSimilarity(t *testing.T) {
+	require.InDelta(t, 0.36363637, similarity("word", "two words"), 1e-6)
+	require.InDelta(t, 0.8, wordSimilarity("word", "two words"), 1e-6)
+	require.InDelta(t, 1, similarity("Word", "word!"), 1e-6)
+	require.Zero(t, similarity("", "word"))
+}
@@ -0,0 +1,151 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateOAuthClient(ctx context.Context, arg database.CreateOAuthClientParams) (database.OauthClient, error) {
+	defer s.lock()()
+
+	if s.t.oauthClientExists(arg.ID) {
+		return database.OauthClient{}, uniqueViolation("oauth_clients", "oauth_clients_pkey")
+	}
+	if arg.RedirectUris == nil {
+		return database.OauthClient{}, notNullViolation("oauth_clients", "redirect_uris")
+	}
+	if !s.t.userExists(arg.OwnerID) {
+		return database.OauthClient{}, foreignKeyViolation("oauth_clients", "oauth_clients_owner_id_fkey")
+	}
+
+	client := database.OauthClient{
+		ID:             arg.ID,
+		OwnerID:        arg.OwnerID,
+		Name:           arg.Name,
+		RedirectUris:   cloneStrings(arg.RedirectUris),
+		IsConfidential: arg.IsConfidential,
+		SecretHash:     arg.SecretHash,
+		CreatedAt:      now(),
+	}
+	s.t.oauthClients = append(s.t.oauthClients, client)
+	return cloneOAuthClient(client), nil
+}
+
+func (s *Store) CreateOAuthCode(ctx context.Context, arg database.CreateOAuthCodeParams) (database.OauthCode, error) {
+	defer s.lock()()
+
+	for _, c := range s.t.oauthCodes {
+		if c.CodeHash == arg.CodeHash {
+			return database.OauthCode{}, uniqueViolation("oauth_codes", "oauth_codes_pkey")
+		}
+	}
+	if arg.Scopes == nil {
+		return database.OauthCode{}, notNullViolation("oauth_codes", "scopes")
+	}
+	if !s.t.oauthClientExists(arg.ClientID) {
+		return database.OauthCode{}, foreignKeyViolation("oauth_codes", "oauth_codes_client_id_fkey")
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.OauthCode{}, foreignKeyViolation("oauth_codes", "oauth_codes_user_id_fkey")
+	}
+
+	code := database.OauthCode{
+		CodeHash:            arg.CodeHash,
+		ClientID:            arg.ClientID,
+		UserID:              arg.UserID,
+		RedirectUri:         arg.RedirectUri,
+		Scopes:              cloneStrings(arg.Scopes),
+		CodeChallenge:       arg.CodeChallenge,
+		CodeChallengeMethod: arg.CodeChallengeMethod,
+		ExpiresAt:           arg.ExpiresAt,
+		CreatedAt:           now(),
+	}
+	s.t.oauthCodes = append(s.t.oauthCodes, code)
+	return cloneOAuthCode(code), nil
+}
+
+func cloneOAuthClient(c database.OauthClient) database.OauthClient {
+	c.RedirectUris = cloneStrings(c.RedirectUris)
+	return c
+}
+
+func cloneOAuthCode(c database.OauthCode) database.OauthCode {
+	c.Scopes = cloneStrings(c.Scopes)
+	return c
+}
+
+// GET QUERIES
+
+func (s *Store) GetOAuthClient(ctx context.Context, id string) (database.OauthClient, error) {
+	defer s.lock()()
+	for _, c := range s.t.oauthClients {
+		if c.ID == id {
+			return cloneOAuthClient(c), nil
+		}
+	}
+	return database.OauthClient{}, sql.ErrNoRows
+}
+
+func (s *Store) ListOAuthClientsByOwner(ctx context.Context, ownerID int32) ([]database.OauthClient, error) {
+	defer s.lock()()
+	var clients []database.OauthClient
+	for _, c := range s.t.oauthClients {
+		if c.OwnerID == ownerID {
+			clients = append(clients, cloneOAuthClient(c))
+		}
+	}
+	return newestFirst(clients, func(c database.OauthClient) time.Time { return c.CreatedAt }), nil
+}
+
+func (s *Store) GetOAuthCode(ctx context.Context, codeHash string) (database.OauthCode, error) {
+	defer s.lock()()
+	for _, c := range s.t.oauthCodes {
+		if c.CodeHash == codeHash {
+			return cloneOAuthCode(c), nil
+		}
+	}
+	return database.OauthCode{}, sql.ErrNoRows
+}
+
+// UPDATE QUERIES
+
+func (s *Store) UseOAuthCode(ctx context.Context, codeHash string) (database.OauthCode, error) {
+	defer s.lock()()
+	for i := range s.t.oauthCodes {
+		c := &s.t.oauthCodes[i]
+		if c.CodeHash == codeHash && !c.IsUsed {
+			c.IsUsed = true
+			return cloneOAuthCode(*c), nil
+		}
+	}
+	return database.OauthCode{}, sql.ErrNoRows
+}
+
+func (s *Store) SetOAuthCodeSession(ctx context.Context, arg database.SetOAuthCodeSessionParams) error {
+	defer s.lock()()
+	for i := range s.t.oauthCodes {
+		if s.t.oauthCodes[i].CodeHash == arg.CodeHash {
+			s.t.oauthCodes[i].SessionID = arg.SessionID
+		}
+	}
+	return nil
+}
+
+// DELETE QUERIES
+
+func (s *Store) DeleteOwnedOAuthClient(ctx context.Context, arg database.DeleteOwnedOAuthClientParams) (int64, error) {
+	defer s.lock()()
+	deleted := make(map[string]bool)
+	s.t.oauthClients = filter(s.t.oauthClients, func(c database.OauthClient) bool {
+		if c.ID == arg.ID && c.OwnerID == arg.OwnerID {
+			deleted[c.ID] = true
+			return false
+		}
+		return true
+	})
+	s.t.cascadeOAuthClients(deleted)
+	return int64(len(deleted)), nil
+}
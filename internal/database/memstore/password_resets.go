@@ -0,0 +1,73 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreatePasswordReset(ctx context.Context, arg database.CreatePasswordResetParams) (database.PasswordReset, error) {
+	defer s.lock()()
+
+	for _, r := range s.t.passwordResets {
+		if r.TokenHash == arg.TokenHash {
+			return database.PasswordReset{}, uniqueViolation("password_resets", "password_resets_token_hash_key")
+		}
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.PasswordReset{}, foreignKeyViolation("password_resets", "password_resets_user_id_fkey")
+	}
+
+	s.seq.passwordResets++
+	reset := database.PasswordReset{
+		ID:        s.seq.passwordResets,
+		UserID:    arg.UserID,
+		TokenHash: arg.TokenHash,
+		CreatedAt: now(),
+		ExpiredAt: arg.ExpiredAt,
+	}
+	s.t.passwordResets = append(s.t.passwordResets, reset)
+	return reset, nil
+}
+
+// Checks if the reset has the token and can still be used
+func activePasswordReset(r database.PasswordReset, tokenHash string) bool {
+	return r.TokenHash == tokenHash && !r.IsUsed && r.ExpiredAt.After(now())
+}
+
+// GET QUERIES
+
+func (s *Store) GetUserByPasswordReset(ctx context.Context, tokenHash string) (database.User, error) {
+	defer s.lock()()
+	for _, r := range s.t.passwordResets {
+		if activePasswordReset(r, tokenHash) {
+			return s.t.findUser(func(u database.User) bool { return u.ID == r.UserID })
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+// UPDATE QUERIES
+
+func (s *Store) UsePasswordReset(ctx context.Context, tokenHash string) (database.PasswordReset, error) {
+	defer s.lock()()
+	for i := range s.t.passwordResets {
+		r := &s.t.passwordResets[i]
+		if activePasswordReset(*r, tokenHash) {
+			r.IsUsed = true
+			return *r, nil
+		}
+	}
+	return database.PasswordReset{}, sql.ErrNoRows
+}
+
+func (s *Store) UseUserPasswordResets(ctx context.Context, userID int32) error {
+	defer s.lock()()
+	for i := range s.t.passwordResets {
+		if s.t.passwordResets[i].UserID == userID {
+			s.t.passwordResets[i].IsUsed = true
+		}
+	}
+	return nil
+}
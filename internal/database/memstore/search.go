@@ -0,0 +1,265 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/search"
+)
+
+// Weights of the matches in the title, text and explanation, the defaults of ts_rank_cd for A, B and C
+var searchWeights = [3]float32{1, 0.4, 0.2}
+
+// Word of a text, with its position in the text
+type word struct {
+	text       string
+	start, end int
+}
+
+// Splits the text into lowercase words like the query is split, so they can be compared
+func splitWords(text string) []word {
+	var words []word
+	start := -1
+	for i, r := range text + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			words = append(words, word{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	return words
+}
+
+// Reads back the query made by search.Query.TSQuery
+func parseTSQuery(q string) search.Query {
+	if q == "" {
+		return nil
+	}
+	var query search.Query
+	for _, t := range strings.Split(q, " & ") {
+		t = strings.TrimSuffix(strings.TrimPrefix(t, "("), ")")
+		term := search.Term{Prefix: strings.HasSuffix(t, ":*")}
+		term.Words = strings.Split(strings.TrimSuffix(t, ":*"), " <-> ")
+		query = append(query, term)
+	}
+	return query
+}
+
+// Checks if the word matches the i-th word of the term
+func termWordMatches(term search.Term, i int, w string) bool {
+	if term.Prefix && i == len(term.Words)-1 {
+		return strings.HasPrefix(w, term.Words[i])
+	}
+	return w == term.Words[i]
+}
+
+// Returns the number of times the term is in the words
+func countTerm(term search.Term, words []word) int {
+	n := 0
+	for start := 0; start+len(term.Words) <= len(words); start++ {
+		matches := true
+		for i := range term.Words {
+			if !termWordMatches(term, i, words[start+i].text) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			n++
+		}
+	}
+	return n
+}
+
+// Takes the highlight marks out of the texts before they are highlighted
+var highlightMarks = strings.NewReplacer(search.HighlightStart, "", search.HighlightStop, "")
+
+// Marks the words of the text that are in the query, like ts_headline
+func highlight(text string, query search.Query) string {
+	text = highlightMarks.Replace(text)
+	var b strings.Builder
+	last := 0
+	for _, w := range splitWords(text) {
+		for _, term := range query {
+			found := false
+			for i := range term.Words {
+				if termWordMatches(term, i, w.text) {
+					found = true
+					break
+				}
+			}
+			if found {
+				b.WriteString(text[last:w.start])
+				b.WriteString(search.HighlightStart + text[w.start:w.end] + search.HighlightStop)
+				last = w.end
+				break
+			}
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// SearchJokes matches the words as they are, without stemming or stop words, like the simple configuration.
+// The snippets highlight the whole text instead of its best fragments.
+func (s *Store) SearchJokes(ctx context.Context, arg database.SearchJokesParams) ([]database.SearchJokesRow, error) {
+	defer s.lock()()
+	if !search.IsLanguage(arg.Language) {
+		return nil, undefinedTextSearchConfig(arg.Language)
+	}
+	query := parseTSQuery(arg.Query)
+
+	var rows []database.SearchJokesRow
+	for _, j := range s.t.jokesWithAuthors() {
+		if j.Language != arg.Language || len(query) == 0 {
+			continue
+		}
+		fields := [3][]word{splitWords(j.Title), splitWords(j.Text), splitWords(j.Explanation)}
+		var rank float32
+		matches := true
+		for _, term := range query {
+			found := false
+			for f, words := range fields {
+				if n := countTerm(term, words); n > 0 {
+					rank += searchWeights[f] * float32(n)
+					found = true
+				}
+			}
+			if !found {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+		rows = append(rows, database.SearchJokesRow{
+			ID:           j.ID,
+			AuthorID:     j.AuthorID,
+			Author:       j.Author,
+			Title:        j.Title,
+			Text:         j.Text,
+			Explanation:  j.Explanation,
+			CreatedAt:    j.CreatedAt,
+			UpdatedAt:    j.UpdatedAt,
+			LaughCount:   j.LaughCount,
+			GroanCount:   j.GroanCount,
+			LoveCount:    j.LoveCount,
+			CommentCount: j.CommentCount,
+			Tags:         j.Tags,
+			Language:     j.Language,
+			Rank:         rank,
+			TitleSnippet: highlight(j.Title, query),
+			TextSnippet:  highlight(j.Text+" "+j.Explanation, query),
+		})
+	}
+	// The rows are sorted by id already
+	sort.SliceStable(rows, func(i, k int) bool { return rows[i].Rank > rows[k].Rank })
+	return page(rows, arg.Limit, arg.Offset), nil
+}
+
+// Defaults of pg_trgm.similarity_threshold and pg_trgm.word_similarity_threshold,
+// used by the % and <% operators
+const (
+	similarityThreshold     = 0.3
+	wordSimilarityThreshold = 0.6
+)
+
+// Returns the trigrams of the text in order, like pg_trgm makes them:
+// every word is lowercased and padded with two spaces in front and one behind
+func trigrams(text string) []string {
+	var trgms []string
+	for _, w := range splitWords(text) {
+		padded := []rune("  " + w.text + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			trgms = append(trgms, string(padded[i:i+3]))
+		}
+	}
+	return trgms
+}
+
+// Returns the share of the trigrams of a and b that both of them have
+func trigramSimilarity(a, b []string) float32 {
+	set := make(map[string]int)
+	for _, t := range a {
+		set[t] |= 1
+	}
+	for _, t := range b {
+		set[t] |= 2
+	}
+	if len(set) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, in := range set {
+		if in == 3 {
+			shared++
+		}
+	}
+	return float32(shared) / float32(len(set))
+}
+
+// Like similarity(a, b) of pg_trgm
+func similarity(a, b string) float32 {
+	return trigramSimilarity(trigrams(a), trigrams(b))
+}
+
+// Like word_similarity(a, b) of pg_trgm, the greatest similarity of a to a continuous part of b
+func wordSimilarity(a, b string) float32 {
+	q, t := trigrams(a), trigrams(b)
+	var best float32
+	for i := range t {
+		for j := i + 1; j <= len(t); j++ {
+			if sim := trigramSimilarity(q, t[i:j]); sim > best {
+				best = sim
+			}
+		}
+	}
+	return best
+}
+
+func (s *Store) SearchUsers(ctx context.Context, arg database.SearchUsersParams) ([]database.SearchUsersRow, error) {
+	defer s.lock()()
+
+	var rows []database.SearchUsersRow
+	for _, u := range s.t.users {
+		usernameSimilarity := similarity(u.Username, arg.Query)
+		usernameWordSimilarity := wordSimilarity(arg.Query, u.Username)
+		fullnameWordSimilarity := wordSimilarity(arg.Query, u.Fullname)
+		if u.IsBanned || u.IsDeactivated || (usernameSimilarity < similarityThreshold &&
+			usernameWordSimilarity < wordSimilarityThreshold &&
+			fullnameWordSimilarity < wordSimilarityThreshold) {
+			continue
+		}
+
+		rank := usernameSimilarity
+		if usernameWordSimilarity > rank {
+			rank = usernameWordSimilarity
+		}
+		if fullnameWordSimilarity > rank {
+			rank = fullnameWordSimilarity
+		}
+		if strings.EqualFold(u.Username, arg.Query) {
+			rank++
+		}
+		rows = append(rows, database.SearchUsersRow{
+			ID:        u.ID,
+			Username:  u.Username,
+			Avatar:    u.Avatar,
+			Fullname:  u.Fullname,
+			Bio:       u.Bio,
+			Status:    u.Status,
+			CreatedAt: u.CreatedAt,
+			Rank:      rank,
+		})
+	}
+	// Ids grow with the insertion, so the rows are sorted by id already
+	sort.SliceStable(rows, func(i, k int) bool { return rows[i].Rank > rows[k].Rank })
+	return page(rows, arg.Limit, arg.Offset), nil
+}
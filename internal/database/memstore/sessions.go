@@ -0,0 +1,100 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.Session, error) {
+	defer s.lock()()
+
+	for _, session := range s.t.sessions {
+		if session.ID == arg.ID {
+			return database.Session{}, uniqueViolation("sessions", "sessions_pkey")
+		}
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.Session{}, foreignKeyViolation("sessions", "sessions_user_id_fkey")
+	}
+	if arg.ClientID.Valid && !s.t.oauthClientExists(arg.ClientID.String) {
+		return database.Session{}, foreignKeyViolation("sessions", "sessions_client_id_fkey")
+	}
+
+	session := database.Session{
+		ID:           arg.ID,
+		UserID:       arg.UserID,
+		RefreshToken: arg.RefreshToken,
+		UserAgent:    arg.UserAgent,
+		ClientIp:     arg.ClientIp,
+		IsBlocked:    arg.IsBlocked,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    now(),
+		Scopes:       cloneStrings(arg.Scopes),
+		ClientID:     arg.ClientID,
+	}
+	s.t.sessions = append(s.t.sessions, session)
+	return cloneSession(session), nil
+}
+
+func cloneSession(session database.Session) database.Session {
+	session.Scopes = cloneStrings(session.Scopes)
+	return session
+}
+
+// GET QUERIES
+
+func (s *Store) GetSession(ctx context.Context, id uuid.UUID) (database.Session, error) {
+	defer s.lock()()
+	for _, session := range s.t.sessions {
+		if session.ID == id {
+			return cloneSession(session), nil
+		}
+	}
+	return database.Session{}, sql.ErrNoRows
+}
+
+func (s *Store) ListSessionsByUser(ctx context.Context, userID int32) ([]database.Session, error) {
+	defer s.lock()()
+	var sessions []database.Session
+	for _, session := range s.t.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+	return newestFirst(sessions, func(s database.Session) time.Time { return s.CreatedAt }), nil
+}
+
+// UPDATE QUERIES
+
+// Blocks the sessions that match, returns the number of them
+func (s *Store) blockSessions(match func(database.Session) bool) int64 {
+	defer s.lock()()
+	var rows int64
+	for i := range s.t.sessions {
+		if match(s.t.sessions[i]) {
+			s.t.sessions[i].IsBlocked = true
+			rows++
+		}
+	}
+	return rows
+}
+
+func (s *Store) BlockSession(ctx context.Context, id uuid.UUID) error {
+	s.blockSessions(func(session database.Session) bool { return session.ID == id })
+	return nil
+}
+
+func (s *Store) BlockOwnedSession(ctx context.Context, arg database.BlockOwnedSessionParams) (int64, error) {
+	return s.blockSessions(func(session database.Session) bool {
+		return session.ID == arg.ID && session.UserID == arg.UserID
+	}), nil
+}
+
+func (s *Store) BlockUserSessions(ctx context.Context, userID int32) error {
+	s.blockSessions(func(session database.Session) bool { return session.UserID == userID })
+	return nil
+}
@@ -0,0 +1,129 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/lib/pq"
+)
+
+func (s *Store) UpsertTags(ctx context.Context, names []string) ([]database.Tag, error) {
+	defer s.lock()()
+
+	var tags []database.Tag
+	upserted := make(map[string]bool, len(names))
+	for _, name := range names {
+		if upserted[name] {
+			return nil, &pq.Error{
+				Severity: "ERROR",
+				Code:     "21000",
+				Message:  "ON CONFLICT DO UPDATE command cannot affect row a second time",
+			}
+		}
+		upserted[name] = true
+
+		tag, ok := s.t.findTag(name)
+		if !ok {
+			s.seq.tags++
+			tag = database.Tag{
+				ID:        s.seq.tags,
+				Name:      name,
+				CreatedAt: now(),
+			}
+			s.t.tags = append(s.t.tags, tag)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (s *Store) AddJokeTags(ctx context.Context, arg database.AddJokeTagsParams) error {
+	defer s.lock()()
+
+	if !s.t.jokeExists(arg.JokeID) {
+		return foreignKeyViolation("joke_tags", "joke_tags_joke_id_fkey")
+	}
+	for _, tagID := range arg.TagIds {
+		if !s.t.tagExists(tagID) {
+			return foreignKeyViolation("joke_tags", "joke_tags_tag_id_fkey")
+		}
+	}
+	for _, tagID := range arg.TagIds {
+		jokeTag := database.JokeTag{JokeID: arg.JokeID, TagID: tagID}
+		exists := false
+		for _, jt := range s.t.jokeTags {
+			exists = exists || jt == jokeTag
+		}
+		if !exists {
+			s.t.jokeTags = append(s.t.jokeTags, jokeTag)
+		}
+	}
+	return nil
+}
+
+func (t *tables) findTag(name string) (database.Tag, bool) {
+	for _, tag := range t.tags {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	return database.Tag{}, false
+}
+
+func (t *tables) tagExists(id int32) bool {
+	for _, tag := range t.tags {
+		if tag.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GET QUERIES
+
+// Returns the sorted tag names of every joke that has tags
+func (t *tables) jokeTagNames() map[int32][]string {
+	names := make(map[int32]string, len(t.tags))
+	for _, tag := range t.tags {
+		names[tag.ID] = tag.Name
+	}
+	jokeTags := make(map[int32][]string)
+	for _, jt := range t.jokeTags {
+		jokeTags[jt.JokeID] = append(jokeTags[jt.JokeID], names[jt.TagID])
+	}
+	for _, tags := range jokeTags {
+		sort.Strings(tags)
+	}
+	return jokeTags
+}
+
+func (s *Store) ListTags(ctx context.Context, arg database.ListTagsParams) ([]database.ListTagsRow, error) {
+	defer s.lock()()
+
+	counts := make(map[int32]int32)
+	for _, jt := range s.t.jokeTags {
+		counts[jt.TagID]++
+	}
+	var rows []database.ListTagsRow
+	for _, tag := range s.t.tags {
+		if counts[tag.ID] > 0 {
+			rows = append(rows, database.ListTagsRow{Name: tag.Name, JokeCount: counts[tag.ID]})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].JokeCount != rows[j].JokeCount {
+			return rows[i].JokeCount > rows[j].JokeCount
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return page(rows, arg.Limit, arg.Offset), nil
+}
+
+// DELETE QUERIES
+
+func (s *Store) DeleteJokeTags(ctx context.Context, jokeID int32) error {
+	defer s.lock()()
+	s.t.jokeTags = filter(s.t.jokeTags, func(jt database.JokeTag) bool { return jt.JokeID != jokeID })
+	return nil
+}
@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateRecoveryCode(ctx context.Context, arg database.CreateRecoveryCodeParams) (database.RecoveryCode, error) {
+	defer s.lock()()
+
+	for _, c := range s.t.recoveryCodes {
+		if c.UserID == arg.UserID && c.CodeHash == arg.CodeHash {
+			return database.RecoveryCode{}, uniqueViolation("recovery_codes", "recovery_codes_user_id_code_hash_idx")
+		}
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.RecoveryCode{}, foreignKeyViolation("recovery_codes", "recovery_codes_user_id_fkey")
+	}
+
+	s.seq.recoveryCodes++
+	code := database.RecoveryCode{
+		ID:        s.seq.recoveryCodes,
+		UserID:    arg.UserID,
+		CodeHash:  arg.CodeHash,
+		CreatedAt: now(),
+	}
+	s.t.recoveryCodes = append(s.t.recoveryCodes, code)
+	return code, nil
+}
+
+func (s *Store) CreateLoginChallenge(ctx context.Context, arg database.CreateLoginChallengeParams) (database.LoginChallenge, error) {
+	defer s.lock()()
+
+	for _, c := range s.t.loginChallenges {
+		if c.ID == arg.ID {
+			return database.LoginChallenge{}, uniqueViolation("login_challenges", "login_challenges_pkey")
+		}
+	}
+	if !s.t.userExists(arg.UserID) {
+		return database.LoginChallenge{}, foreignKeyViolation("login_challenges", "login_challenges_user_id_fkey")
+	}
+
+	challenge := database.LoginChallenge{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		CreatedAt: now(),
+	}
+	s.t.loginChallenges = append(s.t.loginChallenges, challenge)
+	return challenge, nil
+}
+
+// GET QUERIES
+
+func (s *Store) GetLoginChallenge(ctx context.Context, id uuid.UUID) (database.LoginChallenge, error) {
+	defer s.lock()()
+	for _, c := range s.t.loginChallenges {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return database.LoginChallenge{}, sql.ErrNoRows
+}
+
+// UPDATE QUERIES
+
+func (s *Store) UseRecoveryCode(ctx context.Context, arg database.UseRecoveryCodeParams) (int64, error) {
+	defer s.lock()()
+	for i := range s.t.recoveryCodes {
+		c := &s.t.recoveryCodes[i]
+		if c.UserID == arg.UserID && c.CodeHash == arg.CodeHash && !c.IsUsed {
+			c.IsUsed = true
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *Store) IncrementLoginChallengeAttempts(ctx context.Context, id uuid.UUID) (database.LoginChallenge, error) {
+	defer s.lock()()
+	for i := range s.t.loginChallenges {
+		c := &s.t.loginChallenges[i]
+		if c.ID == id {
+			c.Attempts++
+			return *c, nil
+		}
+	}
+	return database.LoginChallenge{}, sql.ErrNoRows
+}
+
+func (s *Store) UseLoginChallenge(ctx context.Context, id uuid.UUID) (int64, error) {
+	defer s.lock()()
+	for i := range s.t.loginChallenges {
+		c := &s.t.loginChallenges[i]
+		if c.ID == id && !c.IsUsed {
+			c.IsUsed = true
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// DELETE QUERIES
+
+func (s *Store) DeleteUserRecoveryCodes(ctx context.Context, userID int32) error {
+	defer s.lock()()
+	s.t.recoveryCodes = filter(s.t.recoveryCodes, func(c database.RecoveryCode) bool { return c.UserID != userID })
+	return nil
+}
@@ -0,0 +1,66 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateUsernameHistory(ctx context.Context, arg database.CreateUsernameHistoryParams) error {
+	defer s.lock()()
+
+	if !s.t.userExists(arg.UserID) {
+		return foreignKeyViolation("username_history", "username_history_user_id_fkey")
+	}
+
+	s.seq.usernameHistory++
+	s.t.usernameHistory = append(s.t.usernameHistory, database.UsernameHistory{
+		ID:          s.seq.usernameHistory,
+		UserID:      arg.UserID,
+		OldUsername: arg.OldUsername,
+		ChangedAt:   arg.ChangedAt,
+	})
+	return nil
+}
+
+// GET QUERIES
+
+// Returns the matching changes, latest first
+func (t *tables) usernameChanges(match func(database.UsernameHistory) bool) []database.UsernameHistory {
+	return newestFirst(filter(t.usernameHistory, match), func(h database.UsernameHistory) time.Time { return h.ChangedAt })
+}
+
+func (s *Store) GetLastUsernameChange(ctx context.Context, userID int32) (time.Time, error) {
+	defer s.lock()()
+	changes := s.t.usernameChanges(func(h database.UsernameHistory) bool { return h.UserID == userID })
+	if len(changes) == 0 {
+		return time.Time{}, sql.ErrNoRows
+	}
+	return changes[0].ChangedAt, nil
+}
+
+func (s *Store) GetRenamedUsername(ctx context.Context, arg database.GetRenamedUsernameParams) (string, error) {
+	defer s.lock()()
+	changes := s.t.usernameChanges(func(h database.UsernameHistory) bool {
+		return h.OldUsername == arg.OldUsername && h.ChangedAt.After(arg.ChangedAt)
+	})
+	for _, h := range changes {
+		user, err := s.t.findUser(func(u database.User) bool { return u.ID == h.UserID })
+		if err == nil {
+			return user.Username, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func (s *Store) IsUsernameReserved(ctx context.Context, arg database.IsUsernameReservedParams) (bool, error) {
+	defer s.lock()()
+	for _, h := range s.t.usernameHistory {
+		if h.OldUsername == arg.OldUsername && h.ChangedAt.After(arg.ChangedAt) && h.UserID != arg.UserID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
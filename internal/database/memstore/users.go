@@ -0,0 +1,268 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	defer s.lock()()
+
+	for _, u := range s.t.users {
+		if u.Username == arg.Username {
+			return database.User{}, uniqueViolation("users", "users_username_key")
+		}
+		if u.Email == arg.Email {
+			return database.User{}, uniqueViolation("users", "users_email_key")
+		}
+	}
+
+	s.seq.users++
+	createdAt := now()
+	user := database.User{
+		ID:             s.seq.users,
+		Username:       arg.Username,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Avatar:         arg.Avatar,
+		Fullname:       arg.Fullname,
+		Bio:            arg.Bio,
+		Status:         arg.Status,
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+		Role:           "user",
+	}
+	s.t.users = append(s.t.users, user)
+	return user, nil
+}
+
+// GET QUERIES
+
+func (s *Store) GetUserByID(ctx context.Context, id int32) (database.User, error) {
+	defer s.lock()()
+	return s.t.findUser(func(u database.User) bool { return u.ID == id })
+}
+
+func (s *Store) GetUserByName(ctx context.Context, username string) (database.User, error) {
+	defer s.lock()()
+	return s.t.findUser(func(u database.User) bool { return u.Username == username })
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	defer s.lock()()
+	return s.t.findUser(func(u database.User) bool { return u.Email == email })
+}
+
+func (s *Store) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	defer s.lock()()
+	// Ids grow with the insertion, so the users are sorted by id already
+	return append([]database.User(nil), page(s.t.users, arg.Limit, arg.Offset)...), nil
+}
+
+func (t *tables) findUser(match func(database.User) bool) (database.User, error) {
+	for _, u := range t.users {
+		if match(u) {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+// UPDATE QUERIES
+
+// Changes the user with the given id if where matches it, returns sql.ErrNoRows otherwise
+func (s *Store) updateUser(id int32, where func(database.User) bool, set func(*database.User) error) (database.User, error) {
+	defer s.lock()()
+	for i := range s.t.users {
+		u := &s.t.users[i]
+		if u.ID != id || !where(*u) {
+			continue
+		}
+		updated := *u
+		if err := set(&updated); err != nil {
+			return database.User{}, err
+		}
+		*u = updated
+		return updated, nil
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func anyUser(database.User) bool { return true }
+
+func (s *Store) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.HashedPassword = arg.HashedPassword
+		return nil
+	})
+}
+
+func (s *Store) RehashUserPassword(ctx context.Context, arg database.RehashUserPasswordParams) (int64, error) {
+	_, err := s.updateUser(arg.ID,
+		func(u database.User) bool { return u.HashedPassword == arg.OldHashedPassword },
+		func(u *database.User) error {
+			u.HashedPassword = arg.NewHashedPassword
+			return nil
+		})
+	return rowsAffected(err)
+}
+
+func (s *Store) UpdateUserAvatar(ctx context.Context, arg database.UpdateUserAvatarParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.Avatar = arg.Avatar
+		return nil
+	})
+}
+
+func (s *Store) UpdateUsername(ctx context.Context, arg database.UpdateUsernameParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		for _, other := range s.t.users {
+			if other.ID != u.ID && other.Username == arg.Username {
+				return uniqueViolation("users", "users_username_key")
+			}
+		}
+		u.Username = arg.Username
+		return nil
+	})
+}
+
+func (s *Store) UpdateUserFullname(ctx context.Context, arg database.UpdateUserFullnameParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.Fullname = arg.Fullname
+		return nil
+	})
+}
+
+func (s *Store) UpdateUserStatus(ctx context.Context, arg database.UpdateUserStatusParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.Status = arg.Status
+		return nil
+	})
+}
+
+func (s *Store) UpdateUserBio(ctx context.Context, arg database.UpdateUserBioParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.Bio = arg.Bio
+		return nil
+	})
+}
+
+func (s *Store) UpdateUserRole(ctx context.Context, arg database.UpdateUserRoleParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.Role = arg.Role
+		return nil
+	})
+}
+
+func (s *Store) BootstrapUserRole(ctx context.Context, arg database.BootstrapUserRoleParams) (int64, error) {
+	defer s.lock()()
+	for _, u := range s.t.users {
+		if u.Role == arg.Role {
+			return 0, nil
+		}
+	}
+	var rows int64
+	for i := range s.t.users {
+		if s.t.users[i].Email == arg.Email && s.t.users[i].IsEmailVerified {
+			s.t.users[i].Role = arg.Role
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) UpdateUserBanned(ctx context.Context, arg database.UpdateUserBannedParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.IsBanned = arg.IsBanned
+		return nil
+	})
+}
+
+func (s *Store) UpdateUserDeactivated(ctx context.Context, arg database.UpdateUserDeactivatedParams) (database.User, error) {
+	return s.updateUser(arg.ID, anyUser, func(u *database.User) error {
+		u.IsDeactivated = arg.IsDeactivated
+		return nil
+	})
+}
+
+func (s *Store) UpdateUserTotpSecret(ctx context.Context, arg database.UpdateUserTotpSecretParams) (database.User, error) {
+	return s.updateUser(arg.ID,
+		func(u database.User) bool { return !u.IsTotpEnabled },
+		func(u *database.User) error {
+			u.TotpSecret = arg.TotpSecret
+			return nil
+		})
+}
+
+func (s *Store) EnableUserTotp(ctx context.Context, arg database.EnableUserTotpParams) (database.User, error) {
+	return s.updateUser(arg.ID,
+		func(u database.User) bool { return !u.IsTotpEnabled && u.TotpSecret != "" },
+		func(u *database.User) error {
+			u.IsTotpEnabled = true
+			u.TotpLastStep = arg.TotpLastStep
+			return nil
+		})
+}
+
+func (s *Store) DisableUserTotp(ctx context.Context, id int32) (database.User, error) {
+	return s.updateUser(id, anyUser, func(u *database.User) error {
+		u.IsTotpEnabled = false
+		u.TotpSecret = ""
+		u.TotpLastStep = 0
+		return nil
+	})
+}
+
+func (s *Store) UseUserTotpStep(ctx context.Context, arg database.UseUserTotpStepParams) (int64, error) {
+	_, err := s.updateUser(arg.ID,
+		func(u database.User) bool { return u.TotpLastStep < arg.TotpLastStep },
+		func(u *database.User) error {
+			u.TotpLastStep = arg.TotpLastStep
+			return nil
+		})
+	return rowsAffected(err)
+}
+
+func (s *Store) UpdateUserUpdatedAt(ctx context.Context, id int32) error {
+	_, err := s.updateUser(id, anyUser, func(u *database.User) error {
+		u.UpdatedAt = now()
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// Turns the result of a single row update into the number of the updated rows
+func rowsAffected(err error) (int64, error) {
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// DELETE QUERIES
+
+func (s *Store) DeleteUser(ctx context.Context, id int32) error {
+	defer s.lock()()
+	s.t.users = filter(s.t.users, func(u database.User) bool { return u.ID != id })
+	s.t.cascadeUsers(map[int32]bool{id: true})
+	return nil
+}
+
+func (s *Store) DeleteAllUsers(ctx context.Context) error {
+	defer s.lock()()
+	deleted := make(map[int32]bool, len(s.t.users))
+	for _, u := range s.t.users {
+		deleted[u.ID] = true
+	}
+	s.t.users = nil
+	s.t.cascadeUsers(deleted)
+	return nil
+}
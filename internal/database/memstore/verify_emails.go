@@ -0,0 +1,52 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+func (s *Store) CreateVerifyEmail(ctx context.Context, arg database.CreateVerifyEmailParams) (database.VerifyEmail, error) {
+	defer s.lock()()
+
+	if !s.t.userExists(arg.UserID) {
+		return database.VerifyEmail{}, foreignKeyViolation("verify_emails", "verify_emails_user_id_fkey")
+	}
+
+	s.seq.verifyEmails++
+	verifyEmail := database.VerifyEmail{
+		ID:        s.seq.verifyEmails,
+		UserID:    arg.UserID,
+		Email:     arg.Email,
+		CodeHash:  arg.CodeHash,
+		CreatedAt: now(),
+		ExpiredAt: arg.ExpiredAt,
+	}
+	s.t.verifyEmails = append(s.t.verifyEmails, verifyEmail)
+	return verifyEmail, nil
+}
+
+// UPDATE QUERIES
+
+// The code is used up even if the user has changed the email since it was sent, like in the CTE of the query
+func (s *Store) VerifyEmail(ctx context.Context, arg database.VerifyEmailParams) (database.User, error) {
+	defer s.lock()()
+
+	for i := range s.t.verifyEmails {
+		v := &s.t.verifyEmails[i]
+		if v.ID != arg.ID || v.CodeHash != arg.CodeHash || v.IsUsed || !v.ExpiredAt.After(now()) {
+			continue
+		}
+		v.IsUsed = true
+
+		for j := range s.t.users {
+			u := &s.t.users[j]
+			if u.ID == v.UserID && u.Email == v.Email {
+				u.IsEmailVerified = true
+				return *u, nil
+			}
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
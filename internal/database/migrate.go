@@ -0,0 +1,117 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// The migrations are built into the binary, so it doesn't depend on the files at runtime
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Time to wait for another instance to finish migrating
+const migrationLockTimeout = 5 * time.Minute
+
+// Migrator changes the schema of the database.
+// The postgres driver holds an advisory lock while migrating,
+// so when several instances start at once only one of them migrates and the rest wait for it.
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+func NewMigrator(databaseURL string) (*Migrator, error) {
+	src, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	m.LockTimeout = migrationLockTimeout
+	return &Migrator{m: m, source: src}, nil
+}
+
+// Up applies all the pending migrations
+func (m *Migrator) Up() error {
+	err := m.m.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Down reverts the last n applied migrations
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return errors.New("number of migrations to revert must be positive")
+	}
+	return m.m.Steps(-n)
+}
+
+// Force sets the version without running the migrations and clears the dirty flag.
+// It is used to recover after a migration failed halfway and was fixed by hand.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Migration is a migration of the source along with whether it is applied
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+type MigrationStatus struct {
+	// Zero if no migrations are applied
+	Version uint
+	// The last migration failed and the schema has to be fixed by hand
+	Dirty      bool
+	Migrations []Migration
+}
+
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var status MigrationStatus
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Version, status.Dirty = version, dirty
+
+	v, err := m.source.First()
+	for err == nil {
+		r, name, readErr := m.source.ReadUp(v)
+		if readErr != nil {
+			return status, readErr
+		}
+		r.Close()
+		status.Migrations = append(status.Migrations, Migration{
+			Version: v,
+			Name:    name,
+			// The dirty migration is counted as applied, as it is the version the database is at
+			Applied: v <= status.Version,
+		})
+		v, err = m.source.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return status, err
+	}
+	return status, nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigratorStatus(t *testing.T) {
+	migrator, err := NewMigrator(testDatabaseURL)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	// TestMain applied all the migrations
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	require.False(t, status.Dirty)
+
+	ups, err := fs.Glob(migrationsFS, "migrations/*.up.sql")
+	require.NoError(t, err)
+	require.Len(t, status.Migrations, len(ups))
+	for _, m := range status.Migrations {
+		require.NotEmpty(t, m.Name)
+		require.True(t, m.Applied)
+	}
+	require.Equal(t, status.Migrations[len(status.Migrations)-1].Version, status.Version)
+}
+
+func TestMigratorUpNoChange(t *testing.T) {
+	migrator, err := NewMigrator(testDatabaseURL)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up())
+}
+
+func TestMigratorDownInvalid(t *testing.T) {
+	migrator, err := NewMigrator(testDatabaseURL)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.Error(t, migrator.Down(0))
+}
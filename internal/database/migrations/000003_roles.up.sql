@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'user';
+ALTER TABLE "users" ADD COLUMN "is_banned" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+-- NULL scopes stand for the full scope set of the user's role
+ALTER TABLE "sessions" ADD COLUMN "scopes" varchar[];
+ALTER TABLE "api_keys" ADD COLUMN "scopes" varchar[];
@@ -0,0 +1,38 @@
+CREATE TABLE "oauth_clients" (
+  "id" varchar PRIMARY KEY,
+  "owner_id" integer NOT NULL,
+  "name" varchar NOT NULL,
+  "redirect_uris" varchar[] NOT NULL,
+  "is_confidential" boolean NOT NULL DEFAULT false,
+  -- Empty for public clients
+  "secret_hash" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oauth_codes" (
+  "code_hash" varchar PRIMARY KEY,
+  "client_id" varchar NOT NULL,
+  "user_id" integer NOT NULL,
+  "redirect_uri" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "code_challenge" varchar NOT NULL,
+  "code_challenge_method" varchar NOT NULL,
+  -- The session started with the code, it is revoked if the code is used twice
+  "session_id" uuid,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- Sessions started by OAuth clients, NULL for the ones started by logging in
+ALTER TABLE "sessions" ADD COLUMN "client_id" varchar;
+
+CREATE INDEX ON "oauth_clients" ("owner_id");
+
+ALTER TABLE "oauth_clients" ADD FOREIGN KEY ("owner_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "oauth_codes" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "oauth_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id") ON DELETE CASCADE;
@@ -0,0 +1,32 @@
+-- Events aren't tied to users with a foreign key, so they outlive deleted accounts
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "type" varchar NOT NULL,
+  "outcome" varchar NOT NULL,
+  "user_id" integer,
+  "actor_id" integer,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "details" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_events" ("user_id", "created_at");
+
+CREATE INDEX ON "audit_events" ("type", "created_at");
+
+CREATE INDEX ON "audit_events" ("created_at");
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+BEFORE UPDATE OR DELETE ON "audit_events"
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+BEFORE TRUNCATE ON "audit_events"
+FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
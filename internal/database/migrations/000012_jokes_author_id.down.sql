@@ -0,0 +1,14 @@
+DROP VIEW IF EXISTS jokes_with_authors;
+
+ALTER TABLE "jokes" ADD COLUMN "author" varchar;
+
+UPDATE "jokes"
+SET "author" = "users"."username"
+FROM "users"
+WHERE "users"."id" = "jokes"."author_id";
+
+ALTER TABLE "jokes" ALTER COLUMN "author" SET NOT NULL;
+
+ALTER TABLE "jokes" DROP COLUMN "author_id";
+
+ALTER TABLE "jokes" ADD FOREIGN KEY ("author") REFERENCES "users" ("username");
@@ -0,0 +1,29 @@
+ALTER TABLE "jokes" ADD COLUMN "author_id" integer;
+
+UPDATE "jokes"
+SET "author_id" = "users"."id"
+FROM "users"
+WHERE "users"."username" = "jokes"."author";
+
+ALTER TABLE "jokes" ALTER COLUMN "author_id" SET NOT NULL;
+
+-- Drops the foreign key to users.username along with the column
+ALTER TABLE "jokes" DROP COLUMN "author";
+
+CREATE INDEX ON "jokes" ("author_id");
+
+ALTER TABLE "jokes" ADD FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Jokes are read with the current username of the author
+CREATE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
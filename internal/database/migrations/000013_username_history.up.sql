@@ -0,0 +1,12 @@
+CREATE TABLE "username_history" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" integer NOT NULL,
+  "old_username" varchar NOT NULL,
+  "changed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "username_history" ("old_username", "changed_at");
+
+CREATE INDEX ON "username_history" ("user_id", "changed_at");
+
+ALTER TABLE "username_history" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,20 @@
+DROP VIEW IF EXISTS jokes_with_authors;
+
+ALTER TABLE "jokes" DROP COLUMN IF EXISTS "laugh_count";
+ALTER TABLE "jokes" DROP COLUMN IF EXISTS "groan_count";
+ALTER TABLE "jokes" DROP COLUMN IF EXISTS "love_count";
+
+DROP TABLE IF EXISTS joke_reactions;
+
+CREATE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
@@ -0,0 +1,34 @@
+CREATE TABLE "joke_reactions" (
+  "user_id" integer NOT NULL,
+  "joke_id" integer NOT NULL,
+  "reaction" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "joke_id")
+);
+
+CREATE INDEX ON "joke_reactions" ("joke_id");
+
+ALTER TABLE "joke_reactions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "joke_reactions" ADD FOREIGN KEY ("joke_id") REFERENCES "jokes" ("id") ON DELETE CASCADE;
+
+-- Counts are kept on the jokes, so listing them doesn't aggregate the reactions
+ALTER TABLE "jokes" ADD COLUMN "laugh_count" integer NOT NULL DEFAULT 0;
+ALTER TABLE "jokes" ADD COLUMN "groan_count" integer NOT NULL DEFAULT 0;
+ALTER TABLE "jokes" ADD COLUMN "love_count" integer NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
@@ -0,0 +1,21 @@
+DROP VIEW IF EXISTS jokes_with_authors;
+
+DROP VIEW IF EXISTS comments_with_authors;
+
+DROP TABLE IF EXISTS comments;
+
+CREATE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
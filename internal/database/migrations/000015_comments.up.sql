@@ -0,0 +1,66 @@
+CREATE TABLE "comments" (
+  "id" serial PRIMARY KEY,
+  "joke_id" integer NOT NULL,
+  "author_id" integer NOT NULL,
+  -- NULL for the comments on the joke itself
+  "parent_id" integer,
+  -- Top-level comment of the thread, NULL for the top-level comments themselves
+  "root_id" integer,
+  "text" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "comments" ("joke_id", "id") WHERE "parent_id" IS NULL;
+
+CREATE INDEX ON "comments" ("root_id");
+
+CREATE INDEX ON "comments" ("parent_id");
+
+CREATE INDEX ON "comments" ("author_id");
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("joke_id") REFERENCES "jokes" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- Replies are deleted along with the comments they answer
+ALTER TABLE "comments" ADD FOREIGN KEY ("parent_id") REFERENCES "comments" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("root_id") REFERENCES "comments" ("id") ON DELETE CASCADE;
+
+-- Comments are read with the current username of the author
+CREATE VIEW "comments_with_authors" AS
+SELECT
+  "comments"."id",
+  "comments"."joke_id",
+  "comments"."parent_id",
+  "comments"."root_id",
+  "comments"."author_id",
+  "users"."username" AS "author",
+  "comments"."text",
+  "comments"."created_at",
+  "comments"."updated_at"
+FROM "comments"
+JOIN "users" ON "users"."id" = "comments"."author_id";
+
+-- Unlike the reactions, comments are counted by the view:
+-- the cascades delete whole threads, so a counter kept by the queries would drift
+CREATE OR REPLACE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id"
+  )::integer AS "comment_count"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
@@ -0,0 +1,25 @@
+DROP VIEW IF EXISTS jokes_with_authors;
+
+DROP TABLE IF EXISTS joke_tags;
+
+DROP TABLE IF EXISTS tags;
+
+CREATE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id"
+  )::integer AS "comment_count"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
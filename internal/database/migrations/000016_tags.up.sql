@@ -0,0 +1,43 @@
+CREATE TABLE "tags" (
+  "id" serial PRIMARY KEY,
+  "name" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "joke_tags" (
+  "joke_id" integer NOT NULL,
+  "tag_id" integer NOT NULL,
+  PRIMARY KEY ("joke_id", "tag_id")
+);
+
+CREATE INDEX ON "joke_tags" ("tag_id");
+
+ALTER TABLE "joke_tags" ADD FOREIGN KEY ("joke_id") REFERENCES "jokes" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "joke_tags" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE OR REPLACE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id"
+  )::integer AS "comment_count",
+  ARRAY(
+    SELECT "tags"."name" FROM "joke_tags"
+    JOIN "tags" ON "tags"."id" = "joke_tags"."tag_id"
+    WHERE "joke_tags"."joke_id" = "jokes"."id"
+    ORDER BY "tags"."name"
+  )::varchar[] AS "tags"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
@@ -0,0 +1,31 @@
+DROP VIEW IF EXISTS "jokes_with_authors";
+
+CREATE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id"
+  )::integer AS "comment_count",
+  ARRAY(
+    SELECT "tags"."name" FROM "joke_tags"
+    JOIN "tags" ON "tags"."id" = "joke_tags"."tag_id"
+    WHERE "joke_tags"."joke_id" = "jokes"."id"
+    ORDER BY "tags"."name"
+  )::varchar[] AS "tags"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
+
+ALTER TABLE "jokes" DROP COLUMN IF EXISTS "search_vector";
+
+ALTER TABLE "jokes" DROP COLUMN IF EXISTS "language";
@@ -0,0 +1,37 @@
+ALTER TABLE "jokes" ADD COLUMN "language" regconfig NOT NULL DEFAULT 'english';
+
+-- Matches in the title rank above the ones in the text, and those above the ones in the explanation
+ALTER TABLE "jokes" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector("language", "title"), 'A') ||
+  setweight(to_tsvector("language", "text"), 'B') ||
+  setweight(to_tsvector("language", "explanation"), 'C')
+) STORED;
+
+CREATE INDEX ON "jokes" USING GIN ("search_vector");
+
+CREATE OR REPLACE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id"
+  )::integer AS "comment_count",
+  ARRAY(
+    SELECT "tags"."name" FROM "joke_tags"
+    JOIN "tags" ON "tags"."id" = "joke_tags"."tag_id"
+    WHERE "joke_tags"."joke_id" = "jokes"."id"
+    ORDER BY "tags"."name"
+  )::varchar[] AS "tags",
+  "jokes"."language"::varchar AS "language"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "users_fullname_trgm_idx";
+
+DROP INDEX IF EXISTS "users_username_trgm_idx";
+
+DROP EXTENSION IF EXISTS pg_trgm;
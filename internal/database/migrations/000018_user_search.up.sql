@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX "users_username_trgm_idx" ON "users" USING GIN ("username" gin_trgm_ops);
+
+CREATE INDEX "users_fullname_trgm_idx" ON "users" USING GIN ("fullname" gin_trgm_ops);
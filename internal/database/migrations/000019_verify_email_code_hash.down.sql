@@ -0,0 +1,4 @@
+-- The hashes can't be turned back into the codes, so the links sent already stop working
+UPDATE "verify_emails" SET "expired_at" = now() WHERE "expired_at" > now();
+
+ALTER TABLE "verify_emails" RENAME COLUMN "code_hash" TO "secret_code";
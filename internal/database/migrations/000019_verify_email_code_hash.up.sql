@@ -0,0 +1,4 @@
+ALTER TABLE "verify_emails" RENAME COLUMN "secret_code" TO "code_hash";
+
+-- The codes sent already keep working, the same way the new ones are hashed
+UPDATE "verify_emails" SET "code_hash" = encode(sha256(convert_to("code_hash", 'UTF8')), 'hex');
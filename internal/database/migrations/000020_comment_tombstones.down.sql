@@ -0,0 +1,61 @@
+CREATE OR REPLACE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id"
+  )::integer AS "comment_count",
+  ARRAY(
+    SELECT "tags"."name" FROM "joke_tags"
+    JOIN "tags" ON "tags"."id" = "joke_tags"."tag_id"
+    WHERE "joke_tags"."joke_id" = "jokes"."id"
+    ORDER BY "tags"."name"
+  )::varchar[] AS "tags",
+  "jokes"."language"::varchar AS "language"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
+
+CREATE OR REPLACE VIEW "comments_with_authors" AS
+SELECT
+  "comments"."id",
+  "comments"."joke_id",
+  "comments"."parent_id",
+  "comments"."root_id",
+  "comments"."author_id",
+  "users"."username" AS "author",
+  "comments"."text",
+  "comments"."created_at",
+  "comments"."updated_at"
+FROM "comments"
+JOIN "users" ON "users"."id" = "comments"."author_id";
+
+ALTER TABLE "comments" DROP CONSTRAINT "comments_root_id_fkey";
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("root_id") REFERENCES "comments" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "comments" DROP CONSTRAINT "comments_parent_id_fkey";
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("parent_id") REFERENCES "comments" ("id") ON DELETE CASCADE;
+
+DROP TRIGGER IF EXISTS comments_tombstone ON "comments";
+
+DROP FUNCTION IF EXISTS comments_clear_tombstone;
+
+-- The tombstones have no author to go back to
+DELETE FROM "comments" WHERE "author_id" IS NULL;
+
+ALTER TABLE "comments" DROP CONSTRAINT "comments_author_id_fkey";
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "comments" ALTER COLUMN "author_id" SET NOT NULL;
@@ -0,0 +1,70 @@
+-- Deleted comments with replies and the comments of deleted users stay in their threads as tombstones:
+-- the author is NULL and the text is cleared
+ALTER TABLE "comments" ALTER COLUMN "author_id" DROP NOT NULL;
+
+ALTER TABLE "comments" DROP CONSTRAINT "comments_author_id_fkey";
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE SET NULL;
+
+CREATE FUNCTION comments_clear_tombstone() RETURNS trigger AS $$
+BEGIN
+  NEW.text := '';
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comments_tombstone
+BEFORE UPDATE OF "author_id" ON "comments"
+FOR EACH ROW WHEN (NEW."author_id" IS NULL)
+EXECUTE FUNCTION comments_clear_tombstone();
+
+-- Comments with replies aren't deleted, so the replies are never deleted along with them
+ALTER TABLE "comments" DROP CONSTRAINT "comments_parent_id_fkey";
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("parent_id") REFERENCES "comments" ("id");
+
+ALTER TABLE "comments" DROP CONSTRAINT "comments_root_id_fkey";
+
+ALTER TABLE "comments" ADD FOREIGN KEY ("root_id") REFERENCES "comments" ("id");
+
+CREATE OR REPLACE VIEW "comments_with_authors" AS
+SELECT
+  "comments"."id",
+  "comments"."joke_id",
+  "comments"."parent_id",
+  "comments"."root_id",
+  "comments"."author_id",
+  "users"."username" AS "author",
+  "comments"."text",
+  "comments"."created_at",
+  "comments"."updated_at"
+FROM "comments"
+LEFT JOIN "users" ON "users"."id" = "comments"."author_id";
+
+-- Tombstones aren't counted
+CREATE OR REPLACE VIEW "jokes_with_authors" AS
+SELECT
+  "jokes"."id",
+  "jokes"."author_id",
+  "users"."username" AS "author",
+  "jokes"."title",
+  "jokes"."text",
+  "jokes"."explanation",
+  "jokes"."created_at",
+  "jokes"."updated_at",
+  "jokes"."laugh_count",
+  "jokes"."groan_count",
+  "jokes"."love_count",
+  (
+    SELECT count(*) FROM "comments"
+    WHERE "comments"."joke_id" = "jokes"."id" AND "comments"."author_id" IS NOT NULL
+  )::integer AS "comment_count",
+  ARRAY(
+    SELECT "tags"."name" FROM "joke_tags"
+    JOIN "tags" ON "tags"."id" = "joke_tags"."tag_id"
+    WHERE "joke_tags"."joke_id" = "jokes"."id"
+    ORDER BY "tags"."name"
+  )::varchar[] AS "tags",
+  "jokes"."language"::varchar AS "language"
+FROM "jokes"
+JOIN "users" ON "users"."id" = "jokes"."author_id";
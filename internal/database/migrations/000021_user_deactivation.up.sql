@@ -0,0 +1,2 @@
+-- Deactivated users are hidden until they log in again
+ALTER TABLE "users" ADD COLUMN "is_deactivated" boolean NOT NULL DEFAULT false;
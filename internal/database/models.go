@@ -5,28 +5,212 @@
 package database
 
 import (
+	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         int64        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	IsRevoked  bool         `json:"is_revoked"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Scopes     []string     `json:"scopes"`
+}
+
+type AuditEvent struct {
+	ID        int64         `json:"id"`
+	Type      string        `json:"type"`
+	Outcome   string        `json:"outcome"`
+	UserID    sql.NullInt32 `json:"user_id"`
+	ActorID   sql.NullInt32 `json:"actor_id"`
+	ClientIp  string        `json:"client_ip"`
+	UserAgent string        `json:"user_agent"`
+	Details   string        `json:"details"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Comment struct {
+	ID        int32         `json:"id"`
+	JokeID    int32         `json:"joke_id"`
+	AuthorID  sql.NullInt32 `json:"author_id"`
+	ParentID  sql.NullInt32 `json:"parent_id"`
+	RootID    sql.NullInt32 `json:"root_id"`
+	Text      string        `json:"text"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type CommentsWithAuthor struct {
+	ID        int32          `json:"id"`
+	JokeID    int32          `json:"joke_id"`
+	ParentID  sql.NullInt32  `json:"parent_id"`
+	RootID    sql.NullInt32  `json:"root_id"`
+	AuthorID  sql.NullInt32  `json:"author_id"`
+	Author    sql.NullString `json:"author"`
+	Text      string         `json:"text"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
 type Joke struct {
-	ID          int32     `json:"id"`
-	Author      string    `json:"author"`
-	Title       string    `json:"title"`
-	Text        string    `json:"text"`
-	Explanation string    `json:"explanation"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           int32     `json:"id"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	Explanation  string    `json:"explanation"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	AuthorID     int32     `json:"author_id"`
+	LaughCount   int32     `json:"laugh_count"`
+	GroanCount   int32     `json:"groan_count"`
+	LoveCount    int32     `json:"love_count"`
+	Language     string    `json:"language"`
+	SearchVector string    `json:"-"`
 }
 
-type User struct {
-	ID             int32     `json:"id"`
-	Username       string    `json:"username"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	Avatar         string    `json:"avatar"`
-	Fullname       string    `json:"fullname"`
-	Bio            string    `json:"bio"`
-	Status         string    `json:"status"`
+type JokeReaction struct {
+	UserID    int32     `json:"user_id"`
+	JokeID    int32     `json:"joke_id"`
+	Reaction  string    `json:"reaction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type JokeTag struct {
+	JokeID int32 `json:"joke_id"`
+	TagID  int32 `json:"tag_id"`
+}
+
+type JokesWithAuthor struct {
+	ID           int32     `json:"id"`
+	AuthorID     int32     `json:"author_id"`
+	Author       string    `json:"author"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	Explanation  string    `json:"explanation"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	LaughCount   int32     `json:"laugh_count"`
+	GroanCount   int32     `json:"groan_count"`
+	LoveCount    int32     `json:"love_count"`
+	CommentCount int32     `json:"comment_count"`
+	Tags         []string  `json:"tags"`
+	Language     string    `json:"language"`
+}
+
+type LoginAttempt struct {
+	Key          string    `json:"key"`
+	Failures     int32     `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+type LoginChallenge struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    int32     `json:"user_id"`
+	Attempts  int32     `json:"attempts"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OauthClient struct {
+	ID             string    `json:"id"`
+	OwnerID        int32     `json:"owner_id"`
+	Name           string    `json:"name"`
+	RedirectUris   []string  `json:"redirect_uris"`
+	IsConfidential bool      `json:"is_confidential"`
+	SecretHash     string    `json:"secret_hash"`
 	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type OauthCode struct {
+	CodeHash            string        `json:"code_hash"`
+	ClientID            string        `json:"client_id"`
+	UserID              int32         `json:"user_id"`
+	RedirectUri         string        `json:"redirect_uri"`
+	Scopes              []string      `json:"scopes"`
+	CodeChallenge       string        `json:"code_challenge"`
+	CodeChallengeMethod string        `json:"code_challenge_method"`
+	SessionID           uuid.NullUUID `json:"session_id"`
+	IsUsed              bool          `json:"is_used"`
+	ExpiresAt           time.Time     `json:"expires_at"`
+	CreatedAt           time.Time     `json:"created_at"`
+}
+
+type PasswordReset struct {
+	ID        int64     `json:"id"`
+	UserID    int32     `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+type RecoveryCode struct {
+	ID        int64     `json:"id"`
+	UserID    int32     `json:"user_id"`
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID      `json:"id"`
+	UserID       int32          `json:"user_id"`
+	RefreshToken string         `json:"refresh_token"`
+	UserAgent    string         `json:"user_agent"`
+	ClientIp     string         `json:"client_ip"`
+	IsBlocked    bool           `json:"is_blocked"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Scopes       []string       `json:"scopes"`
+	ClientID     sql.NullString `json:"client_id"`
+}
+
+type Tag struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type User struct {
+	ID              int32     `json:"id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	HashedPassword  string    `json:"hashed_password"`
+	Avatar          string    `json:"avatar"`
+	Fullname        string    `json:"fullname"`
+	Bio             string    `json:"bio"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Role            string    `json:"role"`
+	IsBanned        bool      `json:"is_banned"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	TotpSecret      string    `json:"totp_secret"`
+	IsTotpEnabled   bool      `json:"is_totp_enabled"`
+	TotpLastStep    int64     `json:"totp_last_step"`
+	IsDeactivated   bool      `json:"is_deactivated"`
+}
+
+type UsernameHistory struct {
+	ID          int64     `json:"id"`
+	UserID      int32     `json:"user_id"`
+	OldUsername string    `json:"old_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+type VerifyEmail struct {
+	ID        int64     `json:"id"`
+	UserID    int32     `json:"user_id"`
+	Email     string    `json:"email"`
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
 }
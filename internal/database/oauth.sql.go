@@ -0,0 +1,259 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: oauth.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createOAuthClient = `-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+    id,
+    owner_id,
+    name,
+    redirect_uris,
+    is_confidential,
+    secret_hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, owner_id, name, redirect_uris, is_confidential, secret_hash, created_at
+`
+
+type CreateOAuthClientParams struct {
+	ID             string   `json:"id"`
+	OwnerID        int32    `json:"owner_id"`
+	Name           string   `json:"name"`
+	RedirectUris   []string `json:"redirect_uris"`
+	IsConfidential bool     `json:"is_confidential"`
+	SecretHash     string   `json:"secret_hash"`
+}
+
+func (q *Queries) CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, createOAuthClient,
+		arg.ID,
+		arg.OwnerID,
+		arg.Name,
+		pq.Array(arg.RedirectUris),
+		arg.IsConfidential,
+		arg.SecretHash,
+	)
+	var i OauthClient
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		pq.Array(&i.RedirectUris),
+		&i.IsConfidential,
+		&i.SecretHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOAuthCode = `-- name: CreateOAuthCode :one
+INSERT INTO oauth_codes (
+    code_hash,
+    client_id,
+    user_id,
+    redirect_uri,
+    scopes,
+    code_challenge,
+    code_challenge_method,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, session_id, is_used, expires_at, created_at
+`
+
+type CreateOAuthCodeParams struct {
+	CodeHash            string    `json:"code_hash"`
+	ClientID            string    `json:"client_id"`
+	UserID              int32     `json:"user_id"`
+	RedirectUri         string    `json:"redirect_uri"`
+	Scopes              []string  `json:"scopes"`
+	CodeChallenge       string    `json:"code_challenge"`
+	CodeChallengeMethod string    `json:"code_challenge_method"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateOAuthCode(ctx context.Context, arg CreateOAuthCodeParams) (OauthCode, error) {
+	row := q.db.QueryRowContext(ctx, createOAuthCode,
+		arg.CodeHash,
+		arg.ClientID,
+		arg.UserID,
+		arg.RedirectUri,
+		pq.Array(arg.Scopes),
+		arg.CodeChallenge,
+		arg.CodeChallengeMethod,
+		arg.ExpiresAt,
+	)
+	var i OauthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.CodeChallengeMethod,
+		&i.SessionID,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteOwnedOAuthClient = `-- name: DeleteOwnedOAuthClient :execrows
+
+DELETE FROM oauth_clients
+WHERE id = $1 AND owner_id = $2
+`
+
+type DeleteOwnedOAuthClientParams struct {
+	ID      string `json:"id"`
+	OwnerID int32  `json:"owner_id"`
+}
+
+// DELETE QUERIES
+func (q *Queries) DeleteOwnedOAuthClient(ctx context.Context, arg DeleteOwnedOAuthClientParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOwnedOAuthClient, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOAuthClient = `-- name: GetOAuthClient :one
+
+SELECT id, owner_id, name, redirect_uris, is_confidential, secret_hash, created_at FROM oauth_clients
+WHERE id = $1 LIMIT 1
+`
+
+// GET QUERIES
+func (q *Queries) GetOAuthClient(ctx context.Context, id string) (OauthClient, error) {
+	row := q.db.QueryRowContext(ctx, getOAuthClient, id)
+	var i OauthClient
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		pq.Array(&i.RedirectUris),
+		&i.IsConfidential,
+		&i.SecretHash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOAuthCode = `-- name: GetOAuthCode :one
+SELECT code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, session_id, is_used, expires_at, created_at FROM oauth_codes
+WHERE code_hash = $1 LIMIT 1
+`
+
+func (q *Queries) GetOAuthCode(ctx context.Context, codeHash string) (OauthCode, error) {
+	row := q.db.QueryRowContext(ctx, getOAuthCode, codeHash)
+	var i OauthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.CodeChallengeMethod,
+		&i.SessionID,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listOAuthClientsByOwner = `-- name: ListOAuthClientsByOwner :many
+SELECT id, owner_id, name, redirect_uris, is_confidential, secret_hash, created_at FROM oauth_clients
+WHERE owner_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListOAuthClientsByOwner(ctx context.Context, ownerID int32) ([]OauthClient, error) {
+	rows, err := q.db.QueryContext(ctx, listOAuthClientsByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OauthClient
+	for rows.Next() {
+		var i OauthClient
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			pq.Array(&i.RedirectUris),
+			&i.IsConfidential,
+			&i.SecretHash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setOAuthCodeSession = `-- name: SetOAuthCodeSession :exec
+UPDATE oauth_codes
+SET session_id = $2
+WHERE code_hash = $1
+`
+
+type SetOAuthCodeSessionParams struct {
+	CodeHash  string        `json:"code_hash"`
+	SessionID uuid.NullUUID `json:"session_id"`
+}
+
+func (q *Queries) SetOAuthCodeSession(ctx context.Context, arg SetOAuthCodeSessionParams) error {
+	_, err := q.db.ExecContext(ctx, setOAuthCodeSession, arg.CodeHash, arg.SessionID)
+	return err
+}
+
+const useOAuthCode = `-- name: UseOAuthCode :one
+
+UPDATE oauth_codes
+SET is_used = true
+WHERE code_hash = $1 AND is_used = false
+RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, session_id, is_used, expires_at, created_at
+`
+
+// UPDATE QUERIES
+func (q *Queries) UseOAuthCode(ctx context.Context, codeHash string) (OauthCode, error) {
+	row := q.db.QueryRowContext(ctx, useOAuthCode, codeHash)
+	var i OauthCode
+	err := row.Scan(
+		&i.CodeHash,
+		&i.ClientID,
+		&i.UserID,
+		&i.RedirectUri,
+		pq.Array(&i.Scopes),
+		&i.CodeChallenge,
+		&i.CodeChallengeMethod,
+		&i.SessionID,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomOAuthClient(t *testing.T, ownerID int32) OauthClient {
+	arg := CreateOAuthClientParams{
+		ID:             random.RandomString(16),
+		OwnerID:        ownerID,
+		Name:           random.RandomString(10),
+		RedirectUris:   []string{"https://example.com/callback", "com.example.app:/callback"},
+		IsConfidential: true,
+		SecretHash:     random.RandomString(64),
+	}
+
+	client, err := testQueries.CreateOAuthClient(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.ID, client.ID)
+	require.Equal(t, arg.OwnerID, client.OwnerID)
+	require.Equal(t, arg.Name, client.Name)
+	require.Equal(t, arg.RedirectUris, client.RedirectUris)
+	require.True(t, client.IsConfidential)
+	require.Equal(t, arg.SecretHash, client.SecretHash)
+	require.NotZero(t, client.CreatedAt)
+
+	return client
+}
+
+func CreateRandomOAuthCode(t *testing.T, client OauthClient, userID int32) OauthCode {
+	arg := CreateOAuthCodeParams{
+		CodeHash:            random.RandomString(64),
+		ClientID:            client.ID,
+		UserID:              userID,
+		RedirectUri:         client.RedirectUris[0],
+		Scopes:              []string{"profile:read"},
+		CodeChallenge:       random.RandomString(43),
+		CodeChallengeMethod: "S256",
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}
+
+	code, err := testQueries.CreateOAuthCode(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.CodeHash, code.CodeHash)
+	require.Equal(t, arg.ClientID, code.ClientID)
+	require.Equal(t, arg.UserID, code.UserID)
+	require.Equal(t, arg.Scopes, code.Scopes)
+	require.False(t, code.IsUsed)
+	require.False(t, code.SessionID.Valid)
+
+	return code
+}
+
+func TestCreateOAuthClient(t *testing.T) {
+	user := CreateRandomUser(t)
+	CreateRandomOAuthClient(t, user.ID)
+}
+
+func TestListOAuthClientsByOwner(t *testing.T) {
+	user := CreateRandomUser(t)
+	for i := 0; i < 3; i++ {
+		CreateRandomOAuthClient(t, user.ID)
+	}
+
+	clients, err := testQueries.ListOAuthClientsByOwner(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, clients, 3)
+}
+
+func TestUseOAuthCode(t *testing.T) {
+	user := CreateRandomUser(t)
+	client := CreateRandomOAuthClient(t, user.ID)
+	code1 := CreateRandomOAuthCode(t, client, user.ID)
+
+	code2, err := testQueries.UseOAuthCode(context.Background(), code1.CodeHash)
+	require.NoError(t, err)
+	require.True(t, code2.IsUsed)
+
+	// Codes are single-use
+	_, err = testQueries.UseOAuthCode(context.Background(), code1.CodeHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	session := CreateRandomSession(t, user.ID)
+	err = testQueries.SetOAuthCodeSession(context.Background(), SetOAuthCodeSessionParams{
+		CodeHash:  code1.CodeHash,
+		SessionID: uuid.NullUUID{UUID: session.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	code3, err := testQueries.GetOAuthCode(context.Background(), code1.CodeHash)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, code3.SessionID.UUID)
+}
+
+func TestDeleteOwnedOAuthClient(t *testing.T) {
+	user := CreateRandomUser(t)
+	stranger := CreateRandomUser(t)
+	client := CreateRandomOAuthClient(t, user.ID)
+
+	rows, err := testQueries.DeleteOwnedOAuthClient(context.Background(), DeleteOwnedOAuthClientParams{
+		ID:      client.ID,
+		OwnerID: stranger.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.DeleteOwnedOAuthClient(context.Background(), DeleteOwnedOAuthClientParams{
+		ID:      client.ID,
+		OwnerID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = testQueries.GetOAuthClient(context.Background(), client.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,112 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: password_resets.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    user_id,
+    token_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, user_id, token_hash, is_used, created_at, expired_at
+`
+
+type CreatePasswordResetParams struct {
+	UserID    int32     `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordReset, arg.UserID, arg.TokenHash, arg.ExpiredAt)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const getUserByPasswordReset = `-- name: GetUserByPasswordReset :one
+
+SELECT users.id, users.username, users.email, users.hashed_password, users.avatar, users.fullname, users.bio, users.status, users.created_at, users.updated_at, users.role, users.is_banned, users.is_email_verified, users.totp_secret, users.is_totp_enabled, users.totp_last_step, users.is_deactivated FROM users
+JOIN password_resets ON password_resets.user_id = users.id
+WHERE password_resets.token_hash = $1
+    AND password_resets.is_used = false
+    AND password_resets.expired_at > now()
+`
+
+// GET QUERIES
+func (q *Queries) GetUserByPasswordReset(ctx context.Context, tokenHash string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByPasswordReset, tokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const usePasswordReset = `-- name: UsePasswordReset :one
+
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = $1
+    AND is_used = false
+    AND expired_at > now()
+RETURNING id, user_id, token_hash, is_used, created_at, expired_at
+`
+
+// UPDATE QUERIES
+func (q *Queries) UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordReset, tokenHash)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const useUserPasswordResets = `-- name: UseUserPasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE user_id = $1 AND is_used = false
+`
+
+func (q *Queries) UseUserPasswordResets(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, useUserPasswordResets, userID)
+	return err
+}
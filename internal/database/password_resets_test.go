@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomPasswordReset(t *testing.T, userID int32, expiredAt time.Time) PasswordReset {
+	arg := CreatePasswordResetParams{
+		UserID:    userID,
+		TokenHash: random.RandomString(64),
+		ExpiredAt: expiredAt,
+	}
+
+	reset, err := testQueries.CreatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, reset)
+
+	require.Equal(t, arg.UserID, reset.UserID)
+	require.Equal(t, arg.TokenHash, reset.TokenHash)
+	require.False(t, reset.IsUsed)
+	require.NotZero(t, reset.ID)
+
+	return reset
+}
+
+func TestUsePasswordReset(t *testing.T) {
+	user := CreateRandomUser(t)
+	reset1 := CreateRandomPasswordReset(t, user.ID, time.Now().Add(time.Hour))
+
+	reset2, err := testQueries.UsePasswordReset(context.Background(), reset1.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, reset1.ID, reset2.ID)
+	require.True(t, reset2.IsUsed)
+
+	// The token is single-use
+	_, err = testQueries.UsePasswordReset(context.Background(), reset1.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestUsePasswordResetExpired(t *testing.T) {
+	user := CreateRandomUser(t)
+	reset := CreateRandomPasswordReset(t, user.ID, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.UsePasswordReset(context.Background(), reset.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestUseUserPasswordResets(t *testing.T) {
+	user := CreateRandomUser(t)
+	reset1 := CreateRandomPasswordReset(t, user.ID, time.Now().Add(time.Hour))
+	reset2 := CreateRandomPasswordReset(t, user.ID, time.Now().Add(time.Hour))
+
+	err := testQueries.UseUserPasswordResets(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	for _, reset := range []PasswordReset{reset1, reset2} {
+		_, err = testQueries.UsePasswordReset(context.Background(), reset.TokenHash)
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+	}
+}
+
+func TestGetUserByPasswordReset(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	reset := CreateRandomPasswordReset(t, user1.ID, time.Now().Add(time.Hour))
+
+	user2, err := testQueries.GetUserByPasswordReset(context.Background(), reset.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user2.ID)
+
+	// Used tokens don't resolve to the user
+	_, err = testQueries.UsePasswordReset(context.Background(), reset.TokenHash)
+	require.NoError(t, err)
+	_, err = testQueries.GetUserByPasswordReset(context.Background(), reset.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,178 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Querier interface {
+	// The counters are changed in the transactions that change the reactions
+	AddJokeReactionCounts(ctx context.Context, arg AddJokeReactionCountsParams) error
+	AddJokeTags(ctx context.Context, arg AddJokeTagsParams) error
+	BlockOwnedSession(ctx context.Context, arg BlockOwnedSessionParams) (int64, error)
+	// UPDATE QUERIES
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, userID int32) error
+	// Gives the role to the user with the verified email if no user has it yet
+	BootstrapUserRole(ctx context.Context, arg BootstrapUserRoleParams) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	// Audit events are append-only, there are no update or delete queries
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error
+	CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error)
+	CreateJoke(ctx context.Context, arg CreateJokeParams) (Joke, error)
+	CreateJokeReaction(ctx context.Context, arg CreateJokeReactionParams) (JokeReaction, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error)
+	CreateOAuthCode(ctx context.Context, arg CreateOAuthCodeParams) (OauthCode, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUsernameHistory(ctx context.Context, arg CreateUsernameHistoryParams) error
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	DeleteAllJokes(ctx context.Context) error
+	DeleteAllUsers(ctx context.Context) error
+	// Deletes the tombstone if its last reply is gone, returns the comment it answers
+	DeleteEmptyTombstone(ctx context.Context, id int32) (sql.NullInt32, error)
+	// DELETE QUERIES
+	DeleteJoke(ctx context.Context, id int32) error
+	// DELETE QUERIES
+	DeleteJokeReaction(ctx context.Context, arg DeleteJokeReactionParams) (JokeReaction, error)
+	// DELETE QUERIES
+	DeleteJokeTags(ctx context.Context, jokeID int32) error
+	DeleteJokesByAuthor(ctx context.Context, authorID int32) error
+	// DELETE QUERIES
+	DeleteLoginAttempt(ctx context.Context, key string) error
+	// DELETE QUERIES
+	// Deletes the comment if it has no replies, returns the comment it answers
+	DeleteOwnedComment(ctx context.Context, arg DeleteOwnedCommentParams) (sql.NullInt32, error)
+	DeleteOwnedJoke(ctx context.Context, arg DeleteOwnedJokeParams) (int64, error)
+	// DELETE QUERIES
+	DeleteOwnedOAuthClient(ctx context.Context, arg DeleteOwnedOAuthClientParams) (int64, error)
+	DeleteStaleLoginAttempts(ctx context.Context, lastFailedAt time.Time) error
+	// DELETE QUERIES
+	DeleteUser(ctx context.Context, id int32) error
+	// DELETE QUERIES
+	DeleteUserRecoveryCodes(ctx context.Context, userID int32) error
+	DisableUserTotp(ctx context.Context, id int32) (User, error)
+	EnableUserTotp(ctx context.Context, arg EnableUserTotpParams) (User, error)
+	// GET QUERIES
+	GetComment(ctx context.Context, id int32) (CommentsWithAuthor, error)
+	// GET QUERIES
+	GetJoke(ctx context.Context, id int32) (JokesWithAuthor, error)
+	// GET QUERIES
+	GetJokeReactionForUpdate(ctx context.Context, arg GetJokeReactionForUpdateParams) (JokeReaction, error)
+	// GET QUERIES
+	GetLastUsernameChange(ctx context.Context, userID int32) (time.Time, error)
+	// GET QUERIES
+	GetLoginAttempt(ctx context.Context, key string) (LoginAttempt, error)
+	// GET QUERIES
+	GetLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	// GET QUERIES
+	GetOAuthClient(ctx context.Context, id string) (OauthClient, error)
+	GetOAuthCode(ctx context.Context, codeHash string) (OauthCode, error)
+	// Finds the current username of the user who had the old one after the given time
+	GetRenamedUsername(ctx context.Context, arg GetRenamedUsernameParams) (string, error)
+	// GET QUERIES
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	// GET QUERIES
+	GetUserByID(ctx context.Context, id int32) (User, error)
+	GetUserByName(ctx context.Context, username string) (User, error)
+	// GET QUERIES
+	GetUserByPasswordReset(ctx context.Context, tokenHash string) (User, error)
+	IncrementLoginChallengeAttempts(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	// Old usernames are kept for their users during the grace period, so the redirects don't go to someone else
+	IsUsernameReserved(ctx context.Context, arg IsUsernameReservedParams) (bool, error)
+	// GET QUERIES
+	ListAPIKeysByUser(ctx context.Context, userID int32) ([]ApiKey, error)
+	// Every filter is optional, NULL matches all events
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	// GET QUERIES
+	ListAuditEventsByUser(ctx context.Context, arg ListAuditEventsByUserParams) ([]AuditEvent, error)
+	// The oldest replies in each of the threads of the top-level comments, at most limit_ per thread.
+	// The parents are older than their replies, so every listed reply answers a listed comment.
+	ListCommentReplies(ctx context.Context, arg ListCommentRepliesParams) ([]CommentsWithAuthor, error)
+	// Top-level comments of the joke after the cursor, oldest first
+	ListComments(ctx context.Context, arg ListCommentsParams) ([]CommentsWithAuthor, error)
+	// Jokes with any of any_tags and all of all_tags, empty or NULL arrays match all jokes
+	ListJokes(ctx context.Context, arg ListJokesParams) ([]JokesWithAuthor, error)
+	ListJokesByAuthor(ctx context.Context, arg ListJokesByAuthorParams) ([]JokesWithAuthor, error)
+	ListOAuthClientsByOwner(ctx context.Context, ownerID int32) ([]OauthClient, error)
+	ListSessionsByUser(ctx context.Context, userID int32) ([]Session, error)
+	// GET QUERIES
+	// Tags in use with the number of their jokes, the most used first
+	ListTags(ctx context.Context, arg ListTagsParams) ([]ListTagsRow, error)
+	// Replies in the thread of the top-level comment after the cursor, oldest first
+	ListThreadReplies(ctx context.Context, arg ListThreadRepliesParams) ([]CommentsWithAuthor, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	LockLoginAttempt(ctx context.Context, arg LockLoginAttemptParams) error
+	// UPDATE QUERIES
+	// The counter starts over if the last failure is older than the window
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error)
+	// Replaces the hash only if the password wasn't changed since the old hash was read
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error)
+	RemoveUserReactionCounts(ctx context.Context, userID int32) error
+	RevokeOwnedAPIKey(ctx context.Context, arg RevokeOwnedAPIKeyParams) (int64, error)
+	// Jokes in the language matching the tsquery, the best matches first.
+	// The query is stemmed with the language. The snippets mark the matches with U+E000 and U+E001,
+	// search.HighlightStart and search.HighlightStop, which are taken out of the text beforehand.
+	SearchJokes(ctx context.Context, arg SearchJokesParams) ([]SearchJokesRow, error)
+	// Users whose username or full name is like the query, the most alike first.
+	// The trigram operators keep typos in, and the user with the username itself is always the first.
+	// Only the public columns are returned, banned and deactivated users aren't found.
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
+	SetOAuthCodeSession(ctx context.Context, arg SetOAuthCodeSessionParams) error
+	// Turns the comment into a tombstone if it has replies, the trigger clears the text
+	TombstoneOwnedComment(ctx context.Context, arg TombstoneOwnedCommentParams) (int64, error)
+	UpdateJokeExplanation(ctx context.Context, arg UpdateJokeExplanationParams) (Joke, error)
+	// UPDATE QUERIES
+	UpdateJokeReaction(ctx context.Context, arg UpdateJokeReactionParams) (JokeReaction, error)
+	UpdateJokeText(ctx context.Context, arg UpdateJokeTextParams) (Joke, error)
+	// UPDATE QUERIES
+	UpdateJokeTitle(ctx context.Context, arg UpdateJokeTitleParams) (Joke, error)
+	// UPDATE QUERIES
+	UpdateOwnedCommentText(ctx context.Context, arg UpdateOwnedCommentTextParams) (Comment, error)
+	UpdateOwnedJokeExplanation(ctx context.Context, arg UpdateOwnedJokeExplanationParams) (Joke, error)
+	UpdateOwnedJokeLanguage(ctx context.Context, arg UpdateOwnedJokeLanguageParams) (Joke, error)
+	UpdateOwnedJokeText(ctx context.Context, arg UpdateOwnedJokeTextParams) (Joke, error)
+	UpdateOwnedJokeTitle(ctx context.Context, arg UpdateOwnedJokeTitleParams) (Joke, error)
+	UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) (User, error)
+	UpdateUserBanned(ctx context.Context, arg UpdateUserBannedParams) (User, error)
+	UpdateUserBio(ctx context.Context, arg UpdateUserBioParams) (User, error)
+	UpdateUserDeactivated(ctx context.Context, arg UpdateUserDeactivatedParams) (User, error)
+	UpdateUserFullname(ctx context.Context, arg UpdateUserFullnameParams) (User, error)
+	// UPDATE QUERIES
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error)
+	UpdateUserTotpSecret(ctx context.Context, arg UpdateUserTotpSecretParams) (User, error)
+	UpdateUserUpdatedAt(ctx context.Context, id int32) error
+	UpdateUsername(ctx context.Context, arg UpdateUsernameParams) (User, error)
+	// Returns the tags with the given names, creating the missing ones
+	UpsertTags(ctx context.Context, names []string) ([]Tag, error)
+	// UPDATE QUERIES
+	// Finds the active key with its user and marks it as used
+	UseAPIKey(ctx context.Context, keyHash string) (UseAPIKeyRow, error)
+	UseLoginChallenge(ctx context.Context, id uuid.UUID) (int64, error)
+	// UPDATE QUERIES
+	UseOAuthCode(ctx context.Context, codeHash string) (OauthCode, error)
+	// UPDATE QUERIES
+	UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	// UPDATE QUERIES
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	UseUserPasswordResets(ctx context.Context, userID int32) error
+	// A TOTP code is accepted only once: the step of every used code has to be later than the last one
+	UseUserTotpStep(ctx context.Context, arg UseUserTotpStepParams) (int64, error)
+	// UPDATE QUERIES
+	VerifyEmail(ctx context.Context, arg VerifyEmailParams) (User, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,34 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    user_id,
+    name,
+    prefix,
+    key_hash,
+    scopes
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: ListAPIKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- UPDATE QUERIES
+
+-- Finds the active key with its user and marks it as used
+-- name: UseAPIKey :one
+UPDATE api_keys
+SET last_used_at = now()
+FROM users
+WHERE api_keys.key_hash = $1
+    AND api_keys.is_revoked = false
+    AND users.id = api_keys.user_id
+RETURNING api_keys.id, api_keys.user_id, api_keys.scopes, users.username, users.email, users.role, users.is_banned;
+
+-- name: RevokeOwnedAPIKey :execrows
+UPDATE api_keys
+SET is_revoked = true
+WHERE id = $1 AND user_id = $2;
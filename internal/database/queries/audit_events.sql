@@ -0,0 +1,34 @@
+-- Audit events are append-only, there are no update or delete queries
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (
+    type,
+    outcome,
+    user_id,
+    actor_id,
+    client_ip,
+    user_agent,
+    details
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- GET QUERIES
+
+-- name: ListAuditEventsByUser :many
+SELECT * FROM audit_events
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+OFFSET $3;
+
+-- Every filter is optional, NULL matches all events
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(user_id)::integer IS NULL OR user_id = sqlc.narg(user_id))
+    AND (sqlc.narg(type)::varchar IS NULL OR type = sqlc.narg(type))
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(limit_)
+OFFSET sqlc.arg(offset_);
@@ -0,0 +1,74 @@
+-- name: CreateComment :one
+INSERT INTO comments (
+    joke_id,
+    author_id,
+    parent_id,
+    root_id,
+    text
+) VALUES (
+    $1, sqlc.arg(author_id)::integer, $2, $3, $4
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: GetComment :one
+SELECT * FROM comments_with_authors
+WHERE id = $1 LIMIT 1;
+
+-- Top-level comments of the joke after the cursor, oldest first
+-- name: ListComments :many
+SELECT * FROM comments_with_authors
+WHERE joke_id = sqlc.arg(joke_id) AND parent_id IS NULL AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_);
+
+-- The oldest replies in each of the threads of the top-level comments, at most limit_ per thread.
+-- The parents are older than their replies, so every listed reply answers a listed comment.
+-- name: ListCommentReplies :many
+SELECT replies.id, replies.joke_id, replies.parent_id, replies.root_id, replies.author_id, replies.author,
+    replies.text, replies.created_at, replies.updated_at
+FROM (
+    SELECT *, row_number() OVER (PARTITION BY root_id ORDER BY id) AS position
+    FROM comments_with_authors
+    WHERE root_id = ANY(sqlc.arg(root_ids)::integer[])
+) AS replies
+WHERE replies.position <= sqlc.arg(limit_)::integer
+ORDER BY replies.id;
+
+-- Replies in the thread of the top-level comment after the cursor, oldest first
+-- name: ListThreadReplies :many
+SELECT * FROM comments_with_authors
+WHERE root_id = sqlc.arg(root_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(limit_);
+
+-- UPDATE QUERIES
+
+-- name: UpdateOwnedCommentText :one
+UPDATE comments
+SET text = $3, updated_at = now()
+WHERE id = $1 AND joke_id = $2 AND author_id = sqlc.arg(author_id)::integer
+RETURNING *;
+
+-- Turns the comment into a tombstone if it has replies, the trigger clears the text
+-- name: TombstoneOwnedComment :execrows
+UPDATE comments
+SET author_id = NULL, updated_at = now()
+WHERE comments.id = $1 AND comments.joke_id = $2 AND comments.author_id = sqlc.arg(author_id)::integer
+    AND EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id);
+
+-- DELETE QUERIES
+
+-- Deletes the comment if it has no replies, returns the comment it answers
+-- name: DeleteOwnedComment :one
+DELETE FROM comments
+WHERE comments.id = $1 AND comments.joke_id = $2 AND comments.author_id = sqlc.arg(author_id)::integer
+    AND NOT EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)
+RETURNING comments.parent_id;
+
+-- Deletes the tombstone if its last reply is gone, returns the comment it answers
+-- name: DeleteEmptyTombstone :one
+DELETE FROM comments
+WHERE comments.id = $1 AND comments.author_id IS NULL
+    AND NOT EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id)
+RETURNING comments.parent_id;
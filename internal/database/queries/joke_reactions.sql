@@ -0,0 +1,58 @@
+-- name: CreateJokeReaction :one
+INSERT INTO joke_reactions (
+    user_id,
+    joke_id,
+    reaction
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: GetJokeReactionForUpdate :one
+SELECT * FROM joke_reactions
+WHERE user_id = $1 AND joke_id = $2
+FOR UPDATE;
+
+-- UPDATE QUERIES
+
+-- name: UpdateJokeReaction :one
+UPDATE joke_reactions
+SET reaction = $3, created_at = now()
+WHERE user_id = $1 AND joke_id = $2
+RETURNING *;
+
+-- The counters are changed in the transactions that change the reactions
+
+-- name: AddJokeReactionCounts :exec
+UPDATE jokes
+SET
+    laugh_count = laugh_count + sqlc.arg(laughs),
+    groan_count = groan_count + sqlc.arg(groans),
+    love_count = love_count + sqlc.arg(loves)
+WHERE id = sqlc.arg(id);
+
+-- name: RemoveUserReactionCounts :exec
+UPDATE jokes
+SET
+    laugh_count = laugh_count - counts.laughs,
+    groan_count = groan_count - counts.groans,
+    love_count = love_count - counts.loves
+FROM (
+    SELECT
+        joke_id,
+        count(*) FILTER (WHERE reaction = 'laugh')::integer AS laughs,
+        count(*) FILTER (WHERE reaction = 'groan')::integer AS groans,
+        count(*) FILTER (WHERE reaction = 'love')::integer AS loves
+    FROM joke_reactions
+    WHERE user_id = $1
+    GROUP BY joke_id
+) AS counts
+WHERE jokes.id = counts.joke_id;
+
+-- DELETE QUERIES
+
+-- name: DeleteJokeReaction :one
+DELETE FROM joke_reactions
+WHERE user_id = $1 AND joke_id = $2
+RETURNING *;
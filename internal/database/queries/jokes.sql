@@ -1,27 +1,40 @@
 -- name: CreateJoke :one
 INSERT INTO jokes (
-    author,
+    author_id,
     title,
     text,
-    explanation
+    explanation,
+    language
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- GET QUERIES
 
 -- name: GetJoke :one
-SELECT * FROM jokes
+SELECT * FROM jokes_with_authors
 WHERE id = $1 LIMIT 1;
 
+-- Jokes with any of any_tags and all of all_tags, empty or NULL arrays match all jokes
 -- name: ListJokes :many
-SELECT * FROM jokes
+SELECT * FROM jokes_with_authors
+WHERE (
+    coalesce(cardinality(sqlc.arg(any_tags)::varchar[]), 0) = 0 OR EXISTS (
+        SELECT 1 FROM joke_tags
+        JOIN tags ON tags.id = joke_tags.tag_id
+        WHERE joke_tags.joke_id = jokes_with_authors.id AND tags.name = ANY(sqlc.arg(any_tags)::varchar[])
+    )
+) AND (
+    SELECT count(*) FROM joke_tags
+    JOIN tags ON tags.id = joke_tags.tag_id
+    WHERE joke_tags.joke_id = jokes_with_authors.id AND tags.name = ANY(sqlc.arg(all_tags)::varchar[])
+) = coalesce(cardinality(sqlc.arg(all_tags)::varchar[]), 0)
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg(limit_)
+OFFSET sqlc.arg(offset_);
 
 -- name: ListJokesByAuthor :many
-SELECT * FROM jokes
+SELECT * FROM jokes_with_authors
 WHERE author = $1
 ORDER BY id
 LIMIT $2
@@ -47,15 +60,43 @@ SET explanation = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateOwnedJokeTitle :one
+UPDATE jokes
+SET title = $3
+WHERE id = $1 AND author_id = $2
+RETURNING *;
+
+-- name: UpdateOwnedJokeText :one
+UPDATE jokes
+SET text = $3
+WHERE id = $1 AND author_id = $2
+RETURNING *;
+
+-- name: UpdateOwnedJokeExplanation :one
+UPDATE jokes
+SET explanation = $3
+WHERE id = $1 AND author_id = $2
+RETURNING *;
+
+-- name: UpdateOwnedJokeLanguage :one
+UPDATE jokes
+SET language = $3
+WHERE id = $1 AND author_id = $2
+RETURNING *;
+
 -- DELETE QUERIES
 
 -- name: DeleteJoke :exec
 DELETE FROM jokes
 WHERE id = $1;
 
+-- name: DeleteOwnedJoke :execrows
+DELETE FROM jokes
+WHERE id = $1 AND author_id = $2;
+
 -- name: DeleteJokesByAuthor :exec
 DELETE FROM jokes
-WHERE author = $1;
+WHERE author_id = $1;
 
 -- name: DeleteAllJokes :exec
 DELETE FROM jokes;
\ No newline at end of file
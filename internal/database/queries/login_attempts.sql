@@ -0,0 +1,40 @@
+-- GET QUERIES
+
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE key = $1 LIMIT 1;
+
+-- UPDATE QUERIES
+
+-- The counter starts over if the last failure is older than the window
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (
+    key,
+    failures,
+    last_failed_at,
+    locked_until
+) VALUES (
+    sqlc.arg(key), 1, sqlc.arg(failed_at), sqlc.arg(failed_at)
+)
+ON CONFLICT (key) DO UPDATE
+SET failures = CASE
+        WHEN login_attempts.last_failed_at < sqlc.arg(window_start) THEN 1
+        ELSE login_attempts.failures + 1
+    END,
+    last_failed_at = EXCLUDED.last_failed_at
+RETURNING *;
+
+-- name: LockLoginAttempt :exec
+UPDATE login_attempts
+SET locked_until = GREATEST(locked_until, sqlc.arg(locked_until))
+WHERE key = sqlc.arg(key);
+
+-- DELETE QUERIES
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE key = $1;
+
+-- name: DeleteStaleLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE last_failed_at < $1 AND locked_until < $1;
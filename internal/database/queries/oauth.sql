@@ -0,0 +1,59 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+    id,
+    owner_id,
+    name,
+    redirect_uris,
+    is_confidential,
+    secret_hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: CreateOAuthCode :one
+INSERT INTO oauth_codes (
+    code_hash,
+    client_id,
+    user_id,
+    redirect_uri,
+    scopes,
+    code_challenge,
+    code_challenge_method,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = $1 LIMIT 1;
+
+-- name: ListOAuthClientsByOwner :many
+SELECT * FROM oauth_clients
+WHERE owner_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetOAuthCode :one
+SELECT * FROM oauth_codes
+WHERE code_hash = $1 LIMIT 1;
+
+-- UPDATE QUERIES
+
+-- name: UseOAuthCode :one
+UPDATE oauth_codes
+SET is_used = true
+WHERE code_hash = $1 AND is_used = false
+RETURNING *;
+
+-- name: SetOAuthCodeSession :exec
+UPDATE oauth_codes
+SET session_id = $2
+WHERE code_hash = $1;
+
+-- DELETE QUERIES
+
+-- name: DeleteOwnedOAuthClient :execrows
+DELETE FROM oauth_clients
+WHERE id = $1 AND owner_id = $2;
@@ -0,0 +1,32 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    user_id,
+    token_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: GetUserByPasswordReset :one
+SELECT users.* FROM users
+JOIN password_resets ON password_resets.user_id = users.id
+WHERE password_resets.token_hash = $1
+    AND password_resets.is_used = false
+    AND password_resets.expired_at > now();
+
+-- UPDATE QUERIES
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE token_hash = $1
+    AND is_used = false
+    AND expired_at > now()
+RETURNING *;
+
+-- name: UseUserPasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE user_id = $1 AND is_used = false;
@@ -0,0 +1,55 @@
+-- Jokes in the language matching the tsquery, the best matches first.
+-- The query is stemmed with the language. The snippets mark the matches with U+E000 and U+E001,
+-- search.HighlightStart and search.HighlightStop, which are taken out of the text beforehand.
+-- name: SearchJokes :many
+SELECT
+    jokes_with_authors.*,
+    ts_rank_cd(jokes.search_vector, query)::real AS rank,
+    ts_headline(
+        jokes.language,
+        translate(jokes.title, E'\uE000\uE001', ''),
+        query,
+        E'HighlightAll=true, StartSel=\uE000, StopSel=\uE001'
+    )::text AS title_snippet,
+    ts_headline(
+        jokes.language,
+        translate(jokes.text || ' ' || jokes.explanation, E'\uE000\uE001', ''),
+        query,
+        E'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=\uE000, StopSel=\uE001'
+    )::text AS text_snippet
+FROM jokes_with_authors
+JOIN jokes ON jokes.id = jokes_with_authors.id,
+    to_tsquery(sqlc.arg(language)::regconfig, sqlc.arg(query)) AS query
+WHERE jokes.language = sqlc.arg(language)::regconfig AND jokes.search_vector @@ query
+ORDER BY rank DESC, jokes.id
+LIMIT sqlc.arg(limit_)
+OFFSET sqlc.arg(offset_);
+
+-- Users whose username or full name is like the query, the most alike first.
+-- The trigram operators keep typos in, and the user with the username itself is always the first.
+-- Only the public columns are returned, banned and deactivated users aren't found.
+-- name: SearchUsers :many
+SELECT
+    id,
+    username,
+    avatar,
+    fullname,
+    bio,
+    status,
+    created_at,
+    (
+        greatest(
+            similarity(username, sqlc.arg(query)),
+            word_similarity(sqlc.arg(query), username),
+            word_similarity(sqlc.arg(query), fullname)
+        ) + CASE WHEN lower(username) = lower(sqlc.arg(query)) THEN 1 ELSE 0 END
+    )::real AS rank
+FROM users
+WHERE NOT is_banned AND NOT is_deactivated AND (
+    username % sqlc.arg(query) OR
+    sqlc.arg(query) <% username OR
+    sqlc.arg(query) <% fullname
+)
+ORDER BY rank DESC, id
+LIMIT sqlc.arg(limit_)
+OFFSET sqlc.arg(offset_);
@@ -0,0 +1,42 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+    id,
+    user_id,
+    refresh_token,
+    user_agent,
+    client_ip,
+    is_blocked,
+    expires_at,
+    scopes,
+    client_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListSessionsByUser :many
+SELECT * FROM sessions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- UPDATE QUERIES
+
+-- name: BlockSession :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1;
+
+-- name: BlockOwnedSession :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1 AND user_id = $2;
+
+-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE user_id = $1;
@@ -0,0 +1,29 @@
+-- Returns the tags with the given names, creating the missing ones
+-- name: UpsertTags :many
+INSERT INTO tags (name)
+SELECT unnest(sqlc.arg(names)::varchar[])
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddJokeTags :exec
+INSERT INTO joke_tags (joke_id, tag_id)
+SELECT sqlc.arg(joke_id), unnest(sqlc.arg(tag_ids)::integer[])
+ON CONFLICT DO NOTHING;
+
+-- GET QUERIES
+
+-- Tags in use with the number of their jokes, the most used first
+-- name: ListTags :many
+SELECT tags.name, count(*)::integer AS joke_count
+FROM tags
+JOIN joke_tags ON joke_tags.tag_id = tags.id
+GROUP BY tags.id
+ORDER BY joke_count DESC, tags.name
+LIMIT $1
+OFFSET $2;
+
+-- DELETE QUERIES
+
+-- name: DeleteJokeTags :exec
+DELETE FROM joke_tags
+WHERE joke_id = $1;
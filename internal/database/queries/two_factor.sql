@@ -0,0 +1,48 @@
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+    user_id,
+    code_hash
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+    id,
+    user_id,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- GET QUERIES
+
+-- name: GetLoginChallenge :one
+SELECT * FROM login_challenges
+WHERE id = $1 LIMIT 1;
+
+-- UPDATE QUERIES
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET is_used = true
+WHERE user_id = $1
+    AND code_hash = $2
+    AND is_used = false;
+
+-- name: IncrementLoginChallengeAttempts :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: UseLoginChallenge :execrows
+UPDATE login_challenges
+SET is_used = true
+WHERE id = $1 AND is_used = false;
+
+-- DELETE QUERIES
+
+-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
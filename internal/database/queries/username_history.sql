@@ -0,0 +1,31 @@
+-- name: CreateUsernameHistory :exec
+INSERT INTO username_history (
+    user_id,
+    old_username,
+    changed_at
+) VALUES (
+    $1, $2, $3
+);
+
+-- GET QUERIES
+
+-- name: GetLastUsernameChange :one
+SELECT changed_at FROM username_history
+WHERE user_id = $1
+ORDER BY changed_at DESC
+LIMIT 1;
+
+-- Finds the current username of the user who had the old one after the given time
+-- name: GetRenamedUsername :one
+SELECT users.username FROM username_history
+JOIN users ON users.id = username_history.user_id
+WHERE username_history.old_username = $1 AND username_history.changed_at > $2
+ORDER BY username_history.changed_at DESC
+LIMIT 1;
+
+-- Old usernames are kept for their users during the grace period, so the redirects don't go to someone else
+-- name: IsUsernameReserved :one
+SELECT EXISTS (
+    SELECT 1 FROM username_history
+    WHERE old_username = $1 AND changed_at > $2 AND user_id <> $3
+);
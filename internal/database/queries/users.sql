@@ -39,12 +39,24 @@ SET hashed_password = $2
 WHERE id = $1
 RETURNING *;
 
+-- Replaces the hash only if the password wasn't changed since the old hash was read
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = sqlc.arg(new_hashed_password)
+WHERE id = sqlc.arg(id) AND hashed_password = sqlc.arg(old_hashed_password);
+
 -- name: UpdateUserAvatar :one
 UPDATE users
 SET avatar = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateUsername :one
+UPDATE users
+SET username = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateUserFullname :one
 UPDATE users
 SET fullname = $2
@@ -63,6 +75,55 @@ SET bio = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- Gives the role to the user with the verified email if no user has it yet
+-- name: BootstrapUserRole :execrows
+UPDATE users
+SET role = sqlc.arg(role)
+WHERE users.email = sqlc.arg(email) AND users.is_email_verified = true
+    AND NOT EXISTS (SELECT 1 FROM users AS holders WHERE holders.role = sqlc.arg(role));
+
+-- name: UpdateUserBanned :one
+UPDATE users
+SET is_banned = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserDeactivated :one
+UPDATE users
+SET is_deactivated = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserTotpSecret :one
+UPDATE users
+SET totp_secret = $2
+WHERE id = $1 AND is_totp_enabled = false
+RETURNING *;
+
+-- name: EnableUserTotp :one
+UPDATE users
+SET is_totp_enabled = true, totp_last_step = $2
+WHERE id = $1 AND is_totp_enabled = false AND totp_secret != ''
+RETURNING *;
+
+-- name: DisableUserTotp :one
+UPDATE users
+SET is_totp_enabled = false, totp_secret = '', totp_last_step = 0
+WHERE id = $1
+RETURNING *;
+
+-- A TOTP code is accepted only once: the step of every used code has to be later than the last one
+-- name: UseUserTotpStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND totp_last_step < $2;
+
 -- name: UpdateUserUpdatedAt :exec
 UPDATE users
 SET updated_at = now()
@@ -0,0 +1,27 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    user_id,
+    email,
+    code_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- UPDATE QUERIES
+
+-- name: VerifyEmail :one
+WITH verified AS (
+    UPDATE verify_emails
+    SET is_used = true
+    WHERE verify_emails.id = $1
+        AND code_hash = $2
+        AND is_used = false
+        AND expired_at > now()
+    RETURNING user_id, email
+)
+UPDATE users
+SET is_email_verified = true
+FROM verified
+WHERE users.id = verified.user_id AND users.email = verified.email
+RETURNING users.*;
@@ -0,0 +1,193 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: search.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const searchJokes = `-- name: SearchJokes :many
+SELECT
+    jokes_with_authors.id, jokes_with_authors.author_id, jokes_with_authors.author, jokes_with_authors.title, jokes_with_authors.text, jokes_with_authors.explanation, jokes_with_authors.created_at, jokes_with_authors.updated_at, jokes_with_authors.laugh_count, jokes_with_authors.groan_count, jokes_with_authors.love_count, jokes_with_authors.comment_count, jokes_with_authors.tags, jokes_with_authors.language,
+    ts_rank_cd(jokes.search_vector, query)::real AS rank,
+    ts_headline(
+        jokes.language,
+        translate(jokes.title, E'\uE000\uE001', ''),
+        query,
+        E'HighlightAll=true, StartSel=\uE000, StopSel=\uE001'
+    )::text AS title_snippet,
+    ts_headline(
+        jokes.language,
+        translate(jokes.text || ' ' || jokes.explanation, E'\uE000\uE001', ''),
+        query,
+        E'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=\uE000, StopSel=\uE001'
+    )::text AS text_snippet
+FROM jokes_with_authors
+JOIN jokes ON jokes.id = jokes_with_authors.id,
+    to_tsquery($1::regconfig, $2) AS query
+WHERE jokes.language = $1::regconfig AND jokes.search_vector @@ query
+ORDER BY rank DESC, jokes.id
+LIMIT $4
+OFFSET $3
+`
+
+type SearchJokesParams struct {
+	Language string `json:"language"`
+	Query    string `json:"query"`
+	Offset   int32  `json:"offset_"`
+	Limit    int32  `json:"limit_"`
+}
+
+type SearchJokesRow struct {
+	ID           int32     `json:"id"`
+	AuthorID     int32     `json:"author_id"`
+	Author       string    `json:"author"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	Explanation  string    `json:"explanation"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	LaughCount   int32     `json:"laugh_count"`
+	GroanCount   int32     `json:"groan_count"`
+	LoveCount    int32     `json:"love_count"`
+	CommentCount int32     `json:"comment_count"`
+	Tags         []string  `json:"tags"`
+	Language     string    `json:"language"`
+	Rank         float32   `json:"rank"`
+	TitleSnippet string    `json:"title_snippet"`
+	TextSnippet  string    `json:"text_snippet"`
+}
+
+// Jokes in the language matching the tsquery, the best matches first.
+// The query is stemmed with the language. The snippets mark the matches with U+E000 and U+E001,
+// search.HighlightStart and search.HighlightStop, which are taken out of the text beforehand.
+func (q *Queries) SearchJokes(ctx context.Context, arg SearchJokesParams) ([]SearchJokesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchJokes,
+		arg.Language,
+		arg.Query,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchJokesRow
+	for rows.Next() {
+		var i SearchJokesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuthorID,
+			&i.Author,
+			&i.Title,
+			&i.Text,
+			&i.Explanation,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LaughCount,
+			&i.GroanCount,
+			&i.LoveCount,
+			&i.CommentCount,
+			pq.Array(&i.Tags),
+			&i.Language,
+			&i.Rank,
+			&i.TitleSnippet,
+			&i.TextSnippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT
+    id,
+    username,
+    avatar,
+    fullname,
+    bio,
+    status,
+    created_at,
+    (
+        greatest(
+            similarity(username, $1),
+            word_similarity($1, username),
+            word_similarity($1, fullname)
+        ) + CASE WHEN lower(username) = lower($1) THEN 1 ELSE 0 END
+    )::real AS rank
+FROM users
+WHERE NOT is_banned AND NOT is_deactivated AND (
+    username % $1 OR
+    $1 <% username OR
+    $1 <% fullname
+)
+ORDER BY rank DESC, id
+LIMIT $3
+OFFSET $2
+`
+
+type SearchUsersParams struct {
+	Query  string `json:"query"`
+	Offset int32  `json:"offset_"`
+	Limit  int32  `json:"limit_"`
+}
+
+type SearchUsersRow struct {
+	ID        int32     `json:"id"`
+	Username  string    `json:"username"`
+	Avatar    string    `json:"avatar"`
+	Fullname  string    `json:"fullname"`
+	Bio       string    `json:"bio"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	Rank      float32   `json:"rank"`
+}
+
+// Users whose username or full name is like the query, the most alike first.
+// The trigram operators keep typos in, and the user with the username itself is always the first.
+// Only the public columns are returned, banned and deactivated users aren't found.
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsers, arg.Query, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Avatar,
+			&i.Fullname,
+			&i.Bio,
+			&i.Status,
+			&i.CreatedAt,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
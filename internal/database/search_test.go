@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/search"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomJokeWithText(t *testing.T, authorID int32, title, text, language string) Joke {
+	joke, err := testQueries.CreateJoke(context.Background(), CreateJokeParams{
+		AuthorID: authorID,
+		Title:    title,
+		Text:     text,
+		Language: language,
+	})
+	require.NoError(t, err)
+	return joke
+}
+
+func searchJokeIDs(t *testing.T, language, q string) []int32 {
+	jokes, err := testQueries.SearchJokes(context.Background(), SearchJokesParams{
+		Language: language,
+		Query:    search.Parse(q).TSQuery(),
+		Limit:    100,
+	})
+	require.NoError(t, err)
+
+	ids := make([]int32, 0, len(jokes))
+	for _, joke := range jokes {
+		ids = append(ids, joke.ID)
+	}
+	return ids
+}
+
+func TestSearchJokesStemming(t *testing.T) {
+	user := CreateRandomUser(t)
+	// Random words keep the other jokes of the database out of the results
+	word := random.RandomString(12)
+	joke := CreateRandomJokeWithText(t, user.ID, word, "The penguins were running "+word, search.English)
+
+	require.Contains(t, searchJokeIDs(t, search.English, word+" penguin runs"), joke.ID)
+	require.Contains(t, searchJokeIDs(t, search.English, `"penguins were running"`), joke.ID)
+	require.NotContains(t, searchJokeIDs(t, search.English, word+" penguin flying"), joke.ID)
+	// Jokes are searched only in their language
+	require.NotContains(t, searchJokeIDs(t, search.Simple, word), joke.ID)
+}
+
+func TestSearchJokesRank(t *testing.T) {
+	user := CreateRandomUser(t)
+	word := random.RandomString(12)
+	inText := CreateRandomJokeWithText(t, user.ID, "title", "text "+word, search.English)
+	inTitle := CreateRandomJokeWithText(t, user.ID, word, "text", search.English)
+
+	jokes, err := testQueries.SearchJokes(context.Background(), SearchJokesParams{
+		Language: search.English,
+		Query:    search.Parse(word[:6] + "*").TSQuery(),
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, jokes, 2)
+	require.Equal(t, inTitle.ID, jokes[0].ID)
+	require.Equal(t, inText.ID, jokes[1].ID)
+	require.Contains(t, jokes[0].TitleSnippet, search.HighlightStart+word+search.HighlightStop)
+	require.Contains(t, jokes[1].TextSnippet, search.HighlightStart+word+search.HighlightStop)
+}
+
+func TestUpdateOwnedJokeLanguage(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+
+	joke2, err := testQueries.UpdateOwnedJokeLanguage(context.Background(), UpdateOwnedJokeLanguageParams{
+		ID:       joke.ID,
+		AuthorID: user.ID,
+		Language: search.French,
+	})
+	require.NoError(t, err)
+	require.Equal(t, search.French, joke2.Language)
+}
+
+func searchUserIDs(t *testing.T, q string) []int32 {
+	users, err := testQueries.SearchUsers(context.Background(), SearchUsersParams{
+		Query: q,
+		Limit: 100,
+	})
+	require.NoError(t, err)
+
+	ids := make([]int32, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func TestSearchUsers(t *testing.T) {
+	user := CreateRandomUser(t)
+	// A typo in the middle of the username
+	typo := user.Username[:4] + "x" + user.Username[5:]
+
+	ids := searchUserIDs(t, user.Username)
+	require.NotEmpty(t, ids)
+	// The exact username comes first
+	require.Equal(t, user.ID, ids[0])
+	require.Contains(t, searchUserIDs(t, typo), user.ID)
+	require.Contains(t, searchUserIDs(t, user.Fullname), user.ID)
+
+	_, err := testQueries.UpdateUserDeactivated(context.Background(), UpdateUserDeactivatedParams{
+		ID:            user.ID,
+		IsDeactivated: true,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, searchUserIDs(t, user.Username), user.ID)
+
+	_, err = testQueries.UpdateUserDeactivated(context.Background(), UpdateUserDeactivatedParams{
+		ID:            user.ID,
+		IsDeactivated: false,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpdateUserBanned(context.Background(), UpdateUserBannedParams{
+		ID:       user.ID,
+		IsBanned: true,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, searchUserIDs(t, user.Username), user.ID)
+}
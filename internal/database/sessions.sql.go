@@ -0,0 +1,179 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: sessions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const blockOwnedSession = `-- name: BlockOwnedSession :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1 AND user_id = $2
+`
+
+type BlockOwnedSessionParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID int32     `json:"user_id"`
+}
+
+func (q *Queries) BlockOwnedSession(ctx context.Context, arg BlockOwnedSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, blockOwnedSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const blockSession = `-- name: BlockSession :exec
+
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+`
+
+// UPDATE QUERIES
+func (q *Queries) BlockSession(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, blockSession, id)
+	return err
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE user_id = $1
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, blockUserSessions, userID)
+	return err
+}
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (
+    id,
+    user_id,
+    refresh_token,
+    user_agent,
+    client_ip,
+    is_blocked,
+    expires_at,
+    scopes,
+    client_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, scopes, client_id
+`
+
+type CreateSessionParams struct {
+	ID           uuid.UUID      `json:"id"`
+	UserID       int32          `json:"user_id"`
+	RefreshToken string         `json:"refresh_token"`
+	UserAgent    string         `json:"user_agent"`
+	ClientIp     string         `json:"client_ip"`
+	IsBlocked    bool           `json:"is_blocked"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	Scopes       []string       `json:"scopes"`
+	ClientID     sql.NullString `json:"client_id"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, createSession,
+		arg.ID,
+		arg.UserID,
+		arg.RefreshToken,
+		arg.UserAgent,
+		arg.ClientIp,
+		arg.IsBlocked,
+		arg.ExpiresAt,
+		pq.Array(arg.Scopes),
+		arg.ClientID,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		pq.Array(&i.Scopes),
+		&i.ClientID,
+	)
+	return i, err
+}
+
+const getSession = `-- name: GetSession :one
+
+SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, scopes, client_id FROM sessions
+WHERE id = $1 LIMIT 1
+`
+
+// GET QUERIES
+func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		pq.Array(&i.Scopes),
+		&i.ClientID,
+	)
+	return i, err
+}
+
+const listSessionsByUser = `-- name: ListSessionsByUser :many
+SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, scopes, client_id FROM sessions
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListSessionsByUser(ctx context.Context, userID int32) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listSessionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			pq.Array(&i.Scopes),
+			&i.ClientID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
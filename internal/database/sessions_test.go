@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomSession(t *testing.T, userID int32) Session {
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		UserID:       userID,
+		RefreshToken: random.RandomString(32),
+		UserAgent:    "Mozilla/5.0",
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.UserID, session.UserID)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	user := CreateRandomUser(t)
+	CreateRandomSession(t, user.ID)
+}
+
+func TestCreateSessionScopes(t *testing.T) {
+	user := CreateRandomUser(t)
+
+	// No scopes are stored as NULL, which stands for the full scope set of the role
+	session1 := CreateRandomSession(t, user.ID)
+	require.Nil(t, session1.Scopes)
+
+	session2, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		RefreshToken: random.RandomString(32),
+		ExpiresAt:    time.Now().Add(time.Hour),
+		Scopes:       []string{"jokes:write", "profile:read"},
+	})
+	require.NoError(t, err)
+
+	session3, err := testQueries.GetSession(context.Background(), session2.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"jokes:write", "profile:read"}, session3.Scopes)
+}
+
+func TestListSessionsByUser(t *testing.T) {
+	user := CreateRandomUser(t)
+	for i := 0; i < 3; i++ {
+		CreateRandomSession(t, user.ID)
+	}
+
+	sessions, err := testQueries.ListSessionsByUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	for _, session := range sessions {
+		require.Equal(t, user.ID, session.UserID)
+	}
+}
+
+func TestBlockOwnedSession(t *testing.T) {
+	user := CreateRandomUser(t)
+	stranger := CreateRandomUser(t)
+	session1 := CreateRandomSession(t, user.ID)
+
+	rows, err := testQueries.BlockOwnedSession(context.Background(), BlockOwnedSessionParams{
+		ID:     session1.ID,
+		UserID: stranger.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testQueries.BlockOwnedSession(context.Background(), BlockOwnedSessionParams{
+		ID:     session1.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	session2, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsBlocked)
+}
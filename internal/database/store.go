@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Attempts of a transaction before the serialization failure is returned
+const maxTxAttempts = 5
+
+// Store runs the queries alone or in transactions
+type Store interface {
+	Querier
+	// ExecTx runs fn in a transaction, which is committed if fn returns nil and rolled back otherwise
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// SQLStore is the Store that owns the connection pool of the database
+type SQLStore struct {
+	*Queries
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) Store {
+	return &SQLStore{
+		Queries: New(db),
+		db:      db,
+	}
+}
+
+// ExecTx runs fn in a serializable transaction, which is committed if fn returns nil and rolled back otherwise.
+// Transactions that fail to serialize with concurrent ones are run again, so fn must have no effects
+// outside the database and must not keep anything from a failed attempt.
+func (s *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = s.execTx(ctx, fn)
+		if !isSerializationFailure(err) {
+			return err
+		}
+
+		// Backs off a bit, so the conflicting transactions don't collide again
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt*attempt) * 10 * time.Millisecond):
+		}
+	}
+	return err
+}
+
+func (s *SQLStore) execTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Checks if Postgres has aborted the transaction because of a concurrent one.
+// Deadlocks are retried as well.
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
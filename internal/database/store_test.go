@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecTxCommit(t *testing.T) {
+	user1 := CreateRandomUser(t)
+
+	err := testStore.ExecTx(context.Background(), func(q Querier) error {
+		_, err := q.UpdateUserBanned(context.Background(), UpdateUserBannedParams{
+			ID:       user1.ID,
+			IsBanned: true,
+		})
+		return err
+	})
+	require.NoError(t, err)
+
+	user2, err := testQueries.GetUserByID(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.True(t, user2.IsBanned)
+}
+
+func TestExecTxRollback(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	txErr := errors.New("tx error")
+
+	err := testStore.ExecTx(context.Background(), func(q Querier) error {
+		_, err := q.UpdateUserBanned(context.Background(), UpdateUserBannedParams{
+			ID:       user1.ID,
+			IsBanned: true,
+		})
+		require.NoError(t, err)
+		return txErr
+	})
+	require.ErrorIs(t, err, txErr)
+
+	user2, err := testQueries.GetUserByID(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.False(t, user2.IsBanned)
+}
+
+func TestExecTxRetry(t *testing.T) {
+	attempts := 0
+	err := testStore.ExecTx(context.Background(), func(q Querier) error {
+		attempts++
+		if attempts < maxTxAttempts {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, maxTxAttempts, attempts)
+}
@@ -0,0 +1,117 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: tags.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const addJokeTags = `-- name: AddJokeTags :exec
+INSERT INTO joke_tags (joke_id, tag_id)
+SELECT $1, unnest($2::integer[])
+ON CONFLICT DO NOTHING
+`
+
+type AddJokeTagsParams struct {
+	JokeID int32   `json:"joke_id"`
+	TagIds []int32 `json:"tag_ids"`
+}
+
+func (q *Queries) AddJokeTags(ctx context.Context, arg AddJokeTagsParams) error {
+	_, err := q.db.ExecContext(ctx, addJokeTags, arg.JokeID, pq.Array(arg.TagIds))
+	return err
+}
+
+const deleteJokeTags = `-- name: DeleteJokeTags :exec
+
+DELETE FROM joke_tags
+WHERE joke_id = $1
+`
+
+// DELETE QUERIES
+func (q *Queries) DeleteJokeTags(ctx context.Context, jokeID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteJokeTags, jokeID)
+	return err
+}
+
+const listTags = `-- name: ListTags :many
+
+SELECT tags.name, count(*)::integer AS joke_count
+FROM tags
+JOIN joke_tags ON joke_tags.tag_id = tags.id
+GROUP BY tags.id
+ORDER BY joke_count DESC, tags.name
+LIMIT $1
+OFFSET $2
+`
+
+type ListTagsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListTagsRow struct {
+	Name      string `json:"name"`
+	JokeCount int32  `json:"joke_count"`
+}
+
+// GET QUERIES
+// Tags in use with the number of their jokes, the most used first
+func (q *Queries) ListTags(ctx context.Context, arg ListTagsParams) ([]ListTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTags, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTagsRow
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(&i.Name, &i.JokeCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertTags = `-- name: UpsertTags :many
+INSERT INTO tags (name)
+SELECT unnest($1::varchar[])
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name, created_at
+`
+
+// Returns the tags with the given names, creating the missing ones
+func (q *Queries) UpsertTags(ctx context.Context, names []string) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, upsertTags, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
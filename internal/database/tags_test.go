@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TagRandomJoke(t *testing.T, jokeID int32, names ...string) []Tag {
+	tags, err := testQueries.UpsertTags(context.Background(), names)
+	require.NoError(t, err)
+	require.Len(t, tags, len(names))
+
+	tagIDs := make([]int32, 0, len(tags))
+	for _, tag := range tags {
+		require.Contains(t, names, tag.Name)
+		require.NotZero(t, tag.ID)
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	err = testQueries.AddJokeTags(context.Background(), AddJokeTagsParams{
+		JokeID: jokeID,
+		TagIds: tagIDs,
+	})
+	require.NoError(t, err)
+
+	return tags
+}
+
+func TestUpsertTags(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	name := random.RandomString(10)
+	tags1 := TagRandomJoke(t, joke.ID, name)
+
+	// Existing tags are returned instead of being created again
+	tags2, err := testQueries.UpsertTags(context.Background(), []string{name})
+	require.NoError(t, err)
+	require.Equal(t, tags1[0].ID, tags2[0].ID)
+
+	joke2, err := testQueries.GetJoke(context.Background(), joke.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{name}, joke2.Tags)
+}
+
+func TestListJokesByTags(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke1 := CreateRandomJoke(t, user.ID)
+	joke2 := CreateRandomJoke(t, user.ID)
+	name1, name2 := random.RandomString(10), random.RandomString(10)
+	TagRandomJoke(t, joke1.ID, name1, name2)
+	TagRandomJoke(t, joke2.ID, name1)
+
+	jokes, err := testQueries.ListJokes(context.Background(), ListJokesParams{
+		AnyTags: []string{name1, name2},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, jokes, 2)
+
+	jokes, err = testQueries.ListJokes(context.Background(), ListJokesParams{
+		AllTags: []string{name1, name2},
+		Limit:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, jokes, 1)
+	require.Equal(t, joke1.ID, jokes[0].ID)
+}
+
+func TestDeleteJokeTags(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke := CreateRandomJoke(t, user.ID)
+	TagRandomJoke(t, joke.ID, random.RandomString(10))
+
+	err := testQueries.DeleteJokeTags(context.Background(), joke.ID)
+	require.NoError(t, err)
+
+	joke2, err := testQueries.GetJoke(context.Background(), joke.ID)
+	require.NoError(t, err)
+	require.Empty(t, joke2.Tags)
+}
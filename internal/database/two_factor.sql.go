@@ -0,0 +1,161 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: two_factor.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createLoginChallenge = `-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+    id,
+    user_id,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, user_id, attempts, is_used, expires_at, created_at
+`
+
+type CreateLoginChallengeParams struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    int32     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error) {
+	row := q.db.QueryRowContext(ctx, createLoginChallenge, arg.ID, arg.UserID, arg.ExpiresAt)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Attempts,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+    user_id,
+    code_hash
+) VALUES (
+    $1, $2
+) RETURNING id, user_id, code_hash, is_used, created_at
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   int32  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserRecoveryCodes = `-- name: DeleteUserRecoveryCodes :exec
+
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+// DELETE QUERIES
+func (q *Queries) DeleteUserRecoveryCodes(ctx context.Context, userID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRecoveryCodes, userID)
+	return err
+}
+
+const getLoginChallenge = `-- name: GetLoginChallenge :one
+
+SELECT id, user_id, attempts, is_used, expires_at, created_at FROM login_challenges
+WHERE id = $1 LIMIT 1
+`
+
+// GET QUERIES
+func (q *Queries) GetLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	row := q.db.QueryRowContext(ctx, getLoginChallenge, id)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Attempts,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementLoginChallengeAttempts = `-- name: IncrementLoginChallengeAttempts :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING id, user_id, attempts, is_used, expires_at, created_at
+`
+
+func (q *Queries) IncrementLoginChallengeAttempts(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	row := q.db.QueryRowContext(ctx, incrementLoginChallengeAttempts, id)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Attempts,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useLoginChallenge = `-- name: UseLoginChallenge :execrows
+UPDATE login_challenges
+SET is_used = true
+WHERE id = $1 AND is_used = false
+`
+
+func (q *Queries) UseLoginChallenge(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useLoginChallenge, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+
+UPDATE recovery_codes
+SET is_used = true
+WHERE user_id = $1
+    AND code_hash = $2
+    AND is_used = false
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int32  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+// UPDATE QUERIES
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableUserTotp(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	require.False(t, user1.IsTotpEnabled)
+
+	// It can't be enabled before the secret is set up
+	_, err := testQueries.EnableUserTotp(context.Background(), EnableUserTotpParams{ID: user1.ID, TotpLastStep: 1})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	user2, err := testQueries.UpdateUserTotpSecret(context.Background(), UpdateUserTotpSecretParams{
+		ID:         user1.ID,
+		TotpSecret: "JBSWY3DPEHPK3PXP",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", user2.TotpSecret)
+	require.False(t, user2.IsTotpEnabled)
+
+	user3, err := testQueries.EnableUserTotp(context.Background(), EnableUserTotpParams{ID: user1.ID, TotpLastStep: 1})
+	require.NoError(t, err)
+	require.True(t, user3.IsTotpEnabled)
+	require.Equal(t, int64(1), user3.TotpLastStep)
+
+	// The secret of the enabled user can't be replaced
+	_, err = testQueries.UpdateUserTotpSecret(context.Background(), UpdateUserTotpSecretParams{
+		ID:         user1.ID,
+		TotpSecret: "KRSXG5CTMVRXEZLU",
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	user4, err := testQueries.DisableUserTotp(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.False(t, user4.IsTotpEnabled)
+	require.Empty(t, user4.TotpSecret)
+}
+
+func TestUseUserTotpStep(t *testing.T) {
+	user := CreateRandomUser(t)
+
+	rows, err := testQueries.UseUserTotpStep(context.Background(), UseUserTotpStepParams{ID: user.ID, TotpLastStep: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// The same or an earlier step is a replayed code
+	for _, step := range []int64{10, 9} {
+		rows, err = testQueries.UseUserTotpStep(context.Background(), UseUserTotpStepParams{ID: user.ID, TotpLastStep: step})
+		require.NoError(t, err)
+		require.Zero(t, rows)
+	}
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := CreateRandomUser(t)
+	code, err := testQueries.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: random.RandomString(64),
+	})
+	require.NoError(t, err)
+	require.False(t, code.IsUsed)
+
+	arg := UseRecoveryCodeParams{UserID: user.ID, CodeHash: code.CodeHash}
+	rows, err := testQueries.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// The code is single-use
+	rows, err = testQueries.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	// Codes of other users don't match
+	rows, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{UserID: CreateRandomUser(t).ID, CodeHash: code.CodeHash})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestLoginChallenge(t *testing.T) {
+	user := CreateRandomUser(t)
+	challenge1, err := testQueries.CreateLoginChallenge(context.Background(), CreateLoginChallengeParams{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, challenge1.Attempts)
+
+	challenge2, err := testQueries.IncrementLoginChallengeAttempts(context.Background(), challenge1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), challenge2.Attempts)
+
+	rows, err := testQueries.UseLoginChallenge(context.Background(), challenge1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testQueries.UseLoginChallenge(context.Background(), challenge1.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	challenge3, err := testQueries.GetLoginChallenge(context.Background(), challenge1.ID)
+	require.NoError(t, err)
+	require.True(t, challenge3.IsUsed)
+}
@@ -0,0 +1,90 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: username_history.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const createUsernameHistory = `-- name: CreateUsernameHistory :exec
+INSERT INTO username_history (
+    user_id,
+    old_username,
+    changed_at
+) VALUES (
+    $1, $2, $3
+)
+`
+
+type CreateUsernameHistoryParams struct {
+	UserID      int32     `json:"user_id"`
+	OldUsername string    `json:"old_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+func (q *Queries) CreateUsernameHistory(ctx context.Context, arg CreateUsernameHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, createUsernameHistory, arg.UserID, arg.OldUsername, arg.ChangedAt)
+	return err
+}
+
+const getLastUsernameChange = `-- name: GetLastUsernameChange :one
+
+SELECT changed_at FROM username_history
+WHERE user_id = $1
+ORDER BY changed_at DESC
+LIMIT 1
+`
+
+// GET QUERIES
+func (q *Queries) GetLastUsernameChange(ctx context.Context, userID int32) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getLastUsernameChange, userID)
+	var changed_at time.Time
+	err := row.Scan(&changed_at)
+	return changed_at, err
+}
+
+const getRenamedUsername = `-- name: GetRenamedUsername :one
+SELECT users.username FROM username_history
+JOIN users ON users.id = username_history.user_id
+WHERE username_history.old_username = $1 AND username_history.changed_at > $2
+ORDER BY username_history.changed_at DESC
+LIMIT 1
+`
+
+type GetRenamedUsernameParams struct {
+	OldUsername string    `json:"old_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+// Finds the current username of the user who had the old one after the given time
+func (q *Queries) GetRenamedUsername(ctx context.Context, arg GetRenamedUsernameParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getRenamedUsername, arg.OldUsername, arg.ChangedAt)
+	var username string
+	err := row.Scan(&username)
+	return username, err
+}
+
+const isUsernameReserved = `-- name: IsUsernameReserved :one
+SELECT EXISTS (
+    SELECT 1 FROM username_history
+    WHERE old_username = $1 AND changed_at > $2 AND user_id <> $3
+)
+`
+
+type IsUsernameReservedParams struct {
+	OldUsername string    `json:"old_username"`
+	ChangedAt   time.Time `json:"changed_at"`
+	UserID      int32     `json:"user_id"`
+}
+
+// Old usernames are kept for their users during the grace period, so the redirects don't go to someone else
+func (q *Queries) IsUsernameReserved(ctx context.Context, arg IsUsernameReservedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isUsernameReserved, arg.OldUsername, arg.ChangedAt, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
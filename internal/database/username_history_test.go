@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+// Renames the user, recording the old username in the history
+func RenameRandomUser(t *testing.T, user User, changedAt time.Time) User {
+	renamed, err := testQueries.UpdateUsername(context.Background(), UpdateUsernameParams{
+		ID:       user.ID,
+		Username: random.RandomUsername(),
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, user.Username, renamed.Username)
+
+	err = testQueries.CreateUsernameHistory(context.Background(), CreateUsernameHistoryParams{
+		UserID:      user.ID,
+		OldUsername: user.Username,
+		ChangedAt:   changedAt,
+	})
+	require.NoError(t, err)
+
+	return renamed
+}
+
+func TestGetLastUsernameChange(t *testing.T) {
+	user := CreateRandomUser(t)
+
+	_, err := testQueries.GetLastUsernameChange(context.Background(), user.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	changedAt := time.Now().Add(-time.Hour)
+	user = RenameRandomUser(t, user, changedAt.Add(-time.Hour))
+	RenameRandomUser(t, user, changedAt)
+
+	lastChange, err := testQueries.GetLastUsernameChange(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, changedAt, lastChange, time.Second)
+}
+
+func TestGetRenamedUsername(t *testing.T) {
+	user := CreateRandomUser(t)
+	renamed := RenameRandomUser(t, user, time.Now())
+
+	newUsername, err := testQueries.GetRenamedUsername(context.Background(), GetRenamedUsernameParams{
+		OldUsername: user.Username,
+		ChangedAt:   time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, renamed.Username, newUsername)
+
+	// Out of the grace period
+	_, err = testQueries.GetRenamedUsername(context.Background(), GetRenamedUsernameParams{
+		OldUsername: user.Username,
+		ChangedAt:   time.Now().Add(time.Hour),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestIsUsernameReserved(t *testing.T) {
+	user := CreateRandomUser(t)
+	stranger := CreateRandomUser(t)
+	RenameRandomUser(t, user, time.Now())
+
+	reserved, err := testQueries.IsUsernameReserved(context.Background(), IsUsernameReservedParams{
+		OldUsername: user.Username,
+		ChangedAt:   time.Now().Add(-time.Hour),
+		UserID:      stranger.ID,
+	})
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// The user can take the old username back
+	reserved, err = testQueries.IsUsernameReserved(context.Background(), IsUsernameReservedParams{
+		OldUsername: user.Username,
+		ChangedAt:   time.Now().Add(-time.Hour),
+		UserID:      user.ID,
+	})
+	require.NoError(t, err)
+	require.False(t, reserved)
+}
+
+func TestRenamedUserKeepsJokes(t *testing.T) {
+	user := CreateRandomUser(t)
+	joke1 := CreateRandomJoke(t, user.ID)
+	renamed := RenameRandomUser(t, user, time.Now())
+
+	joke2, err := testQueries.GetJoke(context.Background(), joke1.ID)
+	require.NoError(t, err)
+	require.Equal(t, renamed.Username, joke2.Author)
+}
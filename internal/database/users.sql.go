@@ -9,6 +9,27 @@ import (
 	"context"
 )
 
+const bootstrapUserRole = `-- name: BootstrapUserRole :execrows
+UPDATE users
+SET role = $1
+WHERE users.email = $2 AND users.is_email_verified = true
+    AND NOT EXISTS (SELECT 1 FROM users AS holders WHERE holders.role = $1)
+`
+
+type BootstrapUserRoleParams struct {
+	Role  string `json:"role"`
+	Email string `json:"email"`
+}
+
+// Gives the role to the user with the verified email if no user has it yet
+func (q *Queries) BootstrapUserRole(ctx context.Context, arg BootstrapUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, bootstrapUserRole, arg.Role, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users(
     username,
@@ -20,7 +41,7 @@ INSERT INTO users(
     bio
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at
+) RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
 `
 
 type CreateUserParams struct {
@@ -55,6 +76,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
@@ -80,8 +108,77 @@ func (q *Queries) DeleteUser(ctx context.Context, id int32) error {
 	return err
 }
 
+const disableUserTotp = `-- name: DisableUserTotp :one
+UPDATE users
+SET is_totp_enabled = false, totp_secret = '', totp_last_step = 0
+WHERE id = $1
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+func (q *Queries) DisableUserTotp(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUserTotp, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const enableUserTotp = `-- name: EnableUserTotp :one
+UPDATE users
+SET is_totp_enabled = true, totp_last_step = $2
+WHERE id = $1 AND is_totp_enabled = false AND totp_secret != ''
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+type EnableUserTotpParams struct {
+	ID           int32 `json:"id"`
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+func (q *Queries) EnableUserTotp(ctx context.Context, arg EnableUserTotpParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTotp, arg.ID, arg.TotpLastStep)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at FROM users
+SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated FROM users
 WHERE email = $1
 `
 
@@ -99,13 +196,20 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
 
-SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at FROM users
+SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated FROM users
 WHERE id = $1
 `
 
@@ -124,12 +228,19 @@ func (q *Queries) GetUserByID(ctx context.Context, id int32) (User, error) {
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
 
 const getUserByName = `-- name: GetUserByName :one
-SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at FROM users
+SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated FROM users
 WHERE username = $1
 `
 
@@ -147,12 +258,19 @@ func (q *Queries) GetUserByName(ctx context.Context, username string) (User, err
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at FROM users
+SELECT id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated FROM users
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -183,6 +301,13 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Role,
+			&i.IsBanned,
+			&i.IsEmailVerified,
+			&i.TotpSecret,
+			&i.IsTotpEnabled,
+			&i.TotpLastStep,
+			&i.IsDeactivated,
 		); err != nil {
 			return nil, err
 		}
@@ -197,11 +322,32 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = $1
+WHERE id = $2 AND hashed_password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewHashedPassword string `json:"new_hashed_password"`
+	ID                int32  `json:"id"`
+	OldHashedPassword string `json:"old_hashed_password"`
+}
+
+// Replaces the hash only if the password wasn't changed since the old hash was read
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rehashUserPassword, arg.NewHashedPassword, arg.ID, arg.OldHashedPassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUserAvatar = `-- name: UpdateUserAvatar :one
 UPDATE users
 SET avatar = $2
 WHERE id = $1
-RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
 `
 
 type UpdateUserAvatarParams struct {
@@ -223,6 +369,50 @@ func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarPara
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const updateUserBanned = `-- name: UpdateUserBanned :one
+UPDATE users
+SET is_banned = $2
+WHERE id = $1
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+type UpdateUserBannedParams struct {
+	ID       int32 `json:"id"`
+	IsBanned bool  `json:"is_banned"`
+}
+
+func (q *Queries) UpdateUserBanned(ctx context.Context, arg UpdateUserBannedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserBanned, arg.ID, arg.IsBanned)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
@@ -231,7 +421,7 @@ const updateUserBio = `-- name: UpdateUserBio :one
 UPDATE users
 SET bio = $2
 WHERE id = $1
-RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
 `
 
 type UpdateUserBioParams struct {
@@ -253,6 +443,50 @@ func (q *Queries) UpdateUserBio(ctx context.Context, arg UpdateUserBioParams) (U
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const updateUserDeactivated = `-- name: UpdateUserDeactivated :one
+UPDATE users
+SET is_deactivated = $2
+WHERE id = $1
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+type UpdateUserDeactivatedParams struct {
+	ID            int32 `json:"id"`
+	IsDeactivated bool  `json:"is_deactivated"`
+}
+
+func (q *Queries) UpdateUserDeactivated(ctx context.Context, arg UpdateUserDeactivatedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserDeactivated, arg.ID, arg.IsDeactivated)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
@@ -261,7 +495,7 @@ const updateUserFullname = `-- name: UpdateUserFullname :one
 UPDATE users
 SET fullname = $2
 WHERE id = $1
-RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
 `
 
 type UpdateUserFullnameParams struct {
@@ -283,6 +517,13 @@ func (q *Queries) UpdateUserFullname(ctx context.Context, arg UpdateUserFullname
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
@@ -292,7 +533,7 @@ const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET hashed_password = $2
 WHERE id = $1
-RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
 `
 
 type UpdateUserPasswordParams struct {
@@ -315,6 +556,50 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE id = $1
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32  `json:"id"`
+	Role string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
@@ -323,7 +608,7 @@ const updateUserStatus = `-- name: UpdateUserStatus :one
 UPDATE users
 SET status = $2
 WHERE id = $1
-RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
 `
 
 type UpdateUserStatusParams struct {
@@ -345,6 +630,50 @@ func (q *Queries) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusPara
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const updateUserTotpSecret = `-- name: UpdateUserTotpSecret :one
+UPDATE users
+SET totp_secret = $2
+WHERE id = $1 AND is_totp_enabled = false
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+type UpdateUserTotpSecretParams struct {
+	ID         int32  `json:"id"`
+	TotpSecret string `json:"totp_secret"`
+}
+
+func (q *Queries) UpdateUserTotpSecret(ctx context.Context, arg UpdateUserTotpSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTotpSecret, arg.ID, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
 	)
 	return i, err
 }
@@ -359,3 +688,60 @@ func (q *Queries) UpdateUserUpdatedAt(ctx context.Context, id int32) error {
 	_, err := q.db.ExecContext(ctx, updateUserUpdatedAt, id)
 	return err
 }
+
+const updateUsername = `-- name: UpdateUsername :one
+UPDATE users
+SET username = $2
+WHERE id = $1
+RETURNING id, username, email, hashed_password, avatar, fullname, bio, status, created_at, updated_at, role, is_banned, is_email_verified, totp_secret, is_totp_enabled, totp_last_step, is_deactivated
+`
+
+type UpdateUsernameParams struct {
+	ID       int32  `json:"id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateUsername(ctx context.Context, arg UpdateUsernameParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUsername, arg.ID, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
+
+const useUserTotpStep = `-- name: UseUserTotpStep :execrows
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1 AND totp_last_step < $2
+`
+
+type UseUserTotpStepParams struct {
+	ID           int32 `json:"id"`
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+// A TOTP code is accepted only once: the step of every used code has to be later than the last one
+func (q *Queries) UseUserTotpStep(ctx context.Context, arg UseUserTotpStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useUserTotpStep, arg.ID, arg.TotpLastStep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
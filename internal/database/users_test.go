@@ -6,21 +6,23 @@ import (
 	"testing"
 	"time"
 
-	"github.com/abc_valera/flugo/internal/utils"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
 	"github.com/stretchr/testify/require"
 )
 
 func CreateRandomUser(t *testing.T) User {
-	hashedPassword, err := utils.HashPassword(utils.RandomPassword())
+	hashedPassword, err := password.HashPassword(random.RandomPassword())
 	require.NoError(t, err)
 
 	createArgs := CreateUserParams{
-		Username:       utils.RandomUsername(),
-		Email:          utils.RandomEmail(),
+		Username:       random.RandomUsername(),
+		Email:          random.RandomEmail(),
 		HashedPassword: hashedPassword,
-		Fullname:       utils.RandomFullname(),
-		Status:         utils.RandomStatus(),
-		Bio:            utils.RandomBio(),
+		Fullname:       random.RandomFullname(),
+		Status:         random.RandomStatus(),
+		Bio:            random.RandomBio(),
 	}
 
 	user, err := testQueries.CreateUser(context.Background(), createArgs)
@@ -90,3 +92,92 @@ func TestDeleteUser(t *testing.T) {
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, user2)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	require.Equal(t, role.User, user1.Role)
+
+	user2, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		ID:   user1.ID,
+		Role: role.Moderator,
+	})
+	require.NoError(t, err)
+	require.Equal(t, role.Moderator, user2.Role)
+}
+
+func TestBootstrapUserRole(t *testing.T) {
+	// A role nobody has, the database is shared with the other tests
+	bootstrapped := "role-" + random.RandomString(8)
+	user1 := CreateRandomUser(t)
+	user2 := CreateRandomUser(t)
+
+	// The email has to be verified
+	rows, err := testQueries.BootstrapUserRole(context.Background(), BootstrapUserRoleParams{
+		Email: user1.Email,
+		Role:  bootstrapped,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	for _, user := range []User{user1, user2} {
+		verifyEmail := CreateRandomVerifyEmail(t, user, time.Now().Add(time.Hour))
+		_, err = testQueries.VerifyEmail(context.Background(), VerifyEmailParams{
+			ID:       verifyEmail.ID,
+			CodeHash: verifyEmail.CodeHash,
+		})
+		require.NoError(t, err)
+	}
+
+	rows, err = testQueries.BootstrapUserRole(context.Background(), BootstrapUserRoleParams{
+		Email: user1.Email,
+		Role:  bootstrapped,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user3, err := testQueries.GetUserByID(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.Equal(t, bootstrapped, user3.Role)
+
+	// Only the first user gets the role
+	rows, err = testQueries.BootstrapUserRole(context.Background(), BootstrapUserRoleParams{
+		Email: user2.Email,
+		Role:  bootstrapped,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestUpdateUserBanned(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	require.False(t, user1.IsBanned)
+
+	user2, err := testQueries.UpdateUserBanned(context.Background(), UpdateUserBannedParams{
+		ID:       user1.ID,
+		IsBanned: true,
+	})
+	require.NoError(t, err)
+	require.True(t, user2.IsBanned)
+}
+
+func TestRehashUserPassword(t *testing.T) {
+	user1 := CreateRandomUser(t)
+
+	arg := RehashUserPasswordParams{
+		ID:                user1.ID,
+		OldHashedPassword: user1.HashedPassword,
+		NewHashedPassword: random.RandomString(60),
+	}
+	rows, err := testQueries.RehashUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user2, err := testQueries.GetUserByID(context.Background(), user1.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.NewHashedPassword, user2.HashedPassword)
+
+	// The hash read before the password change doesn't match anymore
+	rows, err = testQueries.RehashUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
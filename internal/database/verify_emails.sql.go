@@ -0,0 +1,98 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.17.0
+// source: verify_emails.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const createVerifyEmail = `-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    user_id,
+    email,
+    code_hash,
+    expired_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, user_id, email, code_hash, is_used, created_at, expired_at
+`
+
+type CreateVerifyEmailParams struct {
+	UserID    int32     `json:"user_id"`
+	Email     string    `json:"email"`
+	CodeHash  string    `json:"code_hash"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRowContext(ctx, createVerifyEmail,
+		arg.UserID,
+		arg.Email,
+		arg.CodeHash,
+		arg.ExpiredAt,
+	)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const verifyEmail = `-- name: VerifyEmail :one
+
+WITH verified AS (
+    UPDATE verify_emails
+    SET is_used = true
+    WHERE verify_emails.id = $1
+        AND code_hash = $2
+        AND is_used = false
+        AND expired_at > now()
+    RETURNING user_id, email
+)
+UPDATE users
+SET is_email_verified = true
+FROM verified
+WHERE users.id = verified.user_id AND users.email = verified.email
+RETURNING users.id, users.username, users.email, users.hashed_password, users.avatar, users.fullname, users.bio, users.status, users.created_at, users.updated_at, users.role, users.is_banned, users.is_email_verified, users.totp_secret, users.is_totp_enabled, users.totp_last_step, users.is_deactivated
+`
+
+type VerifyEmailParams struct {
+	ID       int64  `json:"id"`
+	CodeHash string `json:"code_hash"`
+}
+
+// UPDATE QUERIES
+func (q *Queries) VerifyEmail(ctx context.Context, arg VerifyEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyEmail, arg.ID, arg.CodeHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Avatar,
+		&i.Fullname,
+		&i.Bio,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Role,
+		&i.IsBanned,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.IsDeactivated,
+	)
+	return i, err
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+func CreateRandomVerifyEmail(t *testing.T, user User, expiredAt time.Time) VerifyEmail {
+	arg := CreateVerifyEmailParams{
+		UserID:    user.ID,
+		Email:     user.Email,
+		CodeHash:  password.HashToken(random.RandomString(32)),
+		ExpiredAt: expiredAt,
+	}
+
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, verifyEmail)
+
+	require.Equal(t, arg.UserID, verifyEmail.UserID)
+	require.Equal(t, arg.Email, verifyEmail.Email)
+	require.Equal(t, arg.CodeHash, verifyEmail.CodeHash)
+	require.False(t, verifyEmail.IsUsed)
+	require.NotZero(t, verifyEmail.ID)
+
+	return verifyEmail
+}
+
+func TestVerifyEmail(t *testing.T) {
+	user1 := CreateRandomUser(t)
+	require.False(t, user1.IsEmailVerified)
+	verifyEmail := CreateRandomVerifyEmail(t, user1, time.Now().Add(time.Hour))
+
+	_, err := testQueries.VerifyEmail(context.Background(), VerifyEmailParams{
+		ID:       verifyEmail.ID,
+		CodeHash: "wrong code",
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	user2, err := testQueries.VerifyEmail(context.Background(), VerifyEmailParams{
+		ID:       verifyEmail.ID,
+		CodeHash: verifyEmail.CodeHash,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user1.ID, user2.ID)
+	require.True(t, user2.IsEmailVerified)
+
+	// The code can be used only once
+	_, err = testQueries.VerifyEmail(context.Background(), VerifyEmailParams{
+		ID:       verifyEmail.ID,
+		CodeHash: verifyEmail.CodeHash,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestVerifyEmailExpired(t *testing.T) {
+	user := CreateRandomUser(t)
+	verifyEmail := CreateRandomVerifyEmail(t, user, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.VerifyEmail(context.Background(), VerifyEmailParams{
+		ID:       verifyEmail.ID,
+		CodeHash: verifyEmail.CodeHash,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
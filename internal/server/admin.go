@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Promotes the user with the ADMIN_EMAIL from the config to admin if there is no admin yet.
+// The user has to be registered and verify the email already,
+// so the first admin is created by restarting the server after that.
+func (s *Server) bootstrapAdmin(ctx context.Context) error {
+	if s.config.AdminEmail == "" {
+		return nil
+	}
+
+	rows, err := s.db.BootstrapUserRole(ctx, database.BootstrapUserRoleParams{
+		Email: s.config.AdminEmail,
+		Role:  role.Admin,
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		log.Printf("admin bootstrap: skipped, there is an admin already or user with email %s isn't registered or verified yet", s.config.AdminEmail)
+	}
+	return nil
+}
+
+// PUT REQUESTS
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+func (s *Server) updateUserRole(c *fiber.Ctx) error {
+	req := new(updateUserRoleRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if !role.IsValid(req.Role) {
+		return fiber.NewError(fiber.StatusBadRequest, "unknown role: "+req.Role)
+	}
+
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong user id")
+	}
+	adminID := c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID
+	if int32(id) == adminID {
+		return fiber.NewError(fiber.StatusBadRequest, "admins can't change their own role")
+	}
+
+	var user database.User
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		user, err = q.UpdateUserRole(c.Context(), database.UpdateUserRoleParams{
+			ID:   int32(id),
+			Role: req.Role,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fiber.NewError(fiber.StatusNotFound, err.Error())
+			}
+			return err
+		}
+
+		// The tokens carry the old role and its scopes, so the user has to log in again
+		return q.BlockUserSessions(c.Context(), user.ID)
+	})
+	if err != nil {
+		return txError(err)
+	}
+	audit.Record(c, s.db, audit.EventRoleChange, audit.OutcomeSuccess, user.ID, adminID, user.Role)
+
+	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
+}
+
+func (s *Server) banUser(c *fiber.Ctx) error {
+	return s.setUserBanned(c, true)
+}
+
+func (s *Server) setUserBanned(c *fiber.Ctx, banned bool) error {
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong user id")
+	}
+	adminID := c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID
+	if int32(id) == adminID {
+		return fiber.NewError(fiber.StatusBadRequest, "admins can't ban themselves")
+	}
+
+	var user database.User
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		user, err = q.UpdateUserBanned(c.Context(), database.UpdateUserBannedParams{
+			ID:       int32(id),
+			IsBanned: banned,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fiber.NewError(fiber.StatusNotFound, err.Error())
+			}
+			return err
+		}
+
+		// Banned user is logged out everywhere
+		if banned {
+			return q.BlockUserSessions(c.Context(), user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return txError(err)
+	}
+
+	if banned {
+		audit.Record(c, s.db, audit.EventBan, audit.OutcomeSuccess, user.ID, adminID, "")
+	} else {
+		audit.Record(c, s.db, audit.EventUnban, audit.OutcomeSuccess, user.ID, adminID, "")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
+}
+
+// DELETE REQUESTS
+
+func (s *Server) unbanUser(c *fiber.Ctx) error {
+	return s.setUserBanned(c, false)
+}
+
+func (s *Server) takedownJoke(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong joke id")
+	}
+
+	_, err = s.db.GetJoke(c.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	err = s.db.DeleteJoke(c.Context(), int32(id))
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	cnfg "github.com/abc_valera/flugo/internal/utils/config"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapAdmin(t *testing.T) {
+	email := random.RandomEmail()
+	s := newTestServer(t, func(config *cnfg.Config) { config.AdminEmail = email })
+	ctx := context.Background()
+
+	createUser := func(email string) database.User {
+		user, err := s.db.CreateUser(ctx, database.CreateUserParams{
+			Username: random.RandomUsername(),
+			Email:    email,
+		})
+		require.NoError(t, err)
+		return user
+	}
+	verify := func(user database.User) {
+		verifyEmail, err := s.db.CreateVerifyEmail(ctx, database.CreateVerifyEmailParams{
+			UserID:    user.ID,
+			Email:     user.Email,
+			CodeHash:  "code",
+			ExpiredAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		_, err = s.db.VerifyEmail(ctx, database.VerifyEmailParams{ID: verifyEmail.ID, CodeHash: "code"})
+		require.NoError(t, err)
+	}
+	roleOf := func(user database.User) string {
+		user, err := s.db.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		return user.Role
+	}
+
+	// Anyone can sign up with the email, so it has to be verified first
+	user := createUser(email)
+	require.NoError(t, s.bootstrapAdmin(ctx))
+	require.Equal(t, role.User, roleOf(user))
+
+	verify(user)
+	require.NoError(t, s.bootstrapAdmin(ctx))
+	require.Equal(t, role.Admin, roleOf(user))
+
+	// Once there is an admin, the email doesn't give the role anymore
+	_, err := s.db.UpdateUserRole(ctx, database.UpdateUserRoleParams{ID: user.ID, Role: role.User})
+	require.NoError(t, err)
+	admin := createUser(random.RandomEmail())
+	_, err = s.db.UpdateUserRole(ctx, database.UpdateUserRoleParams{ID: admin.ID, Role: role.Admin})
+	require.NoError(t, err)
+	require.NoError(t, s.bootstrapAdmin(ctx))
+	require.Equal(t, role.User, roleOf(user))
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+const apiKeyPrefix = "flugo_"
+
+// Returns new API key and its prefix. The key looks like flugo_1a2b3c4d_<secret>,
+// the prefix (flugo_1a2b3c4d) is stored as is, so the user can tell the keys apart.
+func generateAPIKey() (string, string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(b)
+
+	secret, err := random.SecureString(32)
+	if err != nil {
+		return "", "", err
+	}
+	return prefix + "_" + secret, prefix, nil
+}
+
+// apiKeyResponse type is returned back with response. It omits the hash of the key.
+type apiKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	IsRevoked  bool       `json:"is_revoked"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Returns new apiKeyResponse from default api key type. The scopes are the ones the key has with the given role.
+func newAPIKeyResponse(key database.ApiKey, userRole string) apiKeyResponse {
+	var lastUsedAt *time.Time
+	if key.LastUsedAt.Valid {
+		lastUsedAt = &key.LastUsedAt.Time
+	}
+	return apiKeyResponse{
+		key.ID,
+		key.Name,
+		key.Prefix,
+		scope.Granted(key.Scopes, userRole),
+		key.IsRevoked,
+		lastUsedAt,
+		key.CreatedAt,
+	}
+}
+
+// POST REQUESTS
+
+type createAPIKeyRequest struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// The key gets the full scope set of the user's role if it is empty
+	Scopes []string `json:"scopes"`
+}
+
+type createAPIKeyResponse struct {
+	// The key is shown only once, only its hash is stored
+	Key    string         `json:"key"`
+	APIKey apiKeyResponse `json:"api_key"`
+}
+
+func (s *Server) createAPIKey(c *fiber.Ctx) error {
+	req := new(createAPIKeyRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	var scopes []string
+	if len(req.Scopes) > 0 {
+		var err error
+		scopes, err = scope.Reduce(req.Scopes, scope.ForRole(authPayload.Role))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	apiKey, err := s.db.CreateAPIKey(c.Context(), database.CreateAPIKeyParams{
+		UserID:  authPayload.UserID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: password.HashToken(key),
+		Scopes:  scopes,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(createAPIKeyResponse{
+		Key:    key,
+		APIKey: newAPIKeyResponse(apiKey, authPayload.Role),
+	})
+}
+
+// GET REQUESTS
+
+func (s *Server) listMyAPIKeys(c *fiber.Ctx) error {
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	keys, err := s.db.ListAPIKeysByUser(c.Context(), authPayload.UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	keysResponse := make([]apiKeyResponse, 0)
+	for _, key := range keys {
+		keysResponse = append(keysResponse, newAPIKeyResponse(key, authPayload.Role))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(keysResponse)
+}
+
+// DELETE REQUESTS
+
+func (s *Server) revokeMyAPIKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong api key id")
+	}
+
+	// Keys of other users are reported as not found, so their ids can't be probed
+	rows, err := s.db.RevokeOwnedAPIKey(c.Context(), database.RevokeOwnedAPIKeyParams{
+		ID:     int64(id),
+		UserID: c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if rows == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "api key not found")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
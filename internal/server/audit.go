@@ -0,0 +1,151 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page size of the audit events if it isn't given, and the largest one allowed
+const (
+	defaultAuditPageSize = 20
+	maxAuditPageSize     = 100
+)
+
+// auditEventResponse type is returned back with response
+type auditEventResponse struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Outcome   string    `json:"outcome"`
+	UserID    *int32    `json:"user_id"`
+	ActorID   *int32    `json:"actor_id"`
+	ClientIP  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Returns new auditEventResponse from default audit event type
+func newAuditEventResponse(event database.AuditEvent) auditEventResponse {
+	var userID, actorID *int32
+	if event.UserID.Valid {
+		userID = &event.UserID.Int32
+	}
+	if event.ActorID.Valid {
+		actorID = &event.ActorID.Int32
+	}
+	return auditEventResponse{
+		event.ID,
+		event.Type,
+		event.Outcome,
+		userID,
+		actorID,
+		event.ClientIp,
+		event.UserAgent,
+		event.Details,
+		event.CreatedAt,
+	}
+}
+
+func newAuditEventsResponse(events []database.AuditEvent) []auditEventResponse {
+	eventsResponse := make([]auditEventResponse, 0)
+	for _, event := range events {
+		eventsResponse = append(eventsResponse, newAuditEventResponse(event))
+	}
+	return eventsResponse
+}
+
+// Reads the "first" and "size" query parameters of the audit event pages
+func auditPage(c *fiber.Ctx) (int32, int32, error) {
+	first, err := strconv.Atoi(c.Query("first", "0"))
+	if err != nil || first < 0 {
+		return 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong first")
+	}
+	size, err := strconv.Atoi(c.Query("size", strconv.Itoa(defaultAuditPageSize)))
+	if err != nil || size < 1 || size > maxAuditPageSize {
+		return 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong size")
+	}
+	return int32(first), int32(size), nil
+}
+
+// GET REQUESTS
+
+// Returns the events of the user's own account, newest first
+func (s *Server) listMySecurityEvents(c *fiber.Ctx) error {
+	first, size, err := auditPage(c)
+	if err != nil {
+		return err
+	}
+
+	userID := c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID
+	events, err := s.db.ListAuditEventsByUser(c.Context(), database.ListAuditEventsByUserParams{
+		UserID: sql.NullInt32{Int32: userID, Valid: true},
+		Limit:  size,
+		Offset: first,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(newAuditEventsResponse(events))
+}
+
+// Returns the events matching all the given filters: user_id, type, and the time range
+// from since (inclusive) to until (exclusive), both in RFC 3339
+func (s *Server) listAuditEvents(c *fiber.Ctx) error {
+	first, size, err := auditPage(c)
+	if err != nil {
+		return err
+	}
+
+	arg := database.ListAuditEventsParams{
+		Limit:  size,
+		Offset: first,
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil || id <= 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "Provided wrong user id")
+		}
+		arg.UserID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+	if eventType := c.Query("type"); eventType != "" {
+		if !audit.IsValidType(eventType) {
+			return fiber.NewError(fiber.StatusBadRequest, "unknown event type: "+eventType)
+		}
+		arg.Type = sql.NullString{String: eventType, Valid: true}
+	}
+	if arg.Since, err = queryTime(c, "since"); err != nil {
+		return err
+	}
+	if arg.Until, err = queryTime(c, "until"); err != nil {
+		return err
+	}
+
+	events, err := s.db.ListAuditEvents(c.Context(), arg)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(newAuditEventsResponse(events))
+}
+
+// Parses the optional RFC 3339 time of the query parameter
+func queryTime(c *fiber.Ctx, key string) (sql.NullTime, error) {
+	value := c.Query(key)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, fiber.NewError(fiber.StatusBadRequest, "Provided wrong "+key+", it must be in RFC 3339")
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
@@ -0,0 +1,419 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Number of comments in a page if it isn't given, and the largest one allowed.
+// The pages of the joke are of the top-level comments and the pages of a thread are of its replies.
+const (
+	defaultCommentPageSize = 20
+	maxCommentPageSize     = 100
+)
+
+// Number of the oldest replies every top-level comment comes with, the rest are listed by the thread
+const repliesPerThread = 10
+
+// commentResponse type is returned back with response
+type commentResponse struct {
+	ID        int32     `json:"id"`
+	JokeID    int32     `json:"joke_id"`
+	ParentID  *int32    `json:"parent_id"`
+	AuthorID  *int32    `json:"author_id"`
+	Author    *string   `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Deleted comments with replies stay in the thread without the author and the text
+	Deleted bool              `json:"deleted"`
+	Replies []commentResponse `json:"replies"`
+	// Cursor of the rest of the replies of the thread, set only on the top-level comments that have more
+	MoreRepliesCursor string `json:"more_replies_cursor,omitempty"`
+}
+
+// Returns new commentResponse without the replies from default comment type
+func newCommentResponse(comment database.CommentsWithAuthor) commentResponse {
+	res := commentResponse{
+		ID:        comment.ID,
+		JokeID:    comment.JokeID,
+		Text:      comment.Text,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+		Deleted:   !comment.AuthorID.Valid,
+		Replies:   make([]commentResponse, 0),
+	}
+	if comment.ParentID.Valid {
+		res.ParentID = &comment.ParentID.Int32
+	}
+	if comment.AuthorID.Valid {
+		res.AuthorID = &comment.AuthorID.Int32
+		res.Author = &comment.Author.String
+	}
+	return res
+}
+
+// Nests the replies of the threads under the comments they answer.
+// Replies are sorted by id, so the replies to every comment are listed oldest first.
+// Threads with more than repliesPerThread replies get the cursor of the rest.
+func newCommentThreadsResponse(roots, replies []database.CommentsWithAuthor) []commentResponse {
+	listed := make(map[int32]int)
+	lastIDs := make(map[int32]int32)
+	moreCursors := make(map[int32]string)
+	children := make(map[int32][]database.CommentsWithAuthor)
+	for _, reply := range replies {
+		root := reply.RootID.Int32
+		if listed[root] == repliesPerThread {
+			// The reply over the limit only tells that there are more
+			moreCursors[root] = strconv.Itoa(int(lastIDs[root]))
+			continue
+		}
+		listed[root]++
+		lastIDs[root] = reply.ID
+		children[reply.ParentID.Int32] = append(children[reply.ParentID.Int32], reply)
+	}
+
+	var thread func(comment database.CommentsWithAuthor) commentResponse
+	thread = func(comment database.CommentsWithAuthor) commentResponse {
+		res := newCommentResponse(comment)
+		for _, child := range children[comment.ID] {
+			res.Replies = append(res.Replies, thread(child))
+		}
+		return res
+	}
+
+	threads := make([]commentResponse, 0)
+	for _, root := range roots {
+		res := thread(root)
+		res.MoreRepliesCursor = moreCursors[root.ID]
+		threads = append(threads, res)
+	}
+	return threads
+}
+
+type listCommentsResponse struct {
+	Comments []commentResponse `json:"comments"`
+	// Cursor of the next page, empty on the last one
+	NextCursor string `json:"next_cursor"`
+}
+
+// Reads the joke id and the comment id from the path, the comment id only if it is there
+func commentParams(c *fiber.Ctx) (jokeID, commentID int32, err error) {
+	id, err := c.ParamsInt("id")
+	if id <= 0 || err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "invalid joke id")
+	}
+	if c.Params("comment_id") == "" {
+		return int32(id), 0, nil
+	}
+	cid, err := c.ParamsInt("comment_id")
+	if cid <= 0 || err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "invalid comment id")
+	}
+	return int32(id), int32(cid), nil
+}
+
+// Reads the size of the page and the cursor, which is the id of the last comment of the previous page
+func commentPage(c *fiber.Ctx) (size, afterID int, err error) {
+	size, err = strconv.Atoi(c.Query("size", strconv.Itoa(defaultCommentPageSize)))
+	if err != nil || size < 1 || size > maxCommentPageSize {
+		return 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong size")
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err = strconv.Atoi(cursor)
+		if err != nil || afterID < 0 {
+			return 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong cursor")
+		}
+	}
+	return size, afterID, nil
+}
+
+// Responds with the written comment read back with the current username of its author
+func (s *Server) respondWithComment(c *fiber.Ctx, id int32) error {
+	comment, err := s.db.GetComment(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(newCommentResponse(comment))
+}
+
+// commentOwner returns an ownerLookup for the comments of the joke
+func (s *Server) commentOwner(jokeID int32) ownerLookup {
+	return func(ctx context.Context, id int32) (int32, error) {
+		comment, err := s.db.GetComment(ctx, id)
+		// Tombstones can't be changed anymore
+		if err == nil && (comment.JokeID != jokeID || !comment.AuthorID.Valid) {
+			return 0, sql.ErrNoRows
+		}
+		return comment.AuthorID.Int32, err
+	}
+}
+
+// POST REQUESTS
+
+type createCommentRequest struct {
+	Text string `json:"text" validate:"required"`
+	// Comment to reply to, zero comments on the joke itself
+	ParentID int32 `json:"parent_id"`
+}
+
+func (s *Server) createComment(c *fiber.Ctx) error {
+	req := new(createCommentRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	jokeID, _, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	var comment database.Comment
+	// The parent is checked in the same transaction, so the reply isn't added to a deleted thread
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		arg := database.CreateCommentParams{
+			JokeID:   jokeID,
+			AuthorID: authPayload.UserID,
+			Text:     req.Text,
+		}
+		if req.ParentID != 0 {
+			parent, err := q.GetComment(c.Context(), req.ParentID)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					return fiber.NewError(fiber.StatusNotFound, "parent comment not found")
+				}
+				return err
+			}
+			if parent.JokeID != jokeID {
+				return fiber.NewError(fiber.StatusBadRequest, "parent comment belongs to another joke")
+			}
+			arg.ParentID = sql.NullInt32{Int32: parent.ID, Valid: true}
+			arg.RootID = parent.RootID
+			if !parent.RootID.Valid {
+				arg.RootID = arg.ParentID
+			}
+		}
+
+		var err error
+		comment, err = q.CreateComment(c.Context(), arg)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return fiber.NewError(fiber.StatusNotFound, "joke not found")
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return txError(err)
+	}
+
+	return s.respondWithComment(c, comment.ID)
+}
+
+// GET REQUESTS
+
+// Returns a page of the top-level comments of the joke with their oldest replies, oldest first.
+// The next page is read by passing the next_cursor of the response as the cursor query parameter,
+// the rest of the replies are read from listCommentReplies with the more_replies_cursor of the thread.
+func (s *Server) listComments(c *fiber.Ctx) error {
+	jokeID, _, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+	size, afterID, err := commentPage(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.GetJoke(c.Context(), jokeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, "joke not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// One more comment than asked for tells if there is a next page
+	roots, err := s.db.ListComments(c.Context(), database.ListCommentsParams{
+		JokeID:  jokeID,
+		AfterID: int32(afterID),
+		Limit:   int32(size) + 1,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	var nextCursor string
+	if len(roots) > size {
+		roots = roots[:size]
+		nextCursor = strconv.Itoa(int(roots[size-1].ID))
+	}
+
+	rootIDs := make([]int32, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+	}
+	// One more reply than listed tells if the thread has more
+	replies, err := s.db.ListCommentReplies(c.Context(), database.ListCommentRepliesParams{
+		RootIds: rootIDs,
+		Limit:   repliesPerThread + 1,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(listCommentsResponse{
+		Comments:   newCommentThreadsResponse(roots, replies),
+		NextCursor: nextCursor,
+	})
+}
+
+// Returns a page of the replies in the thread of the top-level comment, oldest first.
+// The replies aren't nested, as the comments they answer can be on the previous pages.
+func (s *Server) listCommentReplies(c *fiber.Ctx) error {
+	jokeID, commentID, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+	size, afterID, err := commentPage(c)
+	if err != nil {
+		return err
+	}
+
+	root, err := s.db.GetComment(c.Context(), commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, "comment not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if root.JokeID != jokeID {
+		return fiber.NewError(fiber.StatusNotFound, "comment not found")
+	}
+	if root.ParentID.Valid {
+		return fiber.NewError(fiber.StatusBadRequest, "replies are listed by the top-level comment of the thread")
+	}
+
+	replies, err := s.db.ListThreadReplies(c.Context(), database.ListThreadRepliesParams{
+		RootID:  sql.NullInt32{Int32: root.ID, Valid: true},
+		AfterID: int32(afterID),
+		Limit:   int32(size) + 1,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	var nextCursor string
+	if len(replies) > size {
+		replies = replies[:size]
+		nextCursor = strconv.Itoa(int(replies[size-1].ID))
+	}
+
+	res := listCommentsResponse{
+		Comments:   make([]commentResponse, 0, len(replies)),
+		NextCursor: nextCursor,
+	}
+	for _, reply := range replies {
+		res.Comments = append(res.Comments, newCommentResponse(reply))
+	}
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+// PUT REQUESTS
+
+type updateCommentRequest struct {
+	Text string `json:"text" validate:"required"`
+}
+
+func (s *Server) updateComment(c *fiber.Ctx) error {
+	req := new(updateCommentRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	jokeID, commentID, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	comment, err := s.db.UpdateOwnedCommentText(c.Context(), database.UpdateOwnedCommentTextParams{
+		ID:       commentID,
+		JokeID:   jokeID,
+		AuthorID: authPayload.UserID,
+		Text:     req.Text,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ownershipError(c.Context(), "comment", commentID, authPayload, s.commentOwner(jokeID))
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return s.respondWithComment(c, comment.ID)
+}
+
+// DELETE REQUESTS
+
+// Deletes the comment, or turns it into a tombstone if it has replies.
+// Tombstones left without replies are deleted too.
+func (s *Server) deleteComment(c *fiber.Ctx) error {
+	jokeID, commentID, err := commentParams(c)
+	if err != nil {
+		return err
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		rows, err := q.TombstoneOwnedComment(c.Context(), database.TombstoneOwnedCommentParams{
+			ID:       commentID,
+			JokeID:   jokeID,
+			AuthorID: authPayload.UserID,
+		})
+		if err != nil || rows > 0 {
+			return err
+		}
+
+		parentID, err := q.DeleteOwnedComment(c.Context(), database.DeleteOwnedCommentParams{
+			ID:       commentID,
+			JokeID:   jokeID,
+			AuthorID: authPayload.UserID,
+		})
+		for err == nil && parentID.Valid {
+			parentID, err = q.DeleteEmptyTombstone(c.Context(), parentID.Int32)
+			if err == sql.ErrNoRows {
+				// The parent isn't a tombstone or has other replies
+				return nil
+			}
+		}
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ownershipError(c.Context(), "comment", commentID, authPayload, s.commentOwner(jokeID))
+	}
+	if err != nil {
+		return txError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCommentThreads(t *testing.T) {
+	f := newFixture(t)
+	first := f.createComment(t, f.user.ID, f.joke.ID, 0)
+	second := f.createComment(t, f.other.ID, f.joke.ID, 0)
+	third := f.createComment(t, f.user.ID, f.joke.ID, 0)
+	reply := f.createComment(t, f.other.ID, f.joke.ID, first.ID)
+	nestedReply := f.createComment(t, f.user.ID, f.joke.ID, reply.ID)
+	secondReply := f.createComment(t, f.moderator.ID, f.joke.ID, first.ID)
+
+	list := func(query string) listCommentsResponse {
+		var res listCommentsResponse
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, fmt.Sprintf("/jokes/%d/comments?%s", f.joke.ID, query), "", nil), &res)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return res
+	}
+
+	page := list("size=2")
+	require.Len(t, page.Comments, 2)
+	require.Equal(t, first.ID, page.Comments[0].ID)
+	require.Equal(t, f.user.Username, *page.Comments[0].Author)
+	require.Nil(t, page.Comments[0].ParentID)
+	// Replies are nested under the comments they answer, oldest first
+	require.Len(t, page.Comments[0].Replies, 2)
+	require.Equal(t, reply.ID, page.Comments[0].Replies[0].ID)
+	require.Equal(t, first.ID, *page.Comments[0].Replies[0].ParentID)
+	require.Len(t, page.Comments[0].Replies[0].Replies, 1)
+	require.Equal(t, nestedReply.ID, page.Comments[0].Replies[0].Replies[0].ID)
+	require.Equal(t, secondReply.ID, page.Comments[0].Replies[1].ID)
+	require.Equal(t, second.ID, page.Comments[1].ID)
+	require.Empty(t, page.Comments[1].Replies)
+	require.NotEmpty(t, page.NextCursor)
+
+	page = list("size=2&cursor=" + page.NextCursor)
+	require.Len(t, page.Comments, 1)
+	require.Equal(t, third.ID, page.Comments[0].ID)
+	require.Empty(t, page.NextCursor)
+}
+
+func TestListCommentReplies(t *testing.T) {
+	f := newFixture(t)
+	root := f.createComment(t, f.user.ID, f.joke.ID, 0)
+	var replies []database.Comment
+	parent := root
+	for i := 0; i < repliesPerThread+3; i++ {
+		reply := f.createComment(t, f.other.ID, f.joke.ID, parent.ID)
+		replies = append(replies, reply)
+		parent = reply
+	}
+
+	list := func(target string) listCommentsResponse {
+		var res listCommentsResponse
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, target, "", nil), &res)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return res
+	}
+
+	// The thread comes with its oldest replies only
+	page := list(fmt.Sprintf("/jokes/%d/comments", f.joke.ID))
+	require.Len(t, page.Comments, 1)
+	thread := page.Comments[0]
+	for i := 0; i < repliesPerThread; i++ {
+		require.Len(t, thread.Replies, 1)
+		thread = thread.Replies[0]
+		require.Equal(t, replies[i].ID, thread.ID)
+	}
+	require.Empty(t, thread.Replies)
+	require.Equal(t, strconv.Itoa(int(replies[repliesPerThread-1].ID)), page.Comments[0].MoreRepliesCursor)
+
+	// The rest are listed by the thread
+	more := list(fmt.Sprintf("/jokes/%d/comments/%d/replies?size=2&cursor=%s", f.joke.ID, root.ID, page.Comments[0].MoreRepliesCursor))
+	require.Len(t, more.Comments, 2)
+	require.Equal(t, replies[repliesPerThread].ID, more.Comments[0].ID)
+	require.Equal(t, replies[repliesPerThread-1].ID, *more.Comments[0].ParentID)
+	require.NotEmpty(t, more.NextCursor)
+
+	more = list(fmt.Sprintf("/jokes/%d/comments/%d/replies?size=2&cursor=%s", f.joke.ID, root.ID, more.NextCursor))
+	require.Len(t, more.Comments, 1)
+	require.Equal(t, replies[len(replies)-1].ID, more.Comments[0].ID)
+	require.Empty(t, more.NextCursor)
+}
+
+func TestDeleteCommentThread(t *testing.T) {
+	f := newFixture(t)
+	first := f.createComment(t, f.user.ID, f.joke.ID, 0)
+	reply := f.createComment(t, f.other.ID, f.joke.ID, first.ID)
+	nestedReply := f.createComment(t, f.user.ID, f.joke.ID, reply.ID)
+	f.createComment(t, f.user.ID, f.joke.ID, 0)
+
+	commentCount := func() int32 {
+		var joke database.JokesWithAuthor
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, fmt.Sprintf("/jokes/%d", f.joke.ID), "", nil), &joke)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return joke.CommentCount
+	}
+	firstThread := func() commentResponse {
+		var res listCommentsResponse
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, fmt.Sprintf("/jokes/%d/comments", f.joke.ID), "", nil), &res)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return res.Comments[0]
+	}
+	deleteComment := func(user testUser, id int32) *http.Response {
+		return doRequest(t, f.s, jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d/comments/%d", f.joke.ID, id), user.login.AccessToken, nil), nil)
+	}
+	require.Equal(t, int32(4), commentCount())
+
+	// Deleting the reply keeps it as a tombstone with the reply to it
+	resp := deleteComment(f.other, reply.ID)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, int32(3), commentCount())
+	tombstone := firstThread().Replies[0]
+	require.Equal(t, reply.ID, tombstone.ID)
+	require.True(t, tombstone.Deleted)
+	require.Nil(t, tombstone.AuthorID)
+	require.Nil(t, tombstone.Author)
+	require.Empty(t, tombstone.Text)
+	require.Equal(t, nestedReply.ID, tombstone.Replies[0].ID)
+
+	// Tombstones can't be changed
+	resp = deleteComment(f.other, reply.ID)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The tombstone goes with its last reply
+	resp = deleteComment(f.user, nestedReply.ID)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, int32(2), commentCount())
+	require.Empty(t, firstThread().Replies)
+
+	// Comments of deleted users with replies stay as tombstones
+	f.createComment(t, f.user.ID, f.joke.ID, f.createComment(t, f.other.ID, f.joke.ID, first.ID).ID)
+	require.Equal(t, int32(4), commentCount())
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodDelete, "/users", f.other.login.AccessToken, deleteUserRequest{f.other.password}), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, int32(3), commentCount())
+	require.True(t, firstThread().Replies[0].Deleted)
+	require.Len(t, firstThread().Replies[0].Replies, 1)
+}
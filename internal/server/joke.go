@@ -1,22 +1,55 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/abc_valera/flugo/internal/database"
 	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/search"
 	"github.com/abc_valera/flugo/internal/utils/token"
 	"github.com/gofiber/fiber/v2"
 )
 
+// Responds with the written joke read back with the current username of its author.
+// It isn't taken from the token, which keeps the old username if the author has changed it since.
+func (s *Server) respondWithJoke(c *fiber.Ctx, id int32) error {
+	joke, err := s.db.GetJoke(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(joke)
+}
+
+// Reads the first joke and the number of jokes of the page from the query
+func jokePage(c *fiber.Ctx) (first, size int32, err error) {
+	f, err := strconv.Atoi(c.Query("first"))
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	n, err := strconv.Atoi(c.Query("size"))
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return int32(f), int32(n), nil
+}
+
 // POST REQUESTS
 
 type createJokeRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Text        string `json:"text" validate:"required"`
-	Explanation string `json:"explanation"`
+	Title       string   `json:"title" validate:"required"`
+	Text        string   `json:"text" validate:"required"`
+	Explanation string   `json:"explanation"`
+	Tags        []string `json:"tags" validate:"max=10,dive,tag"`
+	// Language the joke is searched in, English if it isn't given
+	Language string `json:"language"`
 }
 
 func (s *Server) createJoke(c *fiber.Ctx) error {
@@ -24,23 +57,39 @@ func (s *Server) createJoke(c *fiber.Ctx) error {
 	if err := c.BodyParser(req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
+	req.Tags = normalizeTags(req.Tags)
 	if err := s.validator.Validate(req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
+	if req.Language == "" {
+		req.Language = search.DefaultLanguage
+	}
+	if !search.IsLanguage(req.Language) {
+		return fiber.NewError(fiber.StatusBadRequest, "unknown language")
+	}
 
 	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
 
-	joke, err := s.db.CreateJoke(c.Context(), database.CreateJokeParams{
-		Author:      authPayload.Username,
-		Title:       req.Title,
-		Text:        req.Text,
-		Explanation: req.Explanation,
+	var joke database.Joke
+	err := s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		var err error
+		joke, err = q.CreateJoke(c.Context(), database.CreateJokeParams{
+			AuthorID:    authPayload.UserID,
+			Title:       req.Title,
+			Text:        req.Text,
+			Explanation: req.Explanation,
+			Language:    req.Language,
+		})
+		if err != nil {
+			return err
+		}
+		return setJokeTags(c.Context(), q, joke.ID, req.Tags)
 	})
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return txError(err)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(joke)
+	return s.respondWithJoke(c, joke.ID)
 }
 
 // GET REQUESTS
@@ -68,19 +117,15 @@ func (s *Server) listJokesByAuthor(c *fiber.Ctx) error {
 	if queryUsername == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong username")
 	}
-	first, err := strconv.Atoi(c.Query("first"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
-	}
-	size, err := strconv.Atoi(c.Query("size"))
+	first, size, err := jokePage(c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return err
 	}
 
 	jokes, err := s.db.ListJokesByAuthor(c.Context(), database.ListJokesByAuthorParams{
 		Author: queryUsername,
-		Limit:  int32(size),
-		Offset: int32(first),
+		Limit:  size,
+		Offset: first,
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -88,23 +133,49 @@ func (s *Server) listJokesByAuthor(c *fiber.Ctx) error {
 		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+
+	// Old usernames are redirected to the current ones during the grace period
+	if len(jokes) == 0 {
+		newUsername, ok, err := s.renamedUsername(c.Context(), queryUsername)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		if ok {
+			location := "/jokes_by/" + url.PathEscape(newUsername)
+			if query := c.Request().URI().QueryString(); len(query) > 0 {
+				location += "?" + string(query)
+			}
+			return c.Redirect(location, fiber.StatusFound)
+		}
+	}
 	return c.Status(fiber.StatusOK).JSON(jokes)
 }
 
+// Lists the jokes, only the ones with the comma-separated tags if they are given.
+// The match query parameter tells if the jokes need any (default) or all of the tags.
 func (s *Server) listJokes(c *fiber.Ctx) error {
-	first, err := strconv.Atoi(c.Query("first"))
+	first, size, err := jokePage(c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return err
 	}
-	size, err := strconv.Atoi(c.Query("size"))
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+
+	arg := database.ListJokesParams{
+		Limit:  size,
+		Offset: first,
+	}
+	if query := c.Query("tags"); query != "" {
+		tags := normalizeTags(strings.Split(query, ","))
+		switch c.Query("match", tagMatchAny) {
+		case tagMatchAny:
+			arg.AnyTags = tags
+		case tagMatchAll:
+			arg.AllTags = tags
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, "match must be any or all")
+		}
 	}
 
-	jokes, err := s.db.ListJokes(c.Context(), database.ListJokesParams{
-		Limit:  int32(size),
-		Offset: int32(first),
-	})
+	jokes, err := s.db.ListJokes(c.Context(), arg)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -128,15 +199,21 @@ func (s *Server) updateJokeTitle(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	joke, err := s.db.UpdateJokeTitle(c.Context(), database.UpdateJokeTitleParams{
-		ID:    int32(id),
-		Title: req.Title,
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	joke, err := s.db.UpdateOwnedJokeTitle(c.Context(), database.UpdateOwnedJokeTitleParams{
+		ID:       int32(id),
+		AuthorID: authPayload.UserID,
+		Title:    req.Title,
 	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ownershipError(c.Context(), "joke", int32(id), authPayload, s.jokeOwner)
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(joke)
+	return s.respondWithJoke(c, joke.ID)
 }
 
 type updateJokeTextRequest struct {
@@ -154,15 +231,21 @@ func (s *Server) updateJokeText(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	joke, err := s.db.UpdateJokeText(c.Context(), database.UpdateJokeTextParams{
-		ID:   int32(id),
-		Text: req.Text,
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	joke, err := s.db.UpdateOwnedJokeText(c.Context(), database.UpdateOwnedJokeTextParams{
+		ID:       int32(id),
+		AuthorID: authPayload.UserID,
+		Text:     req.Text,
 	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ownershipError(c.Context(), "joke", int32(id), authPayload, s.jokeOwner)
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(joke)
+	return s.respondWithJoke(c, joke.ID)
 }
 
 type updateJokeExplanationRequest struct {
@@ -180,15 +263,105 @@ func (s *Server) updateJokeExplanation(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	joke, err := s.db.UpdateJokeExplanation(c.Context(), database.UpdateJokeExplanationParams{
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	joke, err := s.db.UpdateOwnedJokeExplanation(c.Context(), database.UpdateOwnedJokeExplanationParams{
 		ID:          int32(id),
+		AuthorID:    authPayload.UserID,
 		Explanation: req.Explanation,
 	})
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return ownershipError(c.Context(), "joke", int32(id), authPayload, s.jokeOwner)
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(joke)
+	return s.respondWithJoke(c, joke.ID)
+}
+
+type updateJokeLanguageRequest struct {
+	Language string `json:"language" validate:"required"`
+}
+
+func (s *Server) updateJokeLanguage(c *fiber.Ctx) error {
+	req := new(updateJokeLanguageRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if !search.IsLanguage(req.Language) {
+		return fiber.NewError(fiber.StatusBadRequest, "unknown language")
+	}
+
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid joke id")
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	joke, err := s.db.UpdateOwnedJokeLanguage(c.Context(), database.UpdateOwnedJokeLanguageParams{
+		ID:       int32(id),
+		AuthorID: authPayload.UserID,
+		Language: req.Language,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ownershipError(c.Context(), "joke", int32(id), authPayload, s.jokeOwner)
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return s.respondWithJoke(c, joke.ID)
+}
+
+type updateJokeTagsRequest struct {
+	Tags []string `json:"tags" validate:"max=10,dive,tag"`
+}
+
+// Replaces the tags of the joke, an empty list removes all of them
+func (s *Server) updateJokeTags(c *fiber.Ctx) error {
+	req := new(updateJokeTagsRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	req.Tags = normalizeTags(req.Tags)
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	id, err := c.ParamsInt("id")
+	if id <= 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid joke id")
+	}
+	jokeID := int32(id)
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		owner := func(ctx context.Context, id int32) (int32, error) {
+			joke, err := q.GetJoke(ctx, id)
+			return joke.AuthorID, err
+		}
+		authorID, err := owner(c.Context(), jokeID)
+		if err != nil || authorID != authPayload.UserID {
+			return ownershipError(c.Context(), "joke", jokeID, authPayload, owner)
+		}
+
+		err = setJokeTags(c.Context(), q, jokeID, req.Tags)
+		if isForeignKeyViolation(err) {
+			return fiber.NewError(fiber.StatusNotFound, "joke not found")
+		}
+		return err
+	})
+	if err != nil {
+		return txError(err)
+	}
+
+	return s.respondWithJoke(c, jokeID)
 }
 
 // DELETE REQUESTS
@@ -199,15 +372,23 @@ func (s *Server) deleteJoke(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	err = s.db.DeleteJoke(c.Context(), int32(id))
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	rows, err := s.db.DeleteOwnedJoke(c.Context(), database.DeleteOwnedJokeParams{
+		ID:       int32(id),
+		AuthorID: authPayload.UserID,
+	})
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+	if rows == 0 {
+		return ownershipError(c.Context(), "joke", int32(id), authPayload, s.jokeOwner)
+	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
 func (s *Server) deleteJokesByAuthor(c *fiber.Ctx) error {
-	err := s.db.DeleteJokesByAuthor(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).Username)
+	err := s.db.DeleteJokesByAuthor(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
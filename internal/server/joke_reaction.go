@@ -0,0 +1,128 @@
+package server
+
+import (
+	"database/sql"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/reaction"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Returns the change of the counters of the joke when the reaction is added (n = 1) or taken back (n = -1)
+func reactionCounts(jokeID int32, r string, n int32) database.AddJokeReactionCountsParams {
+	counts := database.AddJokeReactionCountsParams{ID: jokeID}
+	switch r {
+	case reaction.Laugh:
+		counts.Laughs = n
+	case reaction.Groan:
+		counts.Groans = n
+	case reaction.Love:
+		counts.Loves = n
+	}
+	return counts
+}
+
+// PUT REQUESTS
+
+type reactToJokeRequest struct {
+	Reaction string `json:"reaction" validate:"required"`
+}
+
+// Sets the reaction of the user to the joke, replacing the previous one
+func (s *Server) reactToJoke(c *fiber.Ctx) error {
+	req := new(reactToJokeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if !reaction.IsValid(req.Reaction) {
+		return fiber.NewError(fiber.StatusBadRequest, "unknown reaction")
+	}
+
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid joke id")
+	}
+	jokeID := int32(id)
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	// The counters of the joke change along with the reaction
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		old, err := q.GetJokeReactionForUpdate(c.Context(), database.GetJokeReactionForUpdateParams{
+			UserID: authPayload.UserID,
+			JokeID: jokeID,
+		})
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = q.CreateJokeReaction(c.Context(), database.CreateJokeReactionParams{
+				UserID:   authPayload.UserID,
+				JokeID:   jokeID,
+				Reaction: req.Reaction,
+			})
+			if err != nil {
+				if isForeignKeyViolation(err) {
+					return fiber.NewError(fiber.StatusNotFound, "joke not found")
+				}
+				return err
+			}
+		case err != nil:
+			return err
+		case old.Reaction == req.Reaction:
+			return nil
+		default:
+			_, err = q.UpdateJokeReaction(c.Context(), database.UpdateJokeReactionParams{
+				UserID:   authPayload.UserID,
+				JokeID:   jokeID,
+				Reaction: req.Reaction,
+			})
+			if err != nil {
+				return err
+			}
+			err = q.AddJokeReactionCounts(c.Context(), reactionCounts(jokeID, old.Reaction, -1))
+			if err != nil {
+				return err
+			}
+		}
+		return q.AddJokeReactionCounts(c.Context(), reactionCounts(jokeID, req.Reaction, 1))
+	})
+	if err != nil {
+		return txError(err)
+	}
+
+	return s.respondWithJoke(c, jokeID)
+}
+
+// DELETE REQUESTS
+
+func (s *Server) deleteJokeReaction(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid joke id")
+	}
+	jokeID := int32(id)
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		old, err := q.DeleteJokeReaction(c.Context(), database.DeleteJokeReactionParams{
+			UserID: authPayload.UserID,
+			JokeID: jokeID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fiber.NewError(fiber.StatusNotFound, "reaction not found")
+			}
+			return err
+		}
+		return q.AddJokeReactionCounts(c.Context(), reactionCounts(jokeID, old.Reaction, -1))
+	})
+	if err != nil {
+		return txError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/reaction"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJokeReactionCounts(t *testing.T) {
+	f := newFixture(t)
+	path := fmt.Sprintf("/jokes/%d/reactions", f.joke.ID)
+
+	react := func(user testUser, r string) database.JokesWithAuthor {
+		var joke database.JokesWithAuthor
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPut, path, user.login.AccessToken, reactToJokeRequest{r}), &joke)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		return joke
+	}
+	requireCounts := func(joke database.JokesWithAuthor, laughs, groans, loves int32) {
+		require.Equal(t, laughs, joke.LaughCount)
+		require.Equal(t, groans, joke.GroanCount)
+		require.Equal(t, loves, joke.LoveCount)
+	}
+	getJoke := func() database.JokesWithAuthor {
+		var joke database.JokesWithAuthor
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, fmt.Sprintf("/jokes/%d", f.joke.ID), "", nil), &joke)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return joke
+	}
+
+	requireCounts(react(f.user, reaction.Laugh), 1, 0, 0)
+	requireCounts(react(f.other, reaction.Laugh), 2, 0, 0)
+	// Reacting again with the same reaction changes nothing
+	requireCounts(react(f.other, reaction.Laugh), 2, 0, 0)
+	// A new reaction replaces the previous one of the user
+	requireCounts(react(f.other, reaction.Groan), 1, 1, 0)
+	requireCounts(react(f.moderator, reaction.Love), 1, 1, 1)
+
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodDelete, path, f.moderator.login.AccessToken, nil), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	requireCounts(getJoke(), 1, 1, 0)
+
+	// The counts are listed along with the jokes
+	var jokes []database.JokesWithAuthor
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodGet, "/jokes?first=0&size=10", "", nil), &jokes)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, jokes, 1)
+	requireCounts(jokes[0], 1, 1, 0)
+
+	// Reactions of deleted users are taken out of the counts
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodDelete, "/users", f.other.login.AccessToken, deleteUserRequest{f.other.password}), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	requireCounts(getJoke(), 1, 0, 0)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"database/sql"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Keys of the login attempt trackers
+func loginAccountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Rejects the login with 429 while the email or the IP is locked out
+func (s *Server) checkLoginLockout(c *fiber.Ctx, now time.Time, accountKey, ipKey string) error {
+	accountLockedUntil, err := s.accountAttempts.LockedUntil(c.Context(), accountKey, now)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	ipLockedUntil, err := s.ipAttempts.LockedUntil(c.Context(), ipKey, now)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	lockedUntil := accountLockedUntil
+	if ipLockedUntil.After(lockedUntil) {
+		lockedUntil = ipLockedUntil
+	}
+	if !lockedUntil.After(now) {
+		return nil
+	}
+
+	retryAfter := int(math.Ceil(lockedUntil.Sub(now).Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return fiber.NewError(fiber.StatusTooManyRequests, "too many failed login attempts, try again later")
+}
+
+// Records the failed login for the email and the IP, and in the audit log.
+// userID is zero if the email isn't registered.
+func (s *Server) failLogin(c *fiber.Ctx, now time.Time, accountKey, ipKey string, userID int32, details string) error {
+	if err := s.recordLoginFailure(c, now, accountKey, ipKey, userID, details); err != nil {
+		return err
+	}
+	return fiber.NewError(fiber.StatusUnauthorized, "invalid email or password")
+}
+
+// Counts the failure against the email and the IP and writes it to the audit log.
+// Wrong second factors are counted too, so the codes can't be guessed by logging in again and again.
+func (s *Server) recordLoginFailure(c *fiber.Ctx, now time.Time, accountKey, ipKey string, userID int32, details string) error {
+	audit.Record(c, s.db, audit.EventLogin, audit.OutcomeFailure, userID, 0, details)
+	if _, err := s.accountAttempts.Fail(c.Context(), accountKey, now); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if _, err := s.ipAttempts.Fail(c.Context(), ipKey, now); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// Forgets the failures of the email once the login is done, with the second factor if it is on
+func (s *Server) resetLoginFailures(c *fiber.Ctx, accountKey string) error {
+	if err := s.accountAttempts.Reset(c.Context(), accountKey); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return nil
+}
+
+// DELETE REQUESTS
+
+func (s *Server) clearUserLockout(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if id == 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong user id")
+	}
+
+	user, err := s.db.GetUserByID(c.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := s.accountAttempts.Reset(c.Context(), loginAccountKey(user.Email)); err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	audit.Record(c, s.db, audit.EventLockoutClear, audit.OutcomeSuccess, user.ID, c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID, "")
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *Server) clearIPLockout(c *fiber.Ctx) error {
+	ip := c.Params("ip")
+	if net.ParseIP(ip) == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong ip")
+	}
+
+	if err := s.ipAttempts.Reset(c.Context(), loginIPKey(ip)); err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	cnfg "github.com/abc_valera/flugo/internal/utils/config"
+	"github.com/abc_valera/flugo/internal/utils/lockout"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/stretchr/testify/require"
+)
+
+// Requests of the tests come from 0.0.0.0
+func behindProxy(trustedProxies ...string) func(*cnfg.Config) {
+	return func(c *cnfg.Config) {
+		c.ProxyHeader = "Fly-Client-IP"
+		c.TrustedProxies = trustedProxies
+		c.LoginFreeAttempts = 100
+		// The second failure from an IP locks it out
+		c.LoginIPFreeAttempts = 1
+		c.LoginLockoutBaseDelay = time.Hour
+	}
+}
+
+func loginFrom(t *testing.T, s *Server, ip, email, password string) int {
+	req := jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: email, Password: password})
+	req.Header.Set("Fly-Client-IP", ip)
+	return doRequest(t, s, req, nil).StatusCode
+}
+
+func TestLoginIPFromProxyHeader(t *testing.T) {
+	s := newTestServer(t, behindProxy("0.0.0.0"))
+	user := newTestUser(t, s, role.User)
+
+	require.Equal(t, http.StatusUnauthorized, loginFrom(t, s, "203.0.113.1", user.Email, "wrong password"))
+	require.Equal(t, http.StatusUnauthorized, loginFrom(t, s, "203.0.113.1", user.Email, "wrong password"))
+	require.Equal(t, http.StatusTooManyRequests, loginFrom(t, s, "203.0.113.1", user.Email, user.password))
+	// The failures of one client don't lock out the others behind the same proxy
+	require.Equal(t, http.StatusCreated, loginFrom(t, s, "203.0.113.2", user.Email, user.password))
+
+	events, err := s.db.ListAuditEvents(context.Background(), database.ListAuditEventsParams{
+		UserID: sql.NullInt32{Int32: user.ID, Valid: true},
+		Type:   sql.NullString{String: audit.EventLogin, Valid: true},
+		Limit:  1,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "203.0.113.2", events[0].ClientIp)
+}
+
+func TestLoginIPFromUntrustedProxy(t *testing.T) {
+	s := newTestServer(t, behindProxy("10.0.0.1"))
+	user := newTestUser(t, s, role.User)
+
+	// The header is ignored, so changing it doesn't get around the lockout
+	require.Equal(t, http.StatusUnauthorized, loginFrom(t, s, "203.0.113.1", user.Email, "wrong password"))
+	require.Equal(t, http.StatusUnauthorized, loginFrom(t, s, "203.0.113.2", user.Email, "wrong password"))
+	require.Equal(t, http.StatusTooManyRequests, loginFrom(t, s, "203.0.113.3", user.Email, user.password))
+}
+
+func TestSecondFactorFailuresLockOut(t *testing.T) {
+	f := newFixture(t)
+	secret := f.enable2FA(t, f.user)
+	f.s.accountAttempts = lockout.NewMemoryTracker(lockout.Policy{
+		FreeAttempts: 2,
+		BaseDelay:    time.Hour,
+		MaxDelay:     time.Hour,
+		Window:       time.Hour,
+	})
+
+	loginWithCode := func(challengeToken, code string) int {
+		req := jsonRequest(t, http.MethodPost, "/users/login/2fa", "", loginUser2FARequest{ChallengeToken: challengeToken, Code: code})
+		return doRequest(t, f.s, req, nil).StatusCode
+	}
+
+	// The right password doesn't clear the failed codes of the earlier challenges
+	var challengeToken string
+	for i := 0; i < 3; i++ {
+		challengeToken = f.loginChallenge(t, f.user)
+		require.Equal(t, http.StatusUnauthorized, loginWithCode(challengeToken, "000000"))
+	}
+	require.Equal(t, http.StatusTooManyRequests, loginWithCode(challengeToken, f.totpCode(t, secret)))
+
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{
+		Email:    f.user.Email,
+		Password: f.user.password,
+	}), nil)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
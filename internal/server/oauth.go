@@ -0,0 +1,517 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/oauth"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// The flow is the authorization code grant with PKCE (RFC 6749, RFC 7636).
+// Flugo's frontend shows the consent screen: it passes the query of /oauth/authorize
+// to the API with the user's access token and navigates to the returned redirect_uri.
+// Every grant is a session, so the tokens work with the auth middleware and are revoked like any other session.
+
+// Scopes clients get if they don't ask for any
+var defaultOAuthScopes = []string{scope.ProfileRead}
+
+// Responds with the error in the format of RFC 6749 section 5.2
+func oauthErrorResponse(c *fiber.Ctx, status int, err *oauth.Error) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(err)
+}
+
+type authorizeRequest struct {
+	ResponseType        string `json:"response_type" query:"response_type"`
+	ClientID            string `json:"client_id" query:"client_id"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri"`
+	Scope               string `json:"scope" query:"scope"`
+	State               string `json:"state" query:"state"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method"`
+	// Only read by POST, it is the answer of the user on the consent screen
+	Approve bool `json:"approve" query:"-"`
+}
+
+// authorizeRedirect is the response of the authorization endpoint when the flow goes back to the client
+type authorizeRedirect struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// Checks the authorization request. Errors about the client and the redirect URI are returned as fiber errors,
+// as the user must not be sent to a URI the client hasn't registered. Other errors go back to the client
+// in the redirect URI, which is returned as the second value.
+func (s *Server) checkAuthorizeRequest(ctx context.Context, req *authorizeRequest) (database.OauthClient, []string, string, error) {
+	client, err := s.db.GetOAuthClient(ctx, req.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return client, nil, "", fiber.NewError(fiber.StatusBadRequest, "unknown oauth client")
+		}
+		return client, nil, "", fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// The redirect URI can be left out only if the client has registered just one
+	if req.RedirectURI == "" && len(client.RedirectUris) == 1 {
+		req.RedirectURI = client.RedirectUris[0]
+	}
+	if !scope.Contains(client.RedirectUris, req.RedirectURI) {
+		return client, nil, "", fiber.NewError(fiber.StatusBadRequest, "redirect uri is not registered by the client")
+	}
+
+	redirectError := func(code, description string) string {
+		params := url.Values{"error": {code}, "error_description": {description}}
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		return oauth.RedirectURL(req.RedirectURI, params)
+	}
+
+	if req.ResponseType != oauth.ResponseTypeCode {
+		return client, nil, redirectError(oauth.ErrUnsupportedResponseType, "only the code response type is supported"), nil
+	}
+	if req.CodeChallengeMethod != oauth.CodeChallengeS256 || len(req.CodeChallenge) != 43 {
+		return client, nil, redirectError(oauth.ErrInvalidRequest, "pkce with the S256 method is required"), nil
+	}
+
+	// Clients are never granted the privileged scopes of moderators and admins
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = defaultOAuthScopes
+	}
+	scopes, err = scope.Reduce(scopes, scope.ForRole(role.User))
+	if err != nil {
+		return client, nil, redirectError(oauth.ErrInvalidScope, err.Error()), nil
+	}
+
+	return client, scopes, "", nil
+}
+
+// GET REQUESTS
+
+type authorizeInfoResponse struct {
+	ClientID    string   `json:"client_id"`
+	ClientName  string   `json:"client_name"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+}
+
+// Checks the authorization request and returns what the consent screen shows
+func (s *Server) oauthAuthorizeInfo(c *fiber.Ctx) error {
+	req := new(authorizeRequest)
+	if err := c.QueryParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	client, scopes, redirect, err := s.checkAuthorizeRequest(c.Context(), req)
+	if err != nil {
+		return err
+	}
+	if redirect != "" {
+		return c.Status(fiber.StatusOK).JSON(authorizeRedirect{redirect})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(authorizeInfoResponse{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		RedirectURI: req.RedirectURI,
+		Scopes:      scopes,
+	})
+}
+
+// POST REQUESTS
+
+// Issues the authorization code if the user has approved the request
+func (s *Server) oauthAuthorize(c *fiber.Ctx) error {
+	req := new(authorizeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	client, scopes, redirect, err := s.checkAuthorizeRequest(c.Context(), req)
+	if err != nil {
+		return err
+	}
+	if redirect != "" {
+		return c.Status(fiber.StatusOK).JSON(authorizeRedirect{redirect})
+	}
+
+	params := url.Values{}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	if !req.Approve {
+		params.Set("error", oauth.ErrAccessDenied)
+		return c.Status(fiber.StatusOK).JSON(authorizeRedirect{oauth.RedirectURL(req.RedirectURI, params)})
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+	code, err := random.SecureString(32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	_, err = s.db.CreateOAuthCode(c.Context(), database.CreateOAuthCodeParams{
+		CodeHash:            password.HashToken(code),
+		ClientID:            client.ID,
+		UserID:              authPayload.UserID,
+		RedirectUri:         req.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           s.now().Add(s.config.OAuthCodeDuration),
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	params.Set("code", code)
+	return c.Status(fiber.StatusOK).JSON(authorizeRedirect{oauth.RedirectURL(req.RedirectURI, params)})
+}
+
+// Client credentials are read from HTTP Basic auth or from the form (RFC 6749 section 2.3.1)
+type oauthClientCredentials struct {
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// Authenticates the client of the token, introspection and revocation requests.
+// Public clients only send their id, confidential clients have to send the secret.
+func (s *Server) authenticateOAuthClient(c *fiber.Ctx, creds oauthClientCredentials) (database.OauthClient, *oauth.Error, error) {
+	if user, pass, ok := basicAuth(c); ok {
+		creds = oauthClientCredentials{user, pass}
+	}
+
+	client, err := s.db.GetOAuthClient(c.Context(), creds.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return client, oauth.NewError(oauth.ErrInvalidClient, "unknown client"), nil
+		}
+		return client, nil, err
+	}
+
+	if client.IsConfidential {
+		secretHash := password.HashToken(creds.ClientSecret)
+		if creds.ClientSecret == "" || subtle.ConstantTimeCompare([]byte(secretHash), []byte(client.SecretHash)) != 1 {
+			return client, oauth.NewError(oauth.ErrInvalidClient, "invalid client secret"), nil
+		}
+	} else if creds.ClientSecret != "" {
+		return client, oauth.NewError(oauth.ErrInvalidClient, "public clients have no secret"), nil
+	}
+	return client, nil, nil
+}
+
+// Parses the client id and secret from the Authorization header. Both are form-encoded (RFC 6749 section 2.3.1).
+func basicAuth(c *fiber.Ctx) (string, string, bool) {
+	fields := strings.Fields(c.Get(fiber.HeaderAuthorization))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", "", false
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", "", false
+	}
+	if user, err = url.QueryUnescape(user); err != nil {
+		return "", "", false
+	}
+	if pass, err = url.QueryUnescape(pass); err != nil {
+		return "", "", false
+	}
+	return user, pass, true
+}
+
+type oauthTokenRequest struct {
+	oauthClientCredentials
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	Scope        string `form:"scope"`
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+func (s *Server) oauthToken(c *fiber.Ctx) error {
+	req := new(oauthTokenRequest)
+	if err := c.BodyParser(req); err != nil {
+		return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrInvalidRequest, err.Error()))
+	}
+
+	client, oauthErr, err := s.authenticateOAuthClient(c, req.oauthClientCredentials)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if oauthErr != nil {
+		return oauthErrorResponse(c, fiber.StatusUnauthorized, oauthErr)
+	}
+
+	switch req.GrantType {
+	case oauth.GrantAuthorizationCode:
+		return s.oauthExchangeCode(c, req, client)
+	case oauth.GrantRefreshToken:
+		return s.oauthRefreshToken(c, req, client)
+	default:
+		return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrUnsupportedGrantType, req.GrantType))
+	}
+}
+
+func (s *Server) oauthExchangeCode(c *fiber.Ctx, req *oauthTokenRequest, client database.OauthClient) error {
+	invalidGrant := func(description string) error {
+		return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrInvalidGrant, description))
+	}
+
+	// The code is burned even if the exchange is rejected, so the transaction is committed
+	// with the reason of the rejection instead of being rolled back
+	codeHash := password.HashToken(req.Code)
+	var tokens *sessionTokens
+	var rejection string
+	err := s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		tokens, rejection = nil, ""
+
+		code, err := q.UseOAuthCode(c.Context(), codeHash)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case code.ClientID != client.ID:
+			rejection = "authorization code was issued to another client"
+		case s.now().After(code.ExpiresAt):
+			rejection = "authorization code has expired"
+		case code.RedirectUri != req.RedirectURI:
+			rejection = "redirect uri doesn't match the authorization request"
+		case !oauth.VerifyPKCE(req.CodeVerifier, code.CodeChallenge, code.CodeChallengeMethod):
+			rejection = "code verifier doesn't match the code challenge"
+		}
+		if rejection != "" {
+			return nil
+		}
+
+		user, err := q.GetUserByID(c.Context(), code.UserID)
+		if err != nil {
+			return err
+		}
+		if user.IsBanned {
+			rejection = "user is banned"
+			return nil
+		}
+
+		tokens, err = s.createSession(c, q, user, code.Scopes, sql.NullString{String: client.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		return q.SetOAuthCodeSession(c.Context(), database.SetOAuthCodeSessionParams{
+			CodeHash:  codeHash,
+			SessionID: uuid.NullUUID{UUID: tokens.session.ID, Valid: true},
+		})
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		// A code used twice could have been stolen, so the session started with it is revoked (RFC 6749 section 4.1.2)
+		usedCode, err := s.db.GetOAuthCode(c.Context(), codeHash)
+		if err == nil && usedCode.SessionID.Valid {
+			if err := s.db.BlockSession(c.Context(), usedCode.SessionID.UUID); err != nil {
+				return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+			}
+		}
+		return invalidGrant("authorization code is invalid or used")
+	}
+	if rejection != "" {
+		return invalidGrant(rejection)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(oauthTokenResponse{
+		AccessToken:  tokens.accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.config.AccessTokenDuration.Seconds()),
+		RefreshToken: tokens.refreshToken,
+		Scope:        oauth.FormatScope(tokens.accessPayload.Scopes),
+	})
+}
+
+// Issues a new access token. The scope can be narrowed down, but not widened (RFC 6749 section 6).
+func (s *Server) oauthRefreshToken(c *fiber.Ctx, req *oauthTokenRequest, client database.OauthClient) error {
+	invalidGrant := func(description string) error {
+		return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrInvalidGrant, description))
+	}
+
+	session, _, err := s.oauthSession(c.Context(), req.RefreshToken, client)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if session == nil || session.RefreshToken != req.RefreshToken {
+		return invalidGrant("refresh token is invalid, revoked or expired")
+	}
+
+	user, err := s.db.GetUserByID(c.Context(), session.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if user.IsBanned {
+		return invalidGrant("user is banned")
+	}
+
+	scopes := scope.Granted(session.Scopes, user.Role)
+	if requested := oauth.ParseScope(req.Scope); len(requested) > 0 {
+		scopes, err = scope.Reduce(requested, scopes)
+		if err != nil {
+			return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrInvalidScope, err.Error()))
+		}
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.ID, user.Username, user.Email, user.Role, scopes, session.ID, s.config.AccessTokenDuration)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).JSON(oauthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.config.AccessTokenDuration.Seconds()),
+		Scope:       oauth.FormatScope(accessPayload.Scopes),
+	})
+}
+
+// Returns the active session of an access or a refresh token issued to the client, or nil if there is none
+func (s *Server) oauthSession(ctx context.Context, tokenString string, client database.OauthClient) (*database.Session, *token.Payload, error) {
+	payload, err := s.tokenMaker.VerifyToken(tokenString)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	// Access tokens carry the id of their session, refresh tokens are the session's id themselves
+	sessionID := payload.SessionID
+	if sessionID == uuid.Nil {
+		sessionID = payload.ID
+	}
+	session, err := s.db.GetSession(ctx, sessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	if payload.SessionID == uuid.Nil && session.RefreshToken != tokenString {
+		return nil, nil, nil
+	}
+	if session.ClientID.String != client.ID || session.IsBlocked || s.now().After(session.ExpiresAt) {
+		return nil, nil, nil
+	}
+	return &session, payload, nil
+}
+
+type oauthIntrospectRequest struct {
+	oauthClientCredentials
+	Token string `form:"token"`
+}
+
+// Fields of RFC 7662 section 2.2. Inactive tokens only have the active field.
+type oauthIntrospectResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+}
+
+// Clients can only introspect the tokens issued to them, the others are reported as inactive
+func (s *Server) oauthIntrospect(c *fiber.Ctx) error {
+	req := new(oauthIntrospectRequest)
+	if err := c.BodyParser(req); err != nil {
+		return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrInvalidRequest, err.Error()))
+	}
+
+	client, oauthErr, err := s.authenticateOAuthClient(c, req.oauthClientCredentials)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if oauthErr != nil {
+		return oauthErrorResponse(c, fiber.StatusUnauthorized, oauthErr)
+	}
+
+	session, payload, err := s.oauthSession(c.Context(), req.Token, client)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if session == nil {
+		return c.Status(fiber.StatusOK).JSON(oauthIntrospectResponse{Active: false})
+	}
+
+	tokenType := "Bearer"
+	if payload.SessionID == uuid.Nil {
+		tokenType = "refresh_token"
+	}
+	return c.Status(fiber.StatusOK).JSON(oauthIntrospectResponse{
+		Active:    true,
+		Scope:     oauth.FormatScope(payload.Scopes),
+		ClientID:  client.ID,
+		Username:  payload.Username,
+		TokenType: tokenType,
+		Exp:       payload.ExpiredAt.Unix(),
+		Iat:       payload.IssuedAt.Unix(),
+		Sub:       strconv.Itoa(int(payload.UserID)),
+	})
+}
+
+type oauthRevokeRequest struct {
+	oauthClientCredentials
+	Token string `form:"token"`
+}
+
+// Revokes the session of the token. It responds with 200 even for unknown tokens (RFC 7009 section 2.2).
+func (s *Server) oauthRevoke(c *fiber.Ctx) error {
+	req := new(oauthRevokeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return oauthErrorResponse(c, fiber.StatusBadRequest, oauth.NewError(oauth.ErrInvalidRequest, err.Error()))
+	}
+
+	client, oauthErr, err := s.authenticateOAuthClient(c, req.oauthClientCredentials)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if oauthErr != nil {
+		return oauthErrorResponse(c, fiber.StatusUnauthorized, oauthErr)
+	}
+
+	session, _, err := s.oauthSession(c.Context(), req.Token, client)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if session != nil {
+		if err := s.db.BlockSession(c.Context(), session.ID); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/oauth"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// oauthClientResponse type is returned back with response. It omits the hash of the secret.
+type oauthClientResponse struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	RedirectURIs   []string  `json:"redirect_uris"`
+	IsConfidential bool      `json:"is_confidential"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Returns new oauthClientResponse from default oauth client type
+func newOAuthClientResponse(client database.OauthClient) oauthClientResponse {
+	return oauthClientResponse{
+		client.ID,
+		client.Name,
+		client.RedirectUris,
+		client.IsConfidential,
+		client.CreatedAt,
+	}
+}
+
+// POST REQUESTS
+
+type createOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=64"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,max=10"`
+	// Confidential clients run on a server and get a secret. Public clients, like mobile apps, can't keep one.
+	Confidential bool `json:"confidential"`
+}
+
+type createOAuthClientResponse struct {
+	// The secret is shown only once, only its hash is stored
+	ClientSecret string              `json:"client_secret,omitempty"`
+	Client       oauthClientResponse `json:"client"`
+}
+
+func (s *Server) createOAuthClient(c *fiber.Ctx) error {
+	req := new(createOAuthClientRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	for _, uri := range req.RedirectURIs {
+		if !oauth.ValidRedirectURI(uri) {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid redirect uri: "+uri)
+		}
+	}
+
+	clientID, err := random.SecureString(16)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	var secret, secretHash string
+	if req.Confidential {
+		secret, err = random.SecureString(32)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		secretHash = password.HashToken(secret)
+	}
+
+	client, err := s.db.CreateOAuthClient(c.Context(), database.CreateOAuthClientParams{
+		ID:             clientID,
+		OwnerID:        c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID,
+		Name:           req.Name,
+		RedirectUris:   req.RedirectURIs,
+		IsConfidential: req.Confidential,
+		SecretHash:     secretHash,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(createOAuthClientResponse{
+		ClientSecret: secret,
+		Client:       newOAuthClientResponse(client),
+	})
+}
+
+// GET REQUESTS
+
+func (s *Server) listMyOAuthClients(c *fiber.Ctx) error {
+	clients, err := s.db.ListOAuthClientsByOwner(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	clientsResponse := make([]oauthClientResponse, 0)
+	for _, client := range clients {
+		clientsResponse = append(clientsResponse, newOAuthClientResponse(client))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(clientsResponse)
+}
+
+// DELETE REQUESTS
+
+// Deleting the client also ends all the sessions it has started
+func (s *Server) deleteMyOAuthClient(c *fiber.Ctx) error {
+	rows, err := s.db.DeleteOwnedOAuthClient(c.Context(), database.DeleteOwnedOAuthClientParams{
+		ID:      c.Params("id"),
+		OwnerID: c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if rows == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "oauth client not found")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
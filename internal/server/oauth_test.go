@@ -0,0 +1,221 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/utils/oauth"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	s := newTestServer(t)
+	user, accessToken := createTestUser(t, s)
+	redirectURI := "com.example.app:/callback"
+
+	// The user registers a public client, like a mobile app
+	var client createOAuthClientResponse
+	resp := doRequest(t, s, jsonRequest(t, http.MethodPost, "/oauth/clients", accessToken, createOAuthClientRequest{
+		Name:         "Flugo Mobile",
+		RedirectURIs: []string{redirectURI},
+	}), &client)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, client.ClientSecret)
+	clientID := client.Client.ID
+
+	verifier, err := random.SecureString(32)
+	require.NoError(t, err)
+	authorize := authorizeRequest{
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            clientID,
+		RedirectURI:         redirectURI,
+		Scope:               "profile:read jokes:write",
+		State:               "xyz",
+		CodeChallenge:       oauth.S256Challenge(verifier),
+		CodeChallengeMethod: oauth.CodeChallengeS256,
+	}
+
+	// The consent screen
+	query := url.Values{
+		"response_type":         {authorize.ResponseType},
+		"client_id":             {authorize.ClientID},
+		"redirect_uri":          {authorize.RedirectURI},
+		"scope":                 {authorize.Scope},
+		"state":                 {authorize.State},
+		"code_challenge":        {authorize.CodeChallenge},
+		"code_challenge_method": {authorize.CodeChallengeMethod},
+	}
+	var info authorizeInfoResponse
+	resp = doRequest(t, s, jsonRequest(t, http.MethodGet, "/oauth/authorize?"+query.Encode(), accessToken, nil), &info)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Flugo Mobile", info.ClientName)
+	require.Equal(t, []string{"profile:read", "jokes:write"}, info.Scopes)
+
+	// Unregistered redirect URIs are never redirected to
+	badAuthorize := authorize
+	badAuthorize.RedirectURI = "https://evil.example.com/callback"
+	badAuthorize.Approve = true
+	resp = doRequest(t, s, jsonRequest(t, http.MethodPost, "/oauth/authorize", accessToken, badAuthorize), nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// The user approves
+	authorize.Approve = true
+	var redirect authorizeRedirect
+	resp = doRequest(t, s, jsonRequest(t, http.MethodPost, "/oauth/authorize", accessToken, authorize), &redirect)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	callback, err := url.Parse(redirect.RedirectURI)
+	require.NoError(t, err)
+	require.Equal(t, "xyz", callback.Query().Get("state"))
+	code := callback.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	exchange := url.Values{
+		"grant_type":    {oauth.GrantAuthorizationCode},
+		"client_id":     {clientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {"wrong-verifier-wrong-verifier-wrong-verifier"},
+	}
+
+	// The code is burned by a wrong verifier, as if an attacker has tried it
+	var oauthErr oauth.Error
+	resp = doRequest(t, s, formRequest("/oauth/token", exchange), &oauthErr)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, oauth.ErrInvalidGrant, oauthErr.Code)
+
+	// So the flow starts again
+	resp = doRequest(t, s, jsonRequest(t, http.MethodPost, "/oauth/authorize", accessToken, authorize), &redirect)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	callback, err = url.Parse(redirect.RedirectURI)
+	require.NoError(t, err)
+	exchange.Set("code", callback.Query().Get("code"))
+	exchange.Set("code_verifier", verifier)
+
+	var tokens oauthTokenResponse
+	resp = doRequest(t, s, formRequest("/oauth/token", exchange), &tokens)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Bearer", tokens.TokenType)
+	require.Equal(t, "profile:read jokes:write", tokens.Scope)
+	require.NotEmpty(t, tokens.RefreshToken)
+
+	// The token works with the API, within its scopes
+	var me userResponse
+	resp = doRequest(t, s, jsonRequest(t, http.MethodGet, "/users/me", tokens.AccessToken, nil), &me)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, user.Username, me.Username)
+
+	resp = doRequest(t, s, jsonRequest(t, http.MethodPut, "/users/fullname", tokens.AccessToken, map[string]string{"fullname": "Mallory"}), nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Introspection
+	var introspection oauthIntrospectResponse
+	resp = doRequest(t, s, formRequest("/oauth/introspect", url.Values{
+		"client_id": {clientID},
+		"token":     {tokens.AccessToken},
+	}), &introspection)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, introspection.Active)
+	require.Equal(t, clientID, introspection.ClientID)
+	require.Equal(t, user.Username, introspection.Username)
+
+	// Refresh with a narrower scope
+	var refreshed oauthTokenResponse
+	resp = doRequest(t, s, formRequest("/oauth/token", url.Values{
+		"grant_type":    {oauth.GrantRefreshToken},
+		"client_id":     {clientID},
+		"refresh_token": {tokens.RefreshToken},
+		"scope":         {"profile:read"},
+	}), &refreshed)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "profile:read", refreshed.Scope)
+
+	// But not a wider one
+	resp = doRequest(t, s, formRequest("/oauth/token", url.Values{
+		"grant_type":    {oauth.GrantRefreshToken},
+		"client_id":     {clientID},
+		"refresh_token": {tokens.RefreshToken},
+		"scope":         {"profile:write"},
+	}), &oauthErr)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, oauth.ErrInvalidScope, oauthErr.Code)
+
+	// Refresh tokens of clients can't be renewed as the user's own
+	resp = doRequest(t, s, jsonRequest(t, http.MethodPost, "/tokens/renew", "", map[string]string{"refresh_token": tokens.RefreshToken}), nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Revocation ends the session, so all of its tokens stop working
+	resp = doRequest(t, s, formRequest("/oauth/revoke", url.Values{
+		"client_id": {clientID},
+		"token":     {tokens.RefreshToken},
+	}), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doRequest(t, s, formRequest("/oauth/introspect", url.Values{
+		"client_id": {clientID},
+		"token":     {refreshed.AccessToken},
+	}), &introspection)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.False(t, introspection.Active)
+
+	resp = doRequest(t, s, jsonRequest(t, http.MethodGet, "/users/me", refreshed.AccessToken, nil), nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestOAuthCodeReuseRevokesSession(t *testing.T) {
+	s := newTestServer(t)
+	_, accessToken := createTestUser(t, s)
+	redirectURI := "https://example.com/callback"
+
+	var client createOAuthClientResponse
+	resp := doRequest(t, s, jsonRequest(t, http.MethodPost, "/oauth/clients", accessToken, createOAuthClientRequest{
+		Name:         "Partner",
+		RedirectURIs: []string{redirectURI},
+		Confidential: true,
+	}), &client)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, client.ClientSecret)
+
+	verifier, err := random.SecureString(32)
+	require.NoError(t, err)
+	var redirect authorizeRedirect
+	resp = doRequest(t, s, jsonRequest(t, http.MethodPost, "/oauth/authorize", accessToken, authorizeRequest{
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            client.Client.ID,
+		CodeChallenge:       oauth.S256Challenge(verifier),
+		CodeChallengeMethod: oauth.CodeChallengeS256,
+		Approve:             true,
+	}), &redirect)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	callback, err := url.Parse(redirect.RedirectURI)
+	require.NoError(t, err)
+
+	exchange := url.Values{
+		"grant_type":    {oauth.GrantAuthorizationCode},
+		"code":          {callback.Query().Get("code")},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+
+	// Confidential clients must authenticate
+	req := formRequest("/oauth/token", exchange)
+	req.SetBasicAuth(client.Client.ID, "wrong secret")
+	resp = doRequest(t, s, req, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var tokens oauthTokenResponse
+	req = formRequest("/oauth/token", exchange)
+	req.SetBasicAuth(client.Client.ID, client.ClientSecret)
+	resp = doRequest(t, s, req, &tokens)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The second exchange of the code fails and revokes the tokens of the first one
+	req = formRequest("/oauth/token", exchange)
+	req.SetBasicAuth(client.Client.ID, client.ClientSecret)
+	resp = doRequest(t, s, req, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = doRequest(t, s, jsonRequest(t, http.MethodGet, "/users/me", tokens.AccessToken, nil), nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
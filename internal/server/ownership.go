@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ownerLookup returns the id of the user who owns the resource with the given id.
+// It must return sql.ErrNoRows if the resource doesn't exist.
+type ownerLookup func(ctx context.Context, id int32) (int32, error)
+
+// ownershipError is called after an author-scoped query matched no rows.
+// The ownership check itself is done atomically by the query,
+// so this only finds out which error the caller should get:
+// 404 if the resource doesn't exist and 403 if it belongs to another user.
+func ownershipError(ctx context.Context, resource string, id int32, payload *token.Payload, lookup ownerLookup) error {
+	owner, err := lookup(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("%s with id %d not found", resource, id))
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if owner != payload.UserID {
+		return fiber.NewError(fiber.StatusForbidden, fmt.Sprintf("%s with id %d belongs to another user", resource, id))
+	}
+	// The resource was changed between the scoped query and the lookup
+	return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("%s with id %d was modified concurrently", resource, id))
+}
+
+// jokeOwner is an ownerLookup for jokes
+func (s *Server) jokeOwner(ctx context.Context, id int32) (int32, error) {
+	joke, err := s.db.GetJoke(ctx, id)
+	return joke.AuthorID, err
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/gofiber/fiber/v2"
+)
+
+const passwordResetSubject = "Reset your Flugo password"
+
+// Creates a single-use reset token for the user and emails it. Only the hash of the token is stored.
+func (s *Server) sendPasswordResetEmail(ctx context.Context, user database.User) error {
+	resetToken, err := random.SecureString(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.CreatePasswordReset(ctx, database.CreatePasswordResetParams{
+		UserID:    user.ID,
+		TokenHash: password.HashToken(resetToken),
+		ExpiredAt: time.Now().Add(s.config.PasswordResetDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf(
+		"Hello %s,\n\nSomebody asked to reset the password of your Flugo account.\nUse this token to set a new password:\n%s\n\nThe token expires in %s. If it wasn't you, just ignore this email.",
+		user.Username, resetToken, s.config.PasswordResetDuration,
+	)
+
+	return s.mailer.SendEmail([]string{user.Email}, passwordResetSubject, content)
+}
+
+// POST REQUESTS
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// Always responds with 202, so it can't be used to find out which emails are registered
+func (s *Server) forgotPassword(c *fiber.Ctx) error {
+	req := new(forgotPasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	user, err := s.db.GetUserByEmail(c.Context(), req.Email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("password reset: cannot get user: %v", err)
+		}
+		return c.SendStatus(fiber.StatusAccepted)
+	}
+
+	// The email is sent in the background, so the response time doesn't depend on whether the user exists
+	go func() {
+		if err := s.sendPasswordResetEmail(context.Background(), user); err != nil {
+			log.Printf("password reset: cannot send email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+func (s *Server) resetPassword(c *fiber.Ctx) error {
+	req := new(resetPasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	tokenHash := password.HashToken(req.Token)
+
+	// The password is checked before the token is used, so a weak password doesn't burn the token
+	resetUser, err := s.db.GetUserByPasswordReset(c.Context(), tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusBadRequest, "reset token is invalid, used or expired")
+		}
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if err := s.policy.Check(req.NewPassword, resetUser.Username, resetUser.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	// The token is burned only along with the password change
+	var user database.User
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		reset, err := q.UsePasswordReset(c.Context(), tokenHash)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fiber.NewError(fiber.StatusBadRequest, "reset token is invalid, used or expired")
+			}
+			return err
+		}
+
+		user, err = q.UpdateUserPassword(c.Context(), database.UpdateUserPasswordParams{
+			ID:             reset.UserID,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Other reset tokens of the user are burned too
+		err = q.UseUserPasswordResets(c.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+		// Every access token is bound to a session, so blocking all sessions of the user
+		// invalidates all the tokens issued before the reset
+		return q.BlockUserSessions(c.Context(), user.ID)
+	})
+	if err != nil {
+		return txError(err)
+	}
+	audit.Record(c, s.db, audit.EventPasswordReset, audit.OutcomeSuccess, user.ID, user.ID, "")
+
+	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/search"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page size of the search results if it isn't given, and the largest one allowed
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+// Longest search query allowed, in bytes
+const maxSearchQueryLength = 256
+
+// Reads the search query and the page of the results from the query parameters
+func searchParams(c *fiber.Ctx) (q string, first, size int32, err error) {
+	q = strings.TrimSpace(c.Query("q"))
+	if q == "" || len(q) > maxSearchQueryLength {
+		return "", 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong q")
+	}
+	f, err := strconv.Atoi(c.Query("first", "0"))
+	if err != nil || f < 0 {
+		return "", 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong first")
+	}
+	n, err := strconv.Atoi(c.Query("size", strconv.Itoa(defaultSearchPageSize)))
+	if err != nil || n < 1 || n > maxSearchPageSize {
+		return "", 0, 0, fiber.NewError(http.StatusBadRequest, "Provided wrong size")
+	}
+	return q, int32(f), int32(n), nil
+}
+
+// GET REQUESTS
+
+// Searches the jokes in the language by their title, text and explanation, the best matches first.
+// The words of the query are stemmed with the language, so "running" finds "runs" in English.
+// Words in double quotes are searched as a phrase and a word ending with * as a prefix.
+// The snippets are HTML with the matches in <b> and </b>, the rest of the text is escaped.
+func (s *Server) searchJokes(c *fiber.Ctx) error {
+	q, first, size, err := searchParams(c)
+	if err != nil {
+		return err
+	}
+	query := search.Parse(q)
+	if len(query) == 0 {
+		return fiber.NewError(http.StatusBadRequest, "search query has no words")
+	}
+	language := c.Query("language", search.DefaultLanguage)
+	if !search.IsLanguage(language) {
+		return fiber.NewError(http.StatusBadRequest, "unknown language")
+	}
+
+	jokes, err := s.db.SearchJokes(c.Context(), database.SearchJokesParams{
+		Language: language,
+		Query:    query.TSQuery(),
+		Limit:    size,
+		Offset:   first,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if jokes == nil {
+		jokes = make([]database.SearchJokesRow, 0)
+	}
+	for i := range jokes {
+		jokes[i].TitleSnippet = search.HighlightHTML(jokes[i].TitleSnippet)
+		jokes[i].TextSnippet = search.HighlightHTML(jokes[i].TextSnippet)
+	}
+	return c.Status(fiber.StatusOK).JSON(jokes)
+}
+
+// Searches the users by their username and full name, the most alike first.
+// Small typos are forgiven, and the user with the exact username comes first. Banned users aren't found.
+func (s *Server) searchUsers(c *fiber.Ctx) error {
+	q, first, size, err := searchParams(c)
+	if err != nil {
+		return err
+	}
+
+	users, err := s.db.SearchUsers(c.Context(), database.SearchUsersParams{
+		Query:  q,
+		Limit:  size,
+		Offset: first,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if users == nil {
+		users = make([]database.SearchUsersRow, 0)
+	}
+	return c.Status(fiber.StatusOK).JSON(users)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/search"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchJokes(t *testing.T) {
+	f := newFixture(t)
+
+	createJoke := func(req createJokeRequest) database.JokesWithAuthor {
+		var joke database.JokesWithAuthor
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, req), &joke)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		return joke
+	}
+	inText := createJoke(createJokeRequest{Title: "Bar", Text: "A priest walks into a bar with a penguin"})
+	inTitle := createJoke(createJokeRequest{Title: "The penguin", Text: "Knock knock"})
+	inExplanation := createJoke(createJokeRequest{Title: "Ice", Text: "Why so cold?", Explanation: "Penguin jokes are like that"})
+	french := createJoke(createJokeRequest{Title: "Le penguin", Text: "Toc toc", Language: search.French})
+	require.Equal(t, search.English, inText.Language)
+	require.Equal(t, search.French, french.Language)
+
+	searchJokes := func(query string) []database.SearchJokesRow {
+		var jokes []database.SearchJokesRow
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, "/search/jokes?"+query, "", nil), &jokes)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return jokes
+	}
+	ids := func(jokes []database.SearchJokesRow) []int32 {
+		ids := make([]int32, 0, len(jokes))
+		for _, joke := range jokes {
+			ids = append(ids, joke.ID)
+		}
+		return ids
+	}
+
+	// Matches in the title rank above the ones in the text and those above the ones in the explanation.
+	// Only the jokes of the language are searched.
+	jokes := searchJokes("q=penguin")
+	require.Equal(t, []int32{inTitle.ID, inText.ID, inExplanation.ID}, ids(jokes))
+	require.Greater(t, jokes[0].Rank, jokes[1].Rank)
+	require.Equal(t, "The <b>penguin</b>", jokes[0].TitleSnippet)
+	require.Contains(t, jokes[1].TextSnippet, "<b>penguin</b>")
+
+	require.Equal(t, []int32{inTitle.ID, inText.ID, inExplanation.ID}, ids(searchJokes("q=pengu*")))
+	require.Equal(t, []int32{inText.ID}, ids(searchJokes("q=pengu*+priest")))
+	require.Equal(t, []int32{inText.ID}, ids(searchJokes("q="+url.QueryEscape(`"walks into" penguin`))))
+	require.Empty(t, searchJokes("q="+url.QueryEscape(`"into walks"`)))
+	require.Equal(t, []int32{french.ID}, ids(searchJokes("language=french&q=toc")))
+	require.Equal(t, []int32{inText.ID}, ids(searchJokes("q=penguin&first=1&size=1")))
+
+	// Only the highlights are markup in the snippets
+	script := createJoke(createJokeRequest{
+		Title:       "<script>alert('penguin')</script>",
+		Text:        `<img src=x onerror="alert(1)"> penguin`,
+		Explanation: "\uE000penguin",
+	})
+	jokes = searchJokes("q=onerror")
+	require.Equal(t, []int32{script.ID}, ids(jokes))
+	require.Equal(t, "&lt;script&gt;alert(&#39;penguin&#39;)&lt;/script&gt;", jokes[0].TitleSnippet)
+	require.Equal(t, `&lt;img src=x <b>onerror</b>=&#34;alert(1)&#34;&gt; penguin penguin`, jokes[0].TextSnippet)
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d", script.ID), f.user.login.AccessToken, nil), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Changing the language moves the joke to the search of the other language
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/language/%d", inTitle.ID), f.user.login.AccessToken, updateJokeLanguageRequest{search.French}), nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, []int32{inTitle.ID, french.ID}, ids(searchJokes("language=french&q=penguin")))
+}
+
+func TestSearchUsers(t *testing.T) {
+	f := newFixture(t)
+
+	createUser := func(username, fullname string) database.User {
+		user, err := f.s.db.CreateUser(context.Background(), database.CreateUserParams{
+			Username:       username,
+			Email:          random.RandomEmail(),
+			HashedPassword: random.RandomString(60),
+			Fullname:       fullname,
+		})
+		require.NoError(t, err)
+		return user
+	}
+	jonathan := createUser("jonathan", "Jonathan Smith")
+	jon := createUser("jon", "Jonathan Reed")
+	banned := createUser("banned_jonathan", "Jonathan Banned")
+	_, err := f.s.db.UpdateUserBanned(context.Background(), database.UpdateUserBannedParams{
+		ID:       banned.ID,
+		IsBanned: true,
+	})
+	require.NoError(t, err)
+
+	searchUsers := func(q string) []int32 {
+		var users []database.SearchUsersRow
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, "/search/users?q="+url.QueryEscape(q), "", nil), &users)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		ids := make([]int32, 0, len(users))
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		return ids
+	}
+
+	// The exact username comes first, banned users aren't found
+	require.Equal(t, []int32{jon.ID, jonathan.ID}, searchUsers("jon"))
+	require.Equal(t, []int32{jon.ID, jonathan.ID}, searchUsers("JON"))
+	// Typos are forgiven, and the full names are searched too
+	require.Equal(t, []int32{jonathan.ID, jon.ID}, searchUsers("jonathon"))
+	require.Equal(t, []int32{jonathan.ID}, searchUsers("smith"))
+	require.Equal(t, []int32{jon.ID}, searchUsers("reed"))
+	require.Empty(t, searchUsers("xyz"))
+
+	// Deactivated users aren't found until they log in again
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPut, "/users/deactivate", f.user.login.AccessToken, deactivateUserRequest{f.user.password}), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Empty(t, searchUsers(f.user.Username))
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: f.user.Email, Password: f.user.password}), nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, []int32{f.user.ID}, searchUsers(f.user.Username))
+}
@@ -1,44 +1,55 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/abc_valera/flugo/internal/database"
 	cnfg "github.com/abc_valera/flugo/internal/utils/config"
+	"github.com/abc_valera/flugo/internal/utils/lockout"
+	"github.com/abc_valera/flugo/internal/utils/mail"
 	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/abc_valera/flugo/internal/utils/scope"
 	"github.com/abc_valera/flugo/internal/utils/token"
 	v "github.com/abc_valera/flugo/internal/utils/validator"
-	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
 type Server struct {
 	app *fiber.App
 
 	config     cnfg.Config
-	db         *database.Queries
+	db         database.Store
 	tokenMaker token.Maker
-	validator  v.CustomValidator
+	hasher     password.Hasher
+	policy     *password.Policy
+	// Hash checked for unknown emails, so the login takes as long as for the registered ones
+	dummyHash string
+	// Failed logins per email and per IP
+	accountAttempts lockout.Tracker
+	ipAttempts      lockout.Tracker
+	mailer          mail.Mailer
+	validator       v.CustomValidator
+	// Returns the current time. Time-based one-time codes are checked against it.
+	now func() time.Time
 }
 
 func NewServer() (*Server, error) {
-	s := new(Server)
-
 	// init config
 	c, err := cnfg.LoadConfig(".")
 	if err != nil {
 		return nil, err
 	}
-	s.config = c
 
 	// init database
-	conn, err := sql.Open(s.config.DatabaseDriver, s.config.DatabaseUrl)
+	conn, err := sql.Open(c.DatabaseDriver, c.DatabaseUrl)
 	if err != nil {
 		log.Fatal("cannot connect to db: ", err)
 	}
@@ -46,28 +57,60 @@ func NewServer() (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	s.db = database.New(conn)
 
 	// init migrations
-	m, err := migrate.New("file://internal/database/migrations", s.config.DatabaseUrl)
-	if err != nil {
-		return nil, err
+	if c.AutoMigrate {
+		err = migrateUp(c.DatabaseUrl)
+		if err != nil {
+			return nil, err
+		}
 	}
-	// ! MIGRATION DOWN FOR TEST PURPOSES !
-	err = m.Down()
+
+	return newServer(c, database.NewStore(conn))
+}
+
+// Applies the pending migrations. Instances started at once wait for the one that migrates.
+func migrateUp(databaseURL string) error {
+	migrator, err := database.NewMigrator(databaseURL)
 	if err != nil {
-		log.Println(err)
+		return err
 	}
-	err = m.Up()
+	defer migrator.Close()
+	return migrator.Up()
+}
+
+// newServer sets up the server on top of the migrated database. The routes are added by initRouter.
+func newServer(config cnfg.Config, db database.Store) (*Server, error) {
+	s := new(Server)
+	s.config = config
+	s.db = db
+	s.now = time.Now
+
+	// init first admin
+	err := s.bootstrapAdmin(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	//!!!
-	m.Close()
+
+	// The client IP is read from the proxy header only on requests coming from the trusted proxies
+	if s.config.ProxyHeader != "" && len(s.config.TrustedProxies) == 0 {
+		return nil, errors.New("TRUSTED_PROXIES must be set along with PROXY_HEADER")
+	}
 
 	// init fiber app with custom error handler
 	s.app = fiber.New(fiber.Config{
+		ProxyHeader:             s.config.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          s.config.TrustedProxies,
+		// A missing or invalid header falls back to the address of the connection
+		EnableIPValidation: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			if e, ok := err.(*password.PolicyError); ok {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"message":    e.Error(),
+					"violations": e.Violations,
+				})
+			}
 			if e, ok := err.(*fiber.Error); ok {
 				return c.Status(e.Code).JSON(fiber.Map{
 					"message": e.Message,
@@ -81,11 +124,45 @@ func NewServer() (*Server, error) {
 	})
 
 	// init tokenMaker
-	s.tokenMaker, err = token.NewJWTMaker(s.config.TokenSymmetricKey)
+	s.tokenMaker, err = newTokenMaker(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	// init password hasher
+	s.hasher, err = newPasswordHasher(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	s.dummyHash, err = s.hasher.Hash("dummy password")
+	if err != nil {
+		return nil, err
+	}
+
+	// init login attempt trackers
+	s.accountAttempts, s.ipAttempts, err = newLoginTrackers(s.config, s.db)
 	if err != nil {
 		return nil, err
 	}
 
+	// init password policy
+	s.policy, err = newPasswordPolicy(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	// init mailer
+	if s.config.SMTPHost != "" {
+		s.mailer, err = mail.NewSMTPMailer(s.config.SMTPHost, s.config.SMTPPort, s.config.SMTPUsername, s.config.SMTPPassword, s.config.EmailSenderAddress)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		log.Println("SMTP_HOST is not set, emails will be kept in memory instead of being sent")
+		s.mailer = mail.NewFakeMailer()
+	}
+
 	// init custom logger
 	s.app.Use(logger.New(logger.Config{
 		Format:     "${time} |${status}-${method}| ${path}\n",
@@ -94,50 +171,197 @@ func NewServer() (*Server, error) {
 	}))
 
 	// init custom validator
-	s.validator = &v.FlugoValidator{
-		Validator: validator.New(),
-	}
+	s.validator = v.NewFlugoValidator()
 
 	return s, nil
 }
 
+// Returns the token.Maker selected in the config
+func newTokenMaker(c cnfg.Config) (token.Maker, error) {
+	switch c.TokenMaker {
+	case token.MakerJWT, "":
+		return token.NewJWTMaker(c.TokenSymmetricKey)
+	case token.MakerJWTAsymmetric:
+		keyring, err := token.LoadKeyring(c.TokenPrivateKeyFile, c.TokenPublicKeyFiles...)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewAsymmetricJWTMaker(keyring)
+	case token.MakerPasetoLocal:
+		return token.NewPasetoLocalMaker(c.TokenSymmetricKey)
+	case token.MakerPasetoPublic:
+		return token.NewPasetoPublicMaker(c.TokenAsymmetricKey)
+	default:
+		return nil, fmt.Errorf("unknown token maker: %s", c.TokenMaker)
+	}
+}
+
+// Returns the password.Hasher selected in the config.
+// Hashes of the other algorithm are still accepted and replaced on login.
+func newPasswordHasher(c cnfg.Config) (password.Hasher, error) {
+	params := password.Argon2idParams{
+		Memory:      c.Argon2Memory,
+		Iterations:  c.Argon2Iterations,
+		Parallelism: c.Argon2Parallelism,
+	}
+	argon2id := password.NewArgon2idHasher(params)
+	bcrypt := password.NewBcryptHasher(c.BcryptCost)
+
+	switch c.PasswordHasher {
+	case password.HasherArgon2id, "":
+		if err := params.Validate(); err != nil {
+			return nil, err
+		}
+		return password.NewMultiHasher(argon2id, bcrypt), nil
+	case password.HasherBcrypt:
+		return password.NewMultiHasher(bcrypt, argon2id), nil
+	default:
+		return nil, fmt.Errorf("unknown password hasher: %s", c.PasswordHasher)
+	}
+}
+
+// Returns the trackers of failed logins per email and per IP selected in the config
+func newLoginTrackers(c cnfg.Config, db database.Store) (lockout.Tracker, lockout.Tracker, error) {
+	accountPolicy := lockout.Policy{
+		FreeAttempts: c.LoginFreeAttempts,
+		BaseDelay:    c.LoginLockoutBaseDelay,
+		MaxDelay:     c.LoginLockoutMaxDelay,
+		Window:       c.LoginAttemptsWindow,
+	}
+	ipPolicy := accountPolicy
+	ipPolicy.FreeAttempts = c.LoginIPFreeAttempts
+
+	switch c.LoginTracker {
+	case lockout.TrackerMemory, "":
+		return lockout.NewMemoryTracker(accountPolicy), lockout.NewMemoryTracker(ipPolicy), nil
+	case lockout.TrackerPostgres:
+		return lockout.NewPostgresTracker(db, accountPolicy), lockout.NewPostgresTracker(db, ipPolicy), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown login tracker: %s", c.LoginTracker)
+	}
+}
+
+// Returns the password.Policy from the config
+func newPasswordPolicy(c cnfg.Config) (*password.Policy, error) {
+	policy := &password.Policy{
+		MinLength:      c.PasswordMinLength,
+		MaxLength:      c.PasswordMaxLength,
+		MinCharClasses: c.PasswordMinCharClasses,
+	}
+	if c.PasswordBlocklistFile != "" {
+		blocklist, err := password.LoadBlocklist(c.PasswordBlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.Blocklist = blocklist
+	}
+	return policy, nil
+}
+
 func (s *Server) initRouter() {
 	// for unauthorized user
 	// static
 	s.app.Static("/uploads", "./uploads")
+	// tokens
+	s.app.Get("/.well-known/jwks.json", s.getJWKS)
 	// users
 	s.app.Post("/users", s.createUser)
 	s.app.Post("/users/login", s.loginUser)
-	s.app.Get("/users/verify/email", s.verifyEmail)
+	s.app.Post("/users/login/2fa", s.loginUser2FA)
+	s.app.Post("/tokens/renew", s.renewAccessToken)
+	s.app.Get("/users/verify_email", s.verifyEmailCode)
+	s.app.Post("/users/password/forgot", s.forgotPassword)
+	s.app.Post("/users/password/reset", s.resetPassword)
 	s.app.Get("/users", s.listUsers)
 	// jokes
 	s.app.Get("/jokes", s.listJokes)
 	s.app.Get("/jokes/:id", s.getJoke)
 	s.app.Get("/jokes_by/:username", s.listJokesByAuthor)
+	// comments
+	s.app.Get("/jokes/:id/comments", s.listComments)
+	s.app.Get("/jokes/:id/comments/:comment_id/replies", s.listCommentReplies)
+	// tags
+	s.app.Get("/tags", s.listTags)
+	s.app.Get("/tags/:name/jokes", s.listJokesByTag)
+	// search
+	s.app.Get("/search/jokes", s.searchJokes)
+	s.app.Get("/search/users", s.searchUsers)
+	// oauth, the clients authenticate themselves
+	s.app.Post("/oauth/token", s.oauthToken)
+	s.app.Post("/oauth/introspect", s.oauthIntrospect)
+	s.app.Post("/oauth/revoke", s.oauthRevoke)
 
 	// for authorized users
-	authMiddleware := middleware.NewAuthMiddleware(s.tokenMaker)
+	authMiddleware := middleware.NewAuthMiddleware(s.tokenMaker, s.db)
 	auth := s.app.Group("/")
 	auth.Use(authMiddleware)
+	// scopes
+	profileRead := middleware.RequireScopes(scope.ProfileRead)
+	profileWrite := middleware.RequireScopes(scope.ProfileWrite)
+	jokesWrite := middleware.RequireScopes(scope.JokesWrite)
 	// users
-	auth.Get("/users/me", s.getMe)
-	auth.Put("/users/password", s.updateUserPassword)
-	auth.Post("/uploads/images/avatars", s.updateUserAvatar)
-	auth.Put("/users/fullname", s.updateUserFullname)
-	auth.Put("/users/status", s.updateUserStatus)
-	auth.Put("/users/bio", s.updateUserBio)
-	auth.Delete("/users", s.deleteUser)
+	auth.Get("/users/me", profileRead, s.getMe)
+	auth.Put("/users/password", middleware.RequireSession, profileWrite, s.updateUserPassword)
+	auth.Post("/uploads/images/avatars", profileWrite, s.updateUserAvatar)
+	auth.Put("/users/username", middleware.RequireSession, profileWrite, s.updateUsername)
+	auth.Put("/users/fullname", profileWrite, s.updateUserFullname)
+	auth.Put("/users/status", profileWrite, s.updateUserStatus)
+	auth.Put("/users/bio", profileWrite, s.updateUserBio)
+	auth.Put("/users/deactivate", middleware.RequireSession, profileWrite, s.deactivateUser)
+	auth.Delete("/users", middleware.RequireSession, profileWrite, s.deleteUser)
+	auth.Post("/users/verify_email/resend", profileWrite, s.resendVerifyEmail)
+	auth.Post("/users/2fa/setup", middleware.RequireSession, profileWrite, s.setup2FA)
+	auth.Post("/users/2fa/enable", middleware.RequireSession, profileWrite, s.enable2FA)
+	auth.Post("/users/2fa/disable", middleware.RequireSession, profileWrite, s.disable2FA)
+	// sessions
+	auth.Post("/users/logout", middleware.RequireSession, s.logoutUser)
+	auth.Get("/users/me/sessions", profileRead, s.listMySessions)
+	auth.Delete("/users/me/sessions/:id", profileWrite, s.revokeMySession)
+	// audit
+	auth.Get("/users/me/security_events", profileRead, s.listMySecurityEvents)
+	// oauth
+	auth.Get("/oauth/authorize", middleware.RequireSession, s.oauthAuthorizeInfo)
+	auth.Post("/oauth/authorize", middleware.RequireSession, s.oauthAuthorize)
+	auth.Post("/oauth/clients", middleware.RequireSession, profileWrite, s.createOAuthClient)
+	auth.Get("/oauth/clients", profileRead, s.listMyOAuthClients)
+	auth.Delete("/oauth/clients/:id", middleware.RequireSession, profileWrite, s.deleteMyOAuthClient)
+	// api keys
+	auth.Post("/users/me/api_keys", middleware.RequireSession, profileWrite, s.createAPIKey)
+	auth.Get("/users/me/api_keys", profileRead, s.listMyAPIKeys)
+	auth.Delete("/users/me/api_keys/:id", middleware.RequireSession, profileWrite, s.revokeMyAPIKey)
 	// jokes
-	auth.Post("/jokes", s.createJoke)
-	auth.Put("/jokes/title/:id", s.updateJokeTitle)
-	auth.Put("/jokes/text/:id", s.updateJokeText)
-	auth.Put("/jokes/explanation/:id", s.updateJokeExplanation)
-	auth.Delete("/jokes/:id", s.deleteJoke)
-	auth.Delete("/jokes", s.deleteJokesByAuthor)
+	auth.Post("/jokes", jokesWrite, s.requireVerifiedEmail, s.createJoke)
+	auth.Put("/jokes/title/:id", jokesWrite, s.updateJokeTitle)
+	auth.Put("/jokes/text/:id", jokesWrite, s.updateJokeText)
+	auth.Put("/jokes/explanation/:id", jokesWrite, s.updateJokeExplanation)
+	auth.Put("/jokes/language/:id", jokesWrite, s.updateJokeLanguage)
+	auth.Put("/jokes/tags/:id", jokesWrite, s.updateJokeTags)
+	auth.Delete("/jokes/:id", jokesWrite, s.deleteJoke)
+	auth.Delete("/jokes", jokesWrite, s.deleteJokesByAuthor)
+	// reactions
+	auth.Put("/jokes/:id/reactions", jokesWrite, s.reactToJoke)
+	auth.Delete("/jokes/:id/reactions", jokesWrite, s.deleteJokeReaction)
+	// comments
+	auth.Post("/jokes/:id/comments", jokesWrite, s.createComment)
+	auth.Put("/jokes/:id/comments/:comment_id", jokesWrite, s.updateComment)
+	auth.Delete("/jokes/:id/comments/:comment_id", jokesWrite, s.deleteComment)
+
+	// for moderators and admins
+	// The group middleware runs for all the /admin routes, so the scope is checked per route
+	moderator := auth.Group("/admin", middleware.RequireRole(role.Moderator, role.Admin))
+	moderator.Delete("/jokes/:id", middleware.RequireScopes(scope.JokesModerate), s.takedownJoke)
 
+	// for admins only
+	admin := auth.Group("/admin", middleware.RequireRole(role.Admin), middleware.RequireScopes(scope.Admin))
+	admin.Put("/users/:id/role", s.updateUserRole)
+	admin.Put("/users/:id/ban", s.banUser)
+	admin.Delete("/users/:id/ban", s.unbanUser)
+	admin.Delete("/users/:id/lockout", s.clearUserLockout)
+	admin.Delete("/lockouts/ips/:ip", s.clearIPLockout)
+	admin.Get("/audit_events", s.listAuditEvents)
 	// !DANGEROUS FUNCTION FOR TEST ONLY!
-	s.app.Delete("/users_ALL", s.deleteAllUsers)
-	s.app.Delete("/jokes_ALL", s.deleteAllJokes)
+	admin.Delete("/users_ALL", s.deleteAllUsers)
+	admin.Delete("/jokes_ALL", s.deleteAllJokes)
 }
 
 func (s *Server) Start() {
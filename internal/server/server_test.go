@@ -0,0 +1,1035 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/database/memstore"
+	cnfg "github.com/abc_valera/flugo/internal/utils/config"
+	"github.com/abc_valera/flugo/internal/utils/mail"
+	"github.com/abc_valera/flugo/internal/utils/oauth"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/reaction"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/abc_valera/flugo/internal/utils/search"
+	"github.com/abc_valera/flugo/internal/utils/totp"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password that passes the policy of the config
+const testPassword = "Correct-Horse-42"
+
+// Absolute, so the tests can change the working directory
+var repoRoot, _ = filepath.Abs("../..")
+
+// Returns a server with all the routes on top of an in-memory store.
+// The configure functions change the config before the server is made.
+func newTestServer(t *testing.T, configure ...func(*cnfg.Config)) *Server {
+	config, err := cnfg.LoadConfig(repoRoot)
+	require.NoError(t, err)
+	config.PasswordBlocklistFile = ""
+	// The cheapest hashes keep the tests fast
+	config.PasswordHasher = password.HasherBcrypt
+	config.BcryptCost = bcrypt.MinCost
+	config.SMTPHost = ""
+	for _, fn := range configure {
+		fn(&config)
+	}
+
+	s, err := newServer(config, memstore.New())
+	require.NoError(t, err)
+	s.initRouter()
+	return s
+}
+
+// Sends the request to the server and decodes the JSON response into res if it isn't nil
+func doRequest(t *testing.T, s *Server, req *http.Request, res any) *http.Response {
+	resp, err := s.app.Test(req, -1)
+	require.NoError(t, err)
+	if res != nil {
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, res), string(body))
+	}
+	return resp
+}
+
+func jsonRequest(t *testing.T, method, target, accessToken string, body any) *http.Request {
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, target, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return req
+}
+
+func withAPIKey(req *http.Request, apiKey string) *http.Request {
+	req.Header.Set("Authorization", "ApiKey "+apiKey)
+	return req
+}
+
+func formRequest(target string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// User created by the tests, logged in with the full scope set of the role
+type testUser struct {
+	database.User
+	password string
+	login    loginUserResponse
+}
+
+func newTestUser(t *testing.T, s *Server, userRole string) testUser {
+	userPassword := random.RandomPassword()
+	hashedPassword, err := s.hasher.Hash(userPassword)
+	require.NoError(t, err)
+	user, err := s.db.CreateUser(context.Background(), database.CreateUserParams{
+		Username:       random.RandomUsername(),
+		Email:          random.RandomEmail(),
+		HashedPassword: hashedPassword,
+		Fullname:       random.RandomFullname(),
+	})
+	require.NoError(t, err)
+	if userRole != role.User {
+		user, err = s.db.UpdateUserRole(context.Background(), database.UpdateUserRoleParams{
+			ID:   user.ID,
+			Role: userRole,
+		})
+		require.NoError(t, err)
+	}
+
+	var login loginUserResponse
+	resp := doRequest(t, s, jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{
+		Email:    user.Email,
+		Password: userPassword,
+	}), &login)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return testUser{user, userPassword, login}
+}
+
+// Creates a user and logs in, returning the access token
+func createTestUser(t *testing.T, s *Server) (database.User, string) {
+	user := newTestUser(t, s, role.User)
+	return user.User, user.login.AccessToken
+}
+
+// Server with the users and the jokes most of the route tests start with
+type fixture struct {
+	s *Server
+	// Working directory of the server with the uploads folders
+	dir       string
+	user      testUser
+	other     testUser
+	moderator testUser
+	admin     testUser
+	// Joke of the user
+	joke database.Joke
+	// Time of the server
+	clock time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	// The static files are served from the working directory of the moment the routes are set up
+	dir := chdirUploads(t)
+	s := newTestServer(t)
+	f := &fixture{
+		s:         s,
+		dir:       dir,
+		user:      newTestUser(t, s, role.User),
+		other:     newTestUser(t, s, role.User),
+		moderator: newTestUser(t, s, role.Moderator),
+		admin:     newTestUser(t, s, role.Admin),
+	}
+	f.joke = f.createJoke(t, f.user.ID)
+	f.clock = time.Now()
+	s.now = func() time.Time { return f.clock }
+	return f
+}
+
+func (f *fixture) createJoke(t *testing.T, authorID int32) database.Joke {
+	joke, err := f.s.db.CreateJoke(context.Background(), database.CreateJokeParams{
+		AuthorID: authorID,
+		Title:    random.RandomString(10),
+		Text:     random.RandomString(50),
+		Language: search.English,
+	})
+	require.NoError(t, err)
+	return joke
+}
+
+// Adds a comment to the joke, a reply if parentID isn't zero
+func (f *fixture) createComment(t *testing.T, authorID, jokeID, parentID int32) database.Comment {
+	arg := database.CreateCommentParams{
+		JokeID:   jokeID,
+		AuthorID: authorID,
+		Text:     random.RandomString(20),
+	}
+	if parentID != 0 {
+		parent, err := f.s.db.GetComment(context.Background(), parentID)
+		require.NoError(t, err)
+		arg.ParentID = sql.NullInt32{Int32: parentID, Valid: true}
+		arg.RootID = parent.RootID
+		if !parent.RootID.Valid {
+			arg.RootID = arg.ParentID
+		}
+	}
+	comment, err := f.s.db.CreateComment(context.Background(), arg)
+	require.NoError(t, err)
+	return comment
+}
+
+// Replaces the tags of the joke
+func (f *fixture) tagJoke(t *testing.T, jokeID int32, tags ...string) {
+	err := setJokeTags(context.Background(), f.s.db, jokeID, tags)
+	require.NoError(t, err)
+}
+
+// Turns on two-factor authentication for the user, returns the TOTP secret
+func (f *fixture) enable2FA(t *testing.T, user testUser) string {
+	var setup setup2FAResponse
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/2fa/setup", user.login.AccessToken, nil), &setup)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/2fa/enable", user.login.AccessToken, enable2FARequest{
+		Code: f.totpCode(t, setup.Secret),
+	}), nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return setup.Secret
+}
+
+// Moves the clock of the server to the next step and returns its code, so every code is used once
+func (f *fixture) totpCode(t *testing.T, secret string) string {
+	f.clock = f.clock.Add(totp.Period)
+	code, err := totp.GenerateCode(secret, f.clock)
+	require.NoError(t, err)
+	return code
+}
+
+// Returns the challenge token of the user with two-factor authentication on
+func (f *fixture) loginChallenge(t *testing.T, user testUser) string {
+	var challenge loginChallengeResponse
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{
+		Email:    user.Email,
+		Password: user.password,
+	}), &challenge)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	return challenge.ChallengeToken
+}
+
+func (f *fixture) createAPIKey(t *testing.T, user testUser) createAPIKeyResponse {
+	var apiKey createAPIKeyResponse
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/me/api_keys", user.login.AccessToken, createAPIKeyRequest{
+		Name: "CI",
+	}), &apiKey)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return apiKey
+}
+
+func (f *fixture) createOAuthClient(t *testing.T, user testUser) createOAuthClientResponse {
+	var client createOAuthClientResponse
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/oauth/clients", user.login.AccessToken, createOAuthClientRequest{
+		Name:         "Partner",
+		RedirectURIs: []string{"https://example.com/callback"},
+		Confidential: true,
+	}), &client)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return client
+}
+
+// Returns the query of the authorization request of the client
+func oauthAuthorizeQuery(client createOAuthClientResponse) url.Values {
+	return url.Values{
+		"response_type":         {oauth.ResponseTypeCode},
+		"client_id":             {client.Client.ID},
+		"redirect_uri":          {client.Client.RedirectURIs[0]},
+		"scope":                 {"profile:read"},
+		"code_challenge":        {oauth.S256Challenge("verifier-verifier-verifier-verifier-verifier")},
+		"code_challenge_method": {oauth.CodeChallengeS256},
+	}
+}
+
+// Returns the last email sent to the address
+func (f *fixture) lastEmail(t *testing.T, to string) mail.Message {
+	messages := f.s.mailer.(*mail.FakeMailer).Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if len(messages[i].To) > 0 && messages[i].To[0] == to {
+			return messages[i]
+		}
+	}
+	t.Fatalf("no email sent to %s", to)
+	return mail.Message{}
+}
+
+var (
+	verifyLinkRegexp = regexp.MustCompile(`/users/verify_email\?\S+`)
+	resetTokenRegexp = regexp.MustCompile(`new password:\n(\S+)`)
+)
+
+// Sends the verification email to the user, returns the path of the link
+func (f *fixture) verifyEmailLink(t *testing.T, user testUser) string {
+	require.NoError(t, f.s.sendVerifyEmail(context.Background(), user.User))
+	link := verifyLinkRegexp.FindString(f.lastEmail(t, user.Email).Content)
+	require.NotEmpty(t, link)
+	return link
+}
+
+// Sends the password reset email to the user, returns the token
+func (f *fixture) passwordResetToken(t *testing.T, user testUser) string {
+	require.NoError(t, f.s.sendPasswordResetEmail(context.Background(), user.User))
+	match := resetTokenRegexp.FindStringSubmatch(f.lastEmail(t, user.Email).Content)
+	require.Len(t, match, 2)
+	return match[1]
+}
+
+// Moves the test into an empty directory with the uploads folders
+func chdirUploads(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "uploads", "images", "avatars"), 0o755))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func avatarRequest(t *testing.T, accessToken string) *http.Request {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("avatar", "avatar.png")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("\x89PNG\r\n\x1a\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/uploads/images/avatars", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return req
+}
+
+type routeTest struct {
+	// Method and path of the route as registered in initRouter
+	route  string
+	name   string
+	req    func(t *testing.T, f *fixture) *http.Request
+	status int
+}
+
+var routeTests = []routeTest{
+	// static
+	{"GET /uploads", "existing file", func(t *testing.T, f *fixture) *http.Request {
+		require.NoError(t, os.WriteFile(filepath.Join(f.dir, "uploads", "images", "avatars", "1.png"), []byte("png"), 0o644))
+		return httptest.NewRequest(http.MethodGet, "/uploads/images/avatars/1.png", nil)
+	}, http.StatusOK},
+	// Missing files are passed on to the routes of authorized users
+	{"GET /uploads", "missing file", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/uploads/images/avatars/1.png", nil)
+	}, http.StatusUnauthorized},
+
+	// tokens
+	{"GET /.well-known/jwks.json", "key set", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	}, http.StatusOK},
+
+	// users
+	{"POST /users", "created", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users", "", createUserRequest{random.RandomUsername(), random.RandomEmail(), testPassword})
+	}, http.StatusCreated},
+	{"POST /users", "invalid username", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users", "", createUserRequest{"a b", random.RandomEmail(), testPassword})
+	}, http.StatusBadRequest},
+	{"POST /users", "weak password", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users", "", createUserRequest{random.RandomUsername(), random.RandomEmail(), "short"})
+	}, http.StatusBadRequest},
+	{"POST /users", "taken email", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users", "", createUserRequest{random.RandomUsername(), f.user.Email, testPassword})
+	}, http.StatusConflict},
+	{"POST /users", "taken username", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users", "", createUserRequest{f.user.Username, random.RandomEmail(), testPassword})
+	}, http.StatusConflict},
+
+	{"POST /users/login", "logged in", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: f.user.Email, Password: f.user.password})
+	}, http.StatusCreated},
+	{"POST /users/login", "wrong password", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: f.user.Email, Password: "wrong password"})
+	}, http.StatusUnauthorized},
+	{"POST /users/login", "unknown email", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: random.RandomEmail(), Password: testPassword})
+	}, http.StatusUnauthorized},
+	{"POST /users/login", "banned", func(t *testing.T, f *fixture) *http.Request {
+		_, err := f.s.db.UpdateUserBanned(context.Background(), database.UpdateUserBannedParams{ID: f.user.ID, IsBanned: true})
+		require.NoError(t, err)
+		return jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: f.user.Email, Password: f.user.password})
+	}, http.StatusForbidden},
+	{"POST /users/login", "two-factor challenge", func(t *testing.T, f *fixture) *http.Request {
+		f.enable2FA(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{Email: f.user.Email, Password: f.user.password})
+	}, http.StatusAccepted},
+
+	{"POST /users/login/2fa", "valid code", func(t *testing.T, f *fixture) *http.Request {
+		secret := f.enable2FA(t, f.user)
+		challengeToken := f.loginChallenge(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/login/2fa", "", loginUser2FARequest{ChallengeToken: challengeToken, Code: f.totpCode(t, secret)})
+	}, http.StatusCreated},
+	{"POST /users/login/2fa", "wrong code", func(t *testing.T, f *fixture) *http.Request {
+		f.enable2FA(t, f.user)
+		challengeToken := f.loginChallenge(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/login/2fa", "", loginUser2FARequest{ChallengeToken: challengeToken, Code: "000000"})
+	}, http.StatusUnauthorized},
+	{"POST /users/login/2fa", "invalid challenge", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/login/2fa", "", loginUser2FARequest{ChallengeToken: "invalid", Code: "000000"})
+	}, http.StatusUnauthorized},
+
+	{"POST /tokens/renew", "renewed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/tokens/renew", "", renewAccessTokenRequest{f.user.login.RefreshToken})
+	}, http.StatusCreated},
+	{"POST /tokens/renew", "logged out", func(t *testing.T, f *fixture) *http.Request {
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/logout", f.user.login.AccessToken, nil), nil)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+		return jsonRequest(t, http.MethodPost, "/tokens/renew", "", renewAccessTokenRequest{f.user.login.RefreshToken})
+	}, http.StatusUnauthorized},
+	{"POST /tokens/renew", "role changed", func(t *testing.T, f *fixture) *http.Request {
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPut, fmt.Sprintf("/admin/users/%d/role", f.moderator.ID), f.admin.login.AccessToken, updateUserRoleRequest{role.User}), nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		return jsonRequest(t, http.MethodPost, "/tokens/renew", "", renewAccessTokenRequest{f.moderator.login.RefreshToken})
+	}, http.StatusUnauthorized},
+
+	{"GET /users/verify_email", "verified", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, f.verifyEmailLink(t, f.user), nil)
+	}, http.StatusOK},
+	{"GET /users/verify_email", "used link", func(t *testing.T, f *fixture) *http.Request {
+		link := f.verifyEmailLink(t, f.user)
+		resp := doRequest(t, f.s, httptest.NewRequest(http.MethodGet, link, nil), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return httptest.NewRequest(http.MethodGet, link, nil)
+	}, http.StatusBadRequest},
+	{"GET /users/verify_email", "wrong code", func(t *testing.T, f *fixture) *http.Request {
+		f.verifyEmailLink(t, f.user)
+		return httptest.NewRequest(http.MethodGet, "/users/verify_email?id=1&code=wrong", nil)
+	}, http.StatusBadRequest},
+
+	{"POST /users/password/forgot", "registered", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/password/forgot", "", forgotPasswordRequest{f.user.Email})
+	}, http.StatusAccepted},
+	{"POST /users/password/forgot", "not registered", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/password/forgot", "", forgotPasswordRequest{random.RandomEmail()})
+	}, http.StatusAccepted},
+
+	{"POST /users/password/reset", "reset", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/password/reset", "", resetPasswordRequest{f.passwordResetToken(t, f.user), testPassword})
+	}, http.StatusCreated},
+	{"POST /users/password/reset", "invalid token", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/password/reset", "", resetPasswordRequest{"invalid", testPassword})
+	}, http.StatusBadRequest},
+	{"POST /users/password/reset", "weak password", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/password/reset", "", resetPasswordRequest{f.passwordResetToken(t, f.user), "short"})
+	}, http.StatusBadRequest},
+
+	{"GET /users", "listed", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/users?first=0&size=10", nil)
+	}, http.StatusOK},
+
+	// jokes
+	{"GET /jokes", "listed", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/jokes?first=0&size=10", nil)
+	}, http.StatusOK},
+	{"GET /jokes", "wrong page", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/jokes?first=zero&size=10", nil)
+	}, http.StatusBadRequest},
+	{"GET /jokes", "tagged", func(t *testing.T, f *fixture) *http.Request {
+		f.tagJoke(t, f.joke.ID, "puns")
+		return httptest.NewRequest(http.MethodGet, "/jokes?first=0&size=10&tags=puns,dad&match=all", nil)
+	}, http.StatusOK},
+	{"GET /jokes", "wrong match", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/jokes?first=0&size=10&tags=puns&match=some", nil)
+	}, http.StatusBadRequest},
+
+	{"GET /jokes/:id", "found", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/jokes/%d", f.joke.ID), nil)
+	}, http.StatusOK},
+	{"GET /jokes/:id", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/jokes/1000", nil)
+	}, http.StatusBadRequest},
+
+	{"GET /jokes_by/:username", "listed", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/jokes_by/"+f.user.Username+"?first=0&size=10", nil)
+	}, http.StatusOK},
+	{"GET /jokes_by/:username", "renamed author", func(t *testing.T, f *fixture) *http.Request {
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPut, "/users/username", f.user.login.AccessToken, updateUsernameRequest{random.RandomUsername()}), nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		return httptest.NewRequest(http.MethodGet, "/jokes_by/"+f.user.Username+"?first=0&size=10", nil)
+	}, http.StatusFound},
+
+	// comments
+	{"GET /jokes/:id/comments", "listed", func(t *testing.T, f *fixture) *http.Request {
+		f.createComment(t, f.other.ID, f.joke.ID, 0)
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/jokes/%d/comments?size=10", f.joke.ID), nil)
+	}, http.StatusOK},
+	{"GET /jokes/:id/comments", "wrong cursor", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/jokes/%d/comments?cursor=last", f.joke.ID), nil)
+	}, http.StatusBadRequest},
+	{"GET /jokes/:id/comments", "joke not found", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/jokes/1000/comments", nil)
+	}, http.StatusNotFound},
+
+	{"GET /jokes/:id/comments/:comment_id/replies", "listed", func(t *testing.T, f *fixture) *http.Request {
+		root := f.createComment(t, f.user.ID, f.joke.ID, 0)
+		f.createComment(t, f.other.ID, f.joke.ID, root.ID)
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/jokes/%d/comments/%d/replies?size=10", f.joke.ID, root.ID), nil)
+	}, http.StatusOK},
+	{"GET /jokes/:id/comments/:comment_id/replies", "reply", func(t *testing.T, f *fixture) *http.Request {
+		reply := f.createComment(t, f.other.ID, f.joke.ID, f.createComment(t, f.user.ID, f.joke.ID, 0).ID)
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/jokes/%d/comments/%d/replies", f.joke.ID, reply.ID), nil)
+	}, http.StatusBadRequest},
+	{"GET /jokes/:id/comments/:comment_id/replies", "comment not found", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, fmt.Sprintf("/jokes/%d/comments/1000/replies", f.joke.ID), nil)
+	}, http.StatusNotFound},
+
+	// tags
+	{"GET /tags", "listed", func(t *testing.T, f *fixture) *http.Request {
+		f.tagJoke(t, f.joke.ID, "puns")
+		return httptest.NewRequest(http.MethodGet, "/tags", nil)
+	}, http.StatusOK},
+	{"GET /tags", "wrong size", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/tags?size=1000", nil)
+	}, http.StatusBadRequest},
+	{"GET /tags/:name/jokes", "listed", func(t *testing.T, f *fixture) *http.Request {
+		f.tagJoke(t, f.joke.ID, "puns")
+		return httptest.NewRequest(http.MethodGet, "/tags/puns/jokes?first=0&size=10", nil)
+	}, http.StatusOK},
+	{"GET /tags/:name/jokes", "wrong page", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/tags/puns/jokes?first=zero&size=10", nil)
+	}, http.StatusBadRequest},
+
+	// search
+	{"GET /search/jokes", "found", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/search/jokes?q="+url.QueryEscape(f.joke.Title), nil)
+	}, http.StatusOK},
+	{"GET /search/jokes", "no query", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/search/jokes?q=", nil)
+	}, http.StatusBadRequest},
+	{"GET /search/jokes", "no words", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/search/jokes?q="+url.QueryEscape(`"*" &`), nil)
+	}, http.StatusBadRequest},
+	{"GET /search/jokes", "unknown language", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/search/jokes?q=cat&language=klingon", nil)
+	}, http.StatusBadRequest},
+	{"GET /search/users", "found", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/search/users?q="+f.user.Username, nil)
+	}, http.StatusOK},
+	{"GET /search/users", "wrong size", func(t *testing.T, f *fixture) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/search/users?q=jon&size=0", nil)
+	}, http.StatusBadRequest},
+
+	// oauth
+	{"POST /oauth/token", "unknown client", func(t *testing.T, f *fixture) *http.Request {
+		return formRequest("/oauth/token", url.Values{"grant_type": {oauth.GrantAuthorizationCode}, "client_id": {"unknown"}})
+	}, http.StatusUnauthorized},
+	{"POST /oauth/token", "unknown code", func(t *testing.T, f *fixture) *http.Request {
+		client := f.createOAuthClient(t, f.other)
+		return formRequest("/oauth/token", url.Values{
+			"grant_type":    {oauth.GrantAuthorizationCode},
+			"client_id":     {client.Client.ID},
+			"client_secret": {client.ClientSecret},
+			"code":          {"unknown"},
+			"redirect_uri":  {client.Client.RedirectURIs[0]},
+			"code_verifier": {"verifier-verifier-verifier-verifier-verifier"},
+		})
+	}, http.StatusBadRequest},
+
+	{"POST /oauth/introspect", "inactive token", func(t *testing.T, f *fixture) *http.Request {
+		client := f.createOAuthClient(t, f.other)
+		return formRequest("/oauth/introspect", url.Values{
+			"client_id":     {client.Client.ID},
+			"client_secret": {client.ClientSecret},
+			"token":         {f.user.login.AccessToken},
+		})
+	}, http.StatusOK},
+	{"POST /oauth/introspect", "wrong secret", func(t *testing.T, f *fixture) *http.Request {
+		client := f.createOAuthClient(t, f.other)
+		return formRequest("/oauth/introspect", url.Values{
+			"client_id":     {client.Client.ID},
+			"client_secret": {"wrong"},
+			"token":         {f.user.login.AccessToken},
+		})
+	}, http.StatusUnauthorized},
+
+	{"POST /oauth/revoke", "revoked", func(t *testing.T, f *fixture) *http.Request {
+		client := f.createOAuthClient(t, f.other)
+		return formRequest("/oauth/revoke", url.Values{
+			"client_id":     {client.Client.ID},
+			"client_secret": {client.ClientSecret},
+			"token":         {"unknown"},
+		})
+	}, http.StatusOK},
+
+	// authorization
+	{"GET /users/me", "me", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/users/me", f.user.login.AccessToken, nil)
+	}, http.StatusOK},
+	{"GET /users/me", "no token", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/users/me", "", nil)
+	}, http.StatusUnauthorized},
+	{"GET /users/me", "invalid token", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/users/me", "invalid", nil)
+	}, http.StatusUnauthorized},
+	{"GET /users/me", "api key", func(t *testing.T, f *fixture) *http.Request {
+		return withAPIKey(jsonRequest(t, http.MethodGet, "/users/me", "", nil), f.createAPIKey(t, f.user).Key)
+	}, http.StatusOK},
+	{"GET /users/me", "blocked session", func(t *testing.T, f *fixture) *http.Request {
+		require.NoError(t, f.s.db.BlockSession(context.Background(), f.user.login.SessionID))
+		return jsonRequest(t, http.MethodGet, "/users/me", f.user.login.AccessToken, nil)
+	}, http.StatusUnauthorized},
+
+	// users
+	{"PUT /users/password", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/password", f.user.login.AccessToken, updateUserPasswordRequest{f.user.password, testPassword})
+	}, http.StatusCreated},
+	{"PUT /users/password", "wrong old password", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/password", f.user.login.AccessToken, updateUserPasswordRequest{"wrong password", testPassword})
+	}, http.StatusBadRequest},
+
+	{"POST /uploads/images/avatars", "uploaded", func(t *testing.T, f *fixture) *http.Request {
+		return avatarRequest(t, f.user.login.AccessToken)
+	}, http.StatusCreated},
+
+	{"PUT /users/username", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/username", f.user.login.AccessToken, updateUsernameRequest{random.RandomUsername()})
+	}, http.StatusCreated},
+	{"PUT /users/username", "taken", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/username", f.user.login.AccessToken, updateUsernameRequest{f.other.Username})
+	}, http.StatusConflict},
+	{"PUT /users/username", "cooldown", func(t *testing.T, f *fixture) *http.Request {
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPut, "/users/username", f.user.login.AccessToken, updateUsernameRequest{random.RandomUsername()}), nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		return jsonRequest(t, http.MethodPut, "/users/username", f.user.login.AccessToken, updateUsernameRequest{random.RandomUsername()})
+	}, http.StatusTooManyRequests},
+
+	{"PUT /users/fullname", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/fullname", f.user.login.AccessToken, updateUserFullnameRequest{random.RandomFullname()})
+	}, http.StatusCreated},
+	{"PUT /users/status", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/status", f.user.login.AccessToken, updateUserStatusRequest{random.RandomStatus()})
+	}, http.StatusCreated},
+	{"PUT /users/bio", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/bio", f.user.login.AccessToken, updateUserBioRequest{random.RandomBio()})
+	}, http.StatusCreated},
+
+	{"PUT /users/deactivate", "deactivated", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/deactivate", f.user.login.AccessToken, deactivateUserRequest{f.user.password})
+	}, http.StatusNoContent},
+	{"PUT /users/deactivate", "wrong password", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/users/deactivate", f.user.login.AccessToken, deactivateUserRequest{"wrong password"})
+	}, http.StatusUnauthorized},
+	{"PUT /users/deactivate", "api key", func(t *testing.T, f *fixture) *http.Request {
+		return withAPIKey(jsonRequest(t, http.MethodPut, "/users/deactivate", "", deactivateUserRequest{f.user.password}), f.createAPIKey(t, f.user).Key)
+	}, http.StatusForbidden},
+
+	{"DELETE /users", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/users", f.user.login.AccessToken, deleteUserRequest{f.user.password})
+	}, http.StatusNoContent},
+	{"DELETE /users", "wrong password", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/users", f.user.login.AccessToken, deleteUserRequest{"wrong password"})
+	}, http.StatusUnauthorized},
+	{"DELETE /users", "api key", func(t *testing.T, f *fixture) *http.Request {
+		return withAPIKey(jsonRequest(t, http.MethodDelete, "/users", "", deleteUserRequest{f.user.password}), f.createAPIKey(t, f.user).Key)
+	}, http.StatusForbidden},
+
+	{"POST /users/verify_email/resend", "sent", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/verify_email/resend", f.user.login.AccessToken, nil)
+	}, http.StatusAccepted},
+	{"POST /users/verify_email/resend", "verified already", func(t *testing.T, f *fixture) *http.Request {
+		resp := doRequest(t, f.s, httptest.NewRequest(http.MethodGet, f.verifyEmailLink(t, f.user), nil), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return jsonRequest(t, http.MethodPost, "/users/verify_email/resend", f.user.login.AccessToken, nil)
+	}, http.StatusBadRequest},
+
+	{"POST /users/2fa/setup", "set up", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/2fa/setup", f.user.login.AccessToken, nil)
+	}, http.StatusCreated},
+	{"POST /users/2fa/setup", "enabled already", func(t *testing.T, f *fixture) *http.Request {
+		f.enable2FA(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/2fa/setup", f.user.login.AccessToken, nil)
+	}, http.StatusConflict},
+
+	{"POST /users/2fa/enable", "not set up", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/2fa/enable", f.user.login.AccessToken, enable2FARequest{"000000"})
+	}, http.StatusBadRequest},
+	{"POST /users/2fa/enable", "enabled already", func(t *testing.T, f *fixture) *http.Request {
+		secret := f.enable2FA(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/2fa/enable", f.user.login.AccessToken, enable2FARequest{f.totpCode(t, secret)})
+	}, http.StatusConflict},
+
+	{"POST /users/2fa/disable", "disabled", func(t *testing.T, f *fixture) *http.Request {
+		secret := f.enable2FA(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/2fa/disable", f.user.login.AccessToken, disable2FARequest{Password: f.user.password, Code: f.totpCode(t, secret)})
+	}, http.StatusCreated},
+	{"POST /users/2fa/disable", "wrong code", func(t *testing.T, f *fixture) *http.Request {
+		f.enable2FA(t, f.user)
+		return jsonRequest(t, http.MethodPost, "/users/2fa/disable", f.user.login.AccessToken, disable2FARequest{Password: f.user.password, Code: "000000"})
+	}, http.StatusUnauthorized},
+
+	// sessions
+	{"POST /users/logout", "logged out", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/logout", f.user.login.AccessToken, nil)
+	}, http.StatusNoContent},
+
+	{"GET /users/me/sessions", "listed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/users/me/sessions", f.user.login.AccessToken, nil)
+	}, http.StatusOK},
+
+	{"DELETE /users/me/sessions/:id", "revoked", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/users/me/sessions/"+f.user.login.SessionID.String(), f.user.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /users/me/sessions/:id", "session of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/users/me/sessions/"+f.other.login.SessionID.String(), f.user.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	// audit
+	{"GET /users/me/security_events", "listed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/users/me/security_events", f.user.login.AccessToken, nil)
+	}, http.StatusOK},
+	{"GET /users/me/security_events", "wrong size", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/users/me/security_events?size=1000", f.user.login.AccessToken, nil)
+	}, http.StatusBadRequest},
+
+	// oauth
+	{"GET /oauth/authorize", "consent screen", func(t *testing.T, f *fixture) *http.Request {
+		query := oauthAuthorizeQuery(f.createOAuthClient(t, f.other))
+		return jsonRequest(t, http.MethodGet, "/oauth/authorize?"+query.Encode(), f.user.login.AccessToken, nil)
+	}, http.StatusOK},
+	{"GET /oauth/authorize", "unknown client", func(t *testing.T, f *fixture) *http.Request {
+		query := oauthAuthorizeQuery(f.createOAuthClient(t, f.other))
+		query.Set("client_id", "unknown")
+		return jsonRequest(t, http.MethodGet, "/oauth/authorize?"+query.Encode(), f.user.login.AccessToken, nil)
+	}, http.StatusBadRequest},
+
+	{"POST /oauth/authorize", "approved", func(t *testing.T, f *fixture) *http.Request {
+		query := oauthAuthorizeQuery(f.createOAuthClient(t, f.other))
+		return jsonRequest(t, http.MethodPost, "/oauth/authorize", f.user.login.AccessToken, authorizeRequest{
+			ResponseType:        query.Get("response_type"),
+			ClientID:            query.Get("client_id"),
+			RedirectURI:         query.Get("redirect_uri"),
+			Scope:               query.Get("scope"),
+			CodeChallenge:       query.Get("code_challenge"),
+			CodeChallengeMethod: query.Get("code_challenge_method"),
+			Approve:             true,
+		})
+	}, http.StatusOK},
+
+	{"POST /oauth/clients", "created", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/oauth/clients", f.user.login.AccessToken, createOAuthClientRequest{
+			Name:         "Partner",
+			RedirectURIs: []string{"https://example.com/callback"},
+		})
+	}, http.StatusCreated},
+	{"POST /oauth/clients", "no redirect uris", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/oauth/clients", f.user.login.AccessToken, createOAuthClientRequest{Name: "Partner"})
+	}, http.StatusBadRequest},
+
+	{"GET /oauth/clients", "listed", func(t *testing.T, f *fixture) *http.Request {
+		f.createOAuthClient(t, f.user)
+		return jsonRequest(t, http.MethodGet, "/oauth/clients", f.user.login.AccessToken, nil)
+	}, http.StatusOK},
+
+	{"DELETE /oauth/clients/:id", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		client := f.createOAuthClient(t, f.user)
+		return jsonRequest(t, http.MethodDelete, "/oauth/clients/"+client.Client.ID, f.user.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /oauth/clients/:id", "client of another user", func(t *testing.T, f *fixture) *http.Request {
+		client := f.createOAuthClient(t, f.other)
+		return jsonRequest(t, http.MethodDelete, "/oauth/clients/"+client.Client.ID, f.user.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	// api keys
+	{"POST /users/me/api_keys", "created", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/me/api_keys", f.user.login.AccessToken, createAPIKeyRequest{Name: "CI", Scopes: []string{"jokes:write"}})
+	}, http.StatusCreated},
+	{"POST /users/me/api_keys", "scope of another role", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/users/me/api_keys", f.user.login.AccessToken, createAPIKeyRequest{Name: "CI", Scopes: []string{"admin"}})
+	}, http.StatusBadRequest},
+
+	{"GET /users/me/api_keys", "listed", func(t *testing.T, f *fixture) *http.Request {
+		f.createAPIKey(t, f.user)
+		return jsonRequest(t, http.MethodGet, "/users/me/api_keys", f.user.login.AccessToken, nil)
+	}, http.StatusOK},
+
+	{"DELETE /users/me/api_keys/:id", "revoked", func(t *testing.T, f *fixture) *http.Request {
+		apiKey := f.createAPIKey(t, f.user)
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/users/me/api_keys/%d", apiKey.APIKey.ID), f.user.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /users/me/api_keys/:id", "key of another user", func(t *testing.T, f *fixture) *http.Request {
+		apiKey := f.createAPIKey(t, f.other)
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/users/me/api_keys/%d", apiKey.APIKey.ID), f.user.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	// jokes
+	{"POST /jokes", "created", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{Title: "Title", Text: "Text"})
+	}, http.StatusCreated},
+	{"POST /jokes", "tagged", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{Title: "Title", Text: "Text", Tags: []string{"Puns", "dad"}})
+	}, http.StatusCreated},
+	{"POST /jokes", "unknown language", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{Title: "Title", Text: "Text", Language: "klingon"})
+	}, http.StatusBadRequest},
+	{"POST /jokes", "wrong tag", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{Title: "Title", Text: "Text", Tags: []string{"dad jokes"}})
+	}, http.StatusBadRequest},
+	{"POST /jokes", "no title", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{Text: "Text"})
+	}, http.StatusBadRequest},
+	{"POST /jokes", "unverified email", func(t *testing.T, f *fixture) *http.Request {
+		f.s.config.RequireVerifiedEmail = true
+		return jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{Title: "Title", Text: "Text"})
+	}, http.StatusForbidden},
+
+	{"PUT /jokes/title/:id", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/title/%d", f.joke.ID), f.user.login.AccessToken, updateJokeTitleRequest{"Title"})
+	}, http.StatusCreated},
+	{"PUT /jokes/title/:id", "joke of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/title/%d", f.joke.ID), f.other.login.AccessToken, updateJokeTitleRequest{"Title"})
+	}, http.StatusForbidden},
+	{"PUT /jokes/title/:id", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/jokes/title/1000", f.user.login.AccessToken, updateJokeTitleRequest{"Title"})
+	}, http.StatusNotFound},
+
+	{"PUT /jokes/text/:id", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/text/%d", f.joke.ID), f.user.login.AccessToken, updateJokeTextRequest{"Text"})
+	}, http.StatusCreated},
+	{"PUT /jokes/text/:id", "joke of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/text/%d", f.joke.ID), f.other.login.AccessToken, updateJokeTextRequest{"Text"})
+	}, http.StatusForbidden},
+
+	{"PUT /jokes/explanation/:id", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/explanation/%d", f.joke.ID), f.user.login.AccessToken, updateJokeExplanationRequest{"Explanation"})
+	}, http.StatusCreated},
+	{"PUT /jokes/explanation/:id", "joke of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/explanation/%d", f.joke.ID), f.other.login.AccessToken, updateJokeExplanationRequest{"Explanation"})
+	}, http.StatusForbidden},
+
+	{"PUT /jokes/language/:id", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/language/%d", f.joke.ID), f.user.login.AccessToken, updateJokeLanguageRequest{search.French})
+	}, http.StatusCreated},
+	{"PUT /jokes/language/:id", "unknown language", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/language/%d", f.joke.ID), f.user.login.AccessToken, updateJokeLanguageRequest{"klingon"})
+	}, http.StatusBadRequest},
+	{"PUT /jokes/language/:id", "joke of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/language/%d", f.joke.ID), f.other.login.AccessToken, updateJokeLanguageRequest{search.French})
+	}, http.StatusForbidden},
+
+	{"PUT /jokes/tags/:id", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/tags/%d", f.joke.ID), f.user.login.AccessToken, updateJokeTagsRequest{[]string{"puns"}})
+	}, http.StatusCreated},
+	{"PUT /jokes/tags/:id", "too many tags", func(t *testing.T, f *fixture) *http.Request {
+		tags := make([]string, 11)
+		for i := range tags {
+			tags[i] = fmt.Sprintf("tag-%d", i)
+		}
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/tags/%d", f.joke.ID), f.user.login.AccessToken, updateJokeTagsRequest{tags})
+	}, http.StatusBadRequest},
+	{"PUT /jokes/tags/:id", "joke of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/tags/%d", f.joke.ID), f.other.login.AccessToken, updateJokeTagsRequest{[]string{"puns"}})
+	}, http.StatusForbidden},
+	{"PUT /jokes/tags/:id", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/jokes/tags/1000", f.user.login.AccessToken, updateJokeTagsRequest{[]string{"puns"}})
+	}, http.StatusNotFound},
+
+	{"DELETE /jokes/:id", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d", f.joke.ID), f.user.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /jokes/:id", "joke of another user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d", f.joke.ID), f.other.login.AccessToken, nil)
+	}, http.StatusForbidden},
+	{"DELETE /jokes/:id", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/jokes/1000", f.user.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	{"DELETE /jokes", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/jokes", f.user.login.AccessToken, nil)
+	}, http.StatusNoContent},
+
+	// reactions
+	{"PUT /jokes/:id/reactions", "reacted", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/%d/reactions", f.joke.ID), f.other.login.AccessToken, reactToJokeRequest{reaction.Laugh})
+	}, http.StatusCreated},
+	{"PUT /jokes/:id/reactions", "unknown reaction", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/%d/reactions", f.joke.ID), f.other.login.AccessToken, reactToJokeRequest{"shrug"})
+	}, http.StatusBadRequest},
+	{"PUT /jokes/:id/reactions", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/jokes/1000/reactions", f.other.login.AccessToken, reactToJokeRequest{reaction.Laugh})
+	}, http.StatusNotFound},
+
+	{"DELETE /jokes/:id/reactions", "taken back", func(t *testing.T, f *fixture) *http.Request {
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/%d/reactions", f.joke.ID), f.other.login.AccessToken, reactToJokeRequest{reaction.Love}), nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d/reactions", f.joke.ID), f.other.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /jokes/:id/reactions", "no reaction", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d/reactions", f.joke.ID), f.other.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	// comments
+	{"POST /jokes/:id/comments", "commented", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, fmt.Sprintf("/jokes/%d/comments", f.joke.ID), f.other.login.AccessToken, createCommentRequest{Text: "Text"})
+	}, http.StatusCreated},
+	{"POST /jokes/:id/comments", "replied", func(t *testing.T, f *fixture) *http.Request {
+		parent := f.createComment(t, f.user.ID, f.joke.ID, 0)
+		return jsonRequest(t, http.MethodPost, fmt.Sprintf("/jokes/%d/comments", f.joke.ID), f.other.login.AccessToken, createCommentRequest{Text: "Text", ParentID: parent.ID})
+	}, http.StatusCreated},
+	{"POST /jokes/:id/comments", "no text", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, fmt.Sprintf("/jokes/%d/comments", f.joke.ID), f.other.login.AccessToken, createCommentRequest{})
+	}, http.StatusBadRequest},
+	{"POST /jokes/:id/comments", "parent on another joke", func(t *testing.T, f *fixture) *http.Request {
+		parent := f.createComment(t, f.user.ID, f.createJoke(t, f.user.ID).ID, 0)
+		return jsonRequest(t, http.MethodPost, fmt.Sprintf("/jokes/%d/comments", f.joke.ID), f.other.login.AccessToken, createCommentRequest{Text: "Text", ParentID: parent.ID})
+	}, http.StatusBadRequest},
+	{"POST /jokes/:id/comments", "parent not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, fmt.Sprintf("/jokes/%d/comments", f.joke.ID), f.other.login.AccessToken, createCommentRequest{Text: "Text", ParentID: 1000})
+	}, http.StatusNotFound},
+	{"POST /jokes/:id/comments", "joke not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPost, "/jokes/1000/comments", f.other.login.AccessToken, createCommentRequest{Text: "Text"})
+	}, http.StatusNotFound},
+
+	{"PUT /jokes/:id/comments/:comment_id", "changed", func(t *testing.T, f *fixture) *http.Request {
+		comment := f.createComment(t, f.other.ID, f.joke.ID, 0)
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/%d/comments/%d", f.joke.ID, comment.ID), f.other.login.AccessToken, updateCommentRequest{"Text"})
+	}, http.StatusCreated},
+	{"PUT /jokes/:id/comments/:comment_id", "comment of another user", func(t *testing.T, f *fixture) *http.Request {
+		comment := f.createComment(t, f.other.ID, f.joke.ID, 0)
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/%d/comments/%d", f.joke.ID, comment.ID), f.user.login.AccessToken, updateCommentRequest{"Text"})
+	}, http.StatusForbidden},
+	{"PUT /jokes/:id/comments/:comment_id", "comment on another joke", func(t *testing.T, f *fixture) *http.Request {
+		comment := f.createComment(t, f.other.ID, f.createJoke(t, f.user.ID).ID, 0)
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/%d/comments/%d", f.joke.ID, comment.ID), f.other.login.AccessToken, updateCommentRequest{"Text"})
+	}, http.StatusNotFound},
+
+	{"DELETE /jokes/:id/comments/:comment_id", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		comment := f.createComment(t, f.other.ID, f.joke.ID, 0)
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d/comments/%d", f.joke.ID, comment.ID), f.other.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /jokes/:id/comments/:comment_id", "comment of another user", func(t *testing.T, f *fixture) *http.Request {
+		comment := f.createComment(t, f.other.ID, f.joke.ID, 0)
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d/comments/%d", f.joke.ID, comment.ID), f.user.login.AccessToken, nil)
+	}, http.StatusForbidden},
+	{"DELETE /jokes/:id/comments/:comment_id", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d/comments/1000", f.joke.ID), f.other.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	// moderators
+	{"DELETE /admin/jokes/:id", "taken down", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/admin/jokes/%d", f.joke.ID), f.moderator.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /admin/jokes/:id", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/admin/jokes/1000", f.moderator.login.AccessToken, nil)
+	}, http.StatusNotFound},
+	{"DELETE /admin/jokes/:id", "user", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/admin/jokes/%d", f.joke.ID), f.other.login.AccessToken, nil)
+	}, http.StatusForbidden},
+
+	// admins
+	{"PUT /admin/users/:id/role", "changed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/admin/users/%d/role", f.user.ID), f.admin.login.AccessToken, updateUserRoleRequest{role.Moderator})
+	}, http.StatusCreated},
+	{"PUT /admin/users/:id/role", "own role", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/admin/users/%d/role", f.admin.ID), f.admin.login.AccessToken, updateUserRoleRequest{role.User})
+	}, http.StatusBadRequest},
+	{"PUT /admin/users/:id/role", "moderator", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/admin/users/%d/role", f.user.ID), f.moderator.login.AccessToken, updateUserRoleRequest{role.Moderator})
+	}, http.StatusForbidden},
+	{"PUT /admin/users/:id/role", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/admin/users/1000/role", f.admin.login.AccessToken, updateUserRoleRequest{role.Moderator})
+	}, http.StatusNotFound},
+
+	{"PUT /admin/users/:id/ban", "banned", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, fmt.Sprintf("/admin/users/%d/ban", f.user.ID), f.admin.login.AccessToken, nil)
+	}, http.StatusCreated},
+	{"PUT /admin/users/:id/ban", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodPut, "/admin/users/1000/ban", f.admin.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	{"DELETE /admin/users/:id/ban", "unbanned", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/admin/users/%d/ban", f.user.ID), f.admin.login.AccessToken, nil)
+	}, http.StatusCreated},
+
+	{"DELETE /admin/users/:id/lockout", "cleared", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, fmt.Sprintf("/admin/users/%d/lockout", f.user.ID), f.admin.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /admin/users/:id/lockout", "not found", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/admin/users/1000/lockout", f.admin.login.AccessToken, nil)
+	}, http.StatusNotFound},
+
+	{"DELETE /admin/lockouts/ips/:ip", "cleared", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/admin/lockouts/ips/127.0.0.1", f.admin.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /admin/lockouts/ips/:ip", "wrong ip", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/admin/lockouts/ips/localhost", f.admin.login.AccessToken, nil)
+	}, http.StatusBadRequest},
+
+	{"GET /admin/audit_events", "listed", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, fmt.Sprintf("/admin/audit_events?user_id=%d&type=login", f.user.ID), f.admin.login.AccessToken, nil)
+	}, http.StatusOK},
+	{"GET /admin/audit_events", "unknown type", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodGet, "/admin/audit_events?type=unknown", f.admin.login.AccessToken, nil)
+	}, http.StatusBadRequest},
+
+	{"DELETE /admin/users_ALL", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/admin/users_ALL", f.admin.login.AccessToken, nil)
+	}, http.StatusNoContent},
+	{"DELETE /admin/jokes_ALL", "deleted", func(t *testing.T, f *fixture) *http.Request {
+		return jsonRequest(t, http.MethodDelete, "/admin/jokes_ALL", f.admin.login.AccessToken, nil)
+	}, http.StatusNoContent},
+}
+
+func TestRoutes(t *testing.T) {
+	for _, tc := range routeTests {
+		tc := tc
+		t.Run(tc.route+" "+tc.name, func(t *testing.T) {
+			f := newFixture(t)
+			resp := doRequest(t, f.s, tc.req(t, f), nil)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.status, resp.StatusCode, string(body))
+		})
+	}
+}
+
+// Every route of initRouter has to be covered by the route tests
+func TestRoutesCovered(t *testing.T) {
+	tested := make(map[string]bool)
+	for _, tc := range routeTests {
+		tested[tc.route] = true
+	}
+
+	s := newTestServer(t)
+	for _, route := range s.app.GetRoutes(true) {
+		if route.Method == http.MethodHead {
+			continue
+		}
+		name := route.Method + " " + route.Path
+		require.True(t, tested[name], "route %s isn't tested", name)
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// sessionResponse type is returned back with response. It omits the refresh token of the session.
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	IsCurrent bool      `json:"is_current"`
+	// Set for the sessions started by OAuth clients
+	ClientID  *string   `json:"client_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Returns new sessionResponse from default session type
+func newSessionResponse(session database.Session, currentSessionID uuid.UUID) sessionResponse {
+	var clientID *string
+	if session.ClientID.Valid {
+		clientID = &session.ClientID.String
+	}
+	return sessionResponse{
+		session.ID,
+		session.UserAgent,
+		session.ClientIp,
+		session.IsBlocked,
+		session.ID == currentSessionID,
+		clientID,
+		session.ExpiresAt,
+		session.CreatedAt,
+	}
+}
+
+// POST REQUESTS
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	TokenType            string    `json:"token_type"`
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	Scopes               []string  `json:"scopes"`
+}
+
+func (s *Server) renewAccessToken(c *fiber.Ctx) error {
+	req := new(renewAccessTokenRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	session, err := s.db.GetSession(c.Context(), refreshPayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusUnauthorized, "session not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if session.IsBlocked {
+		return fiber.NewError(fiber.StatusUnauthorized, "session is revoked")
+	}
+	// OAuth clients have to authenticate themselves to renew the tokens
+	if session.ClientID.Valid {
+		return fiber.NewError(fiber.StatusUnauthorized, "tokens of oauth clients are renewed at /oauth/token")
+	}
+	if session.UserID != refreshPayload.UserID {
+		return fiber.NewError(fiber.StatusUnauthorized, "incorrect session user")
+	}
+	if session.RefreshToken != req.RefreshToken {
+		return fiber.NewError(fiber.StatusUnauthorized, "mismatched session token")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return fiber.NewError(fiber.StatusUnauthorized, "session has expired")
+	}
+
+	// The user is fetched again, so role changes and bans apply to renewed tokens and their scopes
+	user, err := s.db.GetUserByID(c.Context(), session.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if user.IsBanned {
+		return fiber.NewError(fiber.StatusForbidden, "user is banned")
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
+		user.ID,
+		user.Username,
+		user.Email,
+		user.Role,
+		scope.Granted(session.Scopes, user.Role),
+		session.ID,
+		s.config.AccessTokenDuration,
+	)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(renewAccessTokenResponse{
+		TokenType:            middleware.AuthTypeBearer,
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		Scopes:               accessPayload.Scopes,
+	})
+}
+
+func (s *Server) logoutUser(c *fiber.Ctx) error {
+	err := s.db.BlockSession(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).SessionID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET REQUESTS
+
+func (s *Server) listMySessions(c *fiber.Ctx) error {
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	sessions, err := s.db.ListSessionsByUser(c.Context(), authPayload.UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	sessionsResponse := make([]sessionResponse, 0)
+	for _, session := range sessions {
+		sessionsResponse = append(sessionsResponse, newSessionResponse(session, authPayload.SessionID))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(sessionsResponse)
+}
+
+// DELETE REQUESTS
+
+func (s *Server) revokeMySession(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// Sessions of other users are reported as not found, so their ids can't be probed
+	rows, err := s.db.BlockOwnedSession(c.Context(), database.BlockOwnedSessionParams{
+		ID:     id,
+		UserID: c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if rows == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "session not found")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
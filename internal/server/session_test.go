@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAfterDemotion(t *testing.T) {
+	f := newFixture(t)
+
+	// The session is limited to the scope only moderators have
+	var login loginUserResponse
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/users/login", "", loginUserRequest{
+		Email:    f.moderator.Email,
+		Password: f.moderator.password,
+		Scopes:   []string{scope.JokesModerate},
+	}), &login)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, []string{scope.JokesModerate}, login.Scopes)
+
+	_, err := f.s.db.UpdateUserRole(context.Background(), database.UpdateUserRoleParams{ID: f.moderator.ID, Role: role.User})
+	require.NoError(t, err)
+
+	// The renewed token keeps none of the scopes instead of getting the full set of the new role
+	var renewed renewAccessTokenResponse
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/tokens/renew", "", renewAccessTokenRequest{login.RefreshToken}), &renewed)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, renewed.Scopes)
+
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodGet, "/users/me", renewed.AccessToken, nil), nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Page size of the tags if it isn't given, and the largest one allowed
+const (
+	defaultTagPageSize = 50
+	maxTagPageSize     = 100
+)
+
+// Tag filter of listJokes matching the jokes with any or all of the given tags
+const (
+	tagMatchAny = "any"
+	tagMatchAll = "all"
+)
+
+// Returns the tags trimmed, lowercase and without duplicates, in the given order.
+// Empty tags, like the one after the trailing comma of a filter, are left out.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// Replaces the tags of the joke, creating the tags that don't exist yet
+func setJokeTags(ctx context.Context, q database.Querier, jokeID int32, tags []string) error {
+	err := q.DeleteJokeTags(ctx, jokeID)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	upserted, err := q.UpsertTags(ctx, tags)
+	if err != nil {
+		return err
+	}
+	tagIDs := make([]int32, 0, len(upserted))
+	for _, tag := range upserted {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	return q.AddJokeTags(ctx, database.AddJokeTagsParams{
+		JokeID: jokeID,
+		TagIds: tagIDs,
+	})
+}
+
+// GET REQUESTS
+
+// Returns the tags in use with the number of their jokes, the most used first
+func (s *Server) listTags(c *fiber.Ctx) error {
+	first, err := strconv.Atoi(c.Query("first", "0"))
+	if err != nil || first < 0 {
+		return fiber.NewError(http.StatusBadRequest, "Provided wrong first")
+	}
+	size, err := strconv.Atoi(c.Query("size", strconv.Itoa(defaultTagPageSize)))
+	if err != nil || size < 1 || size > maxTagPageSize {
+		return fiber.NewError(http.StatusBadRequest, "Provided wrong size")
+	}
+
+	tags, err := s.db.ListTags(c.Context(), database.ListTagsParams{
+		Limit:  int32(size),
+		Offset: int32(first),
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if tags == nil {
+		tags = make([]database.ListTagsRow, 0)
+	}
+	return c.Status(fiber.StatusOK).JSON(tags)
+}
+
+func (s *Server) listJokesByTag(c *fiber.Ctx) error {
+	first, size, err := jokePage(c)
+	if err != nil {
+		return err
+	}
+	tag := normalizeTags([]string{c.Params("name")})
+
+	jokes, err := s.db.ListJokes(c.Context(), database.ListJokesParams{
+		AnyTags: tag,
+		Limit:   size,
+		Offset:  first,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusOK).JSON(jokes)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJokeTags(t *testing.T) {
+	f := newFixture(t)
+
+	var created database.JokesWithAuthor
+	resp := doRequest(t, f.s, jsonRequest(t, http.MethodPost, "/jokes", f.user.login.AccessToken, createJokeRequest{
+		Title: "Title",
+		Text:  "Text",
+		Tags:  []string{"Puns", " dad ", "puns", " "},
+	}), &created)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	// Tags are normalized and listed by name, the empty ones are left out
+	require.Equal(t, []string{"dad", "puns"}, created.Tags)
+
+	var updated database.JokesWithAuthor
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/tags/%d", f.joke.ID), f.user.login.AccessToken, updateJokeTagsRequest{[]string{"puns", "cats"}}), &updated)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, []string{"cats", "puns"}, updated.Tags)
+
+	listJokes := func(target string) []int32 {
+		var jokes []database.JokesWithAuthor
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, target, "", nil), &jokes)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		ids := make([]int32, 0, len(jokes))
+		for _, joke := range jokes {
+			ids = append(ids, joke.ID)
+		}
+		return ids
+	}
+	require.Equal(t, []int32{f.joke.ID, created.ID}, listJokes("/jokes?first=0&size=10&tags=dad,cats"))
+	require.Equal(t, []int32{f.joke.ID, created.ID}, listJokes("/jokes?first=0&size=10&tags=puns&match=all"))
+	require.Equal(t, []int32{created.ID}, listJokes("/jokes?first=0&size=10&tags=dad,puns&match=all"))
+	require.Empty(t, listJokes("/jokes?first=0&size=10&tags=dad,cats&match=all"))
+	require.Equal(t, []int32{f.joke.ID}, listJokes("/tags/Cats/jokes?first=0&size=10"))
+	// Empty tags of the filters are left out
+	require.Equal(t, []int32{created.ID}, listJokes("/jokes?first=0&size=10&tags=dad,"))
+	require.Equal(t, []int32{created.ID}, listJokes("/jokes?first=0&size=10&tags=dad,,puns&match=all"))
+	require.Equal(t, []int32{f.joke.ID, created.ID}, listJokes("/jokes?first=0&size=10&tags=,"))
+
+	listTags := func() []database.ListTagsRow {
+		var tags []database.ListTagsRow
+		resp := doRequest(t, f.s, jsonRequest(t, http.MethodGet, "/tags", "", nil), &tags)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return tags
+	}
+	require.Equal(t, []database.ListTagsRow{
+		{Name: "puns", JokeCount: 2},
+		{Name: "cats", JokeCount: 1},
+		{Name: "dad", JokeCount: 1},
+	}, listTags())
+
+	// Removing the tags of the joke and deleting it takes them out of the counts
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodPut, fmt.Sprintf("/jokes/tags/%d", f.joke.ID), f.user.login.AccessToken, updateJokeTagsRequest{}), &updated)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Empty(t, updated.Tags)
+	resp = doRequest(t, f.s, jsonRequest(t, http.MethodDelete, fmt.Sprintf("/jokes/%d", created.ID), f.user.login.AccessToken, nil), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Empty(t, listTags())
+}
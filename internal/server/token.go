@@ -0,0 +1,19 @@
+package server
+
+import (
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GET REQUESTS
+
+// Serves the public keys other services can verify access tokens with.
+// Symmetric makers have nothing to publish, so the key set is empty for them.
+func (s *Server) getJWKS(c *fiber.Ctx) error {
+	jwks := token.JWKSet{Keys: []token.JWK{}}
+	if provider, ok := s.tokenMaker.(token.KeySetProvider); ok {
+		jwks = provider.JWKS()
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(jwks)
+}
@@ -0,0 +1,375 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/abc_valera/flugo/internal/utils/totp"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Stops the transactions of the second factor checks if the code is wrong
+var errInvalidSecondFactor = fiber.NewError(fiber.StatusUnauthorized, "invalid code")
+
+const (
+	recoveryCodesCount = 10
+	// Wrong codes a login challenge can take before it is burned
+	maxLoginChallengeAttempts = 5
+)
+
+// Returns new recovery codes formatted like xxxx-xxxx-xxxx-xxxx
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+	}
+	return codes, nil
+}
+
+// Recovery codes are compared without the dashes and case, as people type them in by hand
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// Replaces recovery codes of the user with new ones. Only the hashes of the codes are stored.
+func resetRecoveryCodes(ctx context.Context, q database.Querier, userID int32) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.DeleteUserRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		_, err = q.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: password.HashToken(normalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// Checks the one-time code from the authenticator app or, if it is empty, the recovery code.
+// Both are burned on success with q, so they can't be replayed.
+func (s *Server) checkSecondFactor(ctx context.Context, q database.Querier, user database.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := totp.Validate(user.TotpSecret, code, s.now())
+		if !ok {
+			return false, nil
+		}
+		rows, err := q.UseUserTotpStep(ctx, database.UseUserTotpStepParams{
+			ID:           user.ID,
+			TotpLastStep: step,
+		})
+		return rows > 0, err
+	}
+
+	rows, err := q.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: password.HashToken(normalizeRecoveryCode(recoveryCode)),
+	})
+	return rows > 0, err
+}
+
+type loginChallengeResponse struct {
+	TwoFactorRequired       bool      `json:"two_factor_required"`
+	ChallengeToken          string    `json:"challenge_token"`
+	ChallengeTokenExpiresAt time.Time `json:"challenge_token_expires_at"`
+}
+
+// Responds with a short-lived challenge token, which is exchanged for the session tokens in loginUser2FA.
+// The token carries no session, so the auth middleware rejects it, and only tokens
+// recorded as login challenges are accepted by loginUser2FA. It carries the requested scopes of the session.
+func (s *Server) startLoginChallenge(c *fiber.Ctx, user database.User, scopes []string) error {
+	challengeToken, challengePayload, err := s.tokenMaker.CreateToken(user.ID, user.Username, user.Email, user.Role, scopes, uuid.Nil, s.config.LoginChallengeDuration)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	_, err = s.db.CreateLoginChallenge(c.Context(), database.CreateLoginChallengeParams{
+		ID:        challengePayload.ID,
+		UserID:    user.ID,
+		ExpiresAt: challengePayload.ExpiredAt,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(loginChallengeResponse{
+		TwoFactorRequired:       true,
+		ChallengeToken:          challengeToken,
+		ChallengeTokenExpiresAt: challengePayload.ExpiredAt,
+	})
+}
+
+// POST REQUESTS
+
+type loginUser2FARequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+func (s *Server) loginUser2FA(c *fiber.Ctx) error {
+	req := new(loginUser2FARequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code or recovery_code is required")
+	}
+
+	challengePayload, err := s.tokenMaker.VerifyToken(req.ChallengeToken)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	challenge, err := s.db.GetLoginChallenge(c.Context(), challengePayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusUnauthorized, "login challenge not found")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if challenge.IsUsed || challenge.UserID != challengePayload.UserID || s.now().After(challenge.ExpiresAt) {
+		return fiber.NewError(fiber.StatusUnauthorized, "login challenge is used or expired")
+	}
+
+	challenge, err = s.db.IncrementLoginChallengeAttempts(c.Context(), challenge.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if challenge.Attempts > maxLoginChallengeAttempts {
+		return fiber.NewError(fiber.StatusUnauthorized, "too many attempts, log in again")
+	}
+
+	user, err := s.db.GetUserByID(c.Context(), challenge.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if user.IsBanned {
+		audit.Record(c, s.db, audit.EventLogin, audit.OutcomeFailure, user.ID, 0, "user is banned")
+		return fiber.NewError(http.StatusForbidden, "user is banned")
+	}
+	if !user.IsTotpEnabled {
+		return fiber.NewError(fiber.StatusUnauthorized, "two-factor authentication is disabled, log in again")
+	}
+
+	// The codes are limited by the same lockout as the passwords
+	now := s.now()
+	accountKey, ipKey := loginAccountKey(user.Email), loginIPKey(c.IP())
+	if err := s.checkLoginLockout(c, now, accountKey, ipKey); err != nil {
+		audit.Record(c, s.db, audit.EventLogin, audit.OutcomeFailure, user.ID, 0, "locked out")
+		return err
+	}
+
+	// The code is burned only if the session is started
+	var tokens *sessionTokens
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		ok, err := s.checkSecondFactor(c.Context(), q, user, req.Code, req.RecoveryCode)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errInvalidSecondFactor
+		}
+
+		// Concurrent requests with the same challenge start one session at most
+		rows, err := q.UseLoginChallenge(c.Context(), challenge.ID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fiber.NewError(fiber.StatusUnauthorized, "login challenge is used or expired")
+		}
+
+		tokens, err = s.createSession(c, q, user, challengePayload.Scopes, sql.NullString{})
+		return err
+	})
+	if err != nil {
+		if err == errInvalidSecondFactor {
+			// The password was right, so it may be known to someone else
+			if err := s.recordLoginFailure(c, now, accountKey, ipKey, user.ID, "wrong two-factor code"); err != nil {
+				return err
+			}
+		}
+		return txError(err)
+	}
+
+	if err := s.resetLoginFailures(c, accountKey); err != nil {
+		return err
+	}
+
+	audit.Record(c, s.db, audit.EventLogin, audit.OutcomeSuccess, user.ID, user.ID, "two-factor")
+	return respondWithSession(c, user, tokens)
+}
+
+type setup2FAResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// Generates a new secret. Two-factor authentication is off until the first code is confirmed with enable2FA.
+func (s *Server) setup2FA(c *fiber.Ctx) error {
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	user, err := s.db.UpdateUserTotpSecret(c.Context(), database.UpdateUserTotpSecretParams{
+		ID:         authPayload.UserID,
+		TotpSecret: secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusConflict, "two-factor authentication is enabled already")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(setup2FAResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(s.config.TOTPIssuer, user.Email, secret),
+	})
+}
+
+type enable2FARequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type enable2FAResponse struct {
+	RecoveryCodes []string     `json:"recovery_codes"`
+	User          userResponse `json:"user"`
+}
+
+// Confirms the setup with a code from the authenticator app and responds with recovery codes.
+// The codes are shown only once.
+func (s *Server) enable2FA(c *fiber.Ctx) error {
+	req := new(enable2FARequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	user, err := s.db.GetUserByID(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if user.IsTotpEnabled {
+		return fiber.NewError(fiber.StatusConflict, "two-factor authentication is enabled already")
+	}
+	if user.TotpSecret == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "two-factor authentication is not set up")
+	}
+
+	step, ok := totp.Validate(user.TotpSecret, req.Code, s.now())
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid code")
+	}
+
+	// Two-factor authentication is never on without the recovery codes
+	var codes []string
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		user, err = q.EnableUserTotp(c.Context(), database.EnableUserTotpParams{
+			ID:           user.ID,
+			TotpLastStep: step,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fiber.NewError(fiber.StatusConflict, "two-factor authentication is enabled already")
+			}
+			return err
+		}
+
+		codes, err = resetRecoveryCodes(c.Context(), q, user.ID)
+		return err
+	})
+	if err != nil {
+		return txError(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(enable2FAResponse{
+		RecoveryCodes: codes,
+		User:          newUserResponse(user),
+	})
+}
+
+type disable2FARequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (s *Server) disable2FA(c *fiber.Ctx) error {
+	req := new(disable2FARequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code or recovery_code is required")
+	}
+
+	user, err := s.db.GetUserByID(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if !user.IsTotpEnabled {
+		return fiber.NewError(fiber.StatusBadRequest, "two-factor authentication is not enabled")
+	}
+
+	err = s.hasher.Check(req.Password, user.HashedPassword)
+	if err != nil {
+		return fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		ok, err := s.checkSecondFactor(c.Context(), q, user, req.Code, req.RecoveryCode)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errInvalidSecondFactor
+		}
+
+		user, err = q.DisableUserTotp(c.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+		return q.DeleteUserRecoveryCodes(c.Context(), user.ID)
+	})
+	if err != nil {
+		return txError(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
+}
@@ -0,0 +1,17 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Returns the error of a transaction as a fiber error.
+// Handlers stop transactions with fiber errors to respond with them, any other error is an internal one.
+func txError(err error) error {
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+}
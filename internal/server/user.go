@@ -1,31 +1,38 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
 	"github.com/abc_valera/flugo/internal/utils/middleware"
-	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/scope"
 	"github.com/abc_valera/flugo/internal/utils/token"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // UserResponse type is returned back with response. It omits unnecessary data from the database's user type.
 type userResponse struct {
-	ID        int32     `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Avatar    string    `json:"avatar"`
-	Fullname  string    `json:"fullname"`
-	Bio       string    `json:"bio"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int32     `json:"id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	IsTotpEnabled   bool      `json:"is_totp_enabled"`
+	Avatar          string    `json:"avatar"`
+	Fullname        string    `json:"fullname"`
+	Bio             string    `json:"bio"`
+	Status          string    `json:"status"`
+	Role            string    `json:"role"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // Returns new UserResponse from default user type
@@ -34,10 +41,13 @@ func newUserResponse(user database.User) userResponse {
 		user.ID,
 		user.Username,
 		user.Email,
+		user.IsEmailVerified,
+		user.IsTotpEnabled,
 		user.Avatar,
 		user.Fullname,
 		user.Bio,
 		user.Status,
+		user.Role,
 		user.CreatedAt,
 		user.UpdatedAt,
 	}
@@ -46,7 +56,7 @@ func newUserResponse(user database.User) userResponse {
 // POST REQUESTS
 
 type createUserRequest struct {
-	Username string `json:"username" validate:"required"`
+	Username string `json:"username" validate:"required,username"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
@@ -60,7 +70,19 @@ func (s *Server) createUser(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	hashedPassword, err := password.HashPassword(req.Password)
+	if err := s.policy.Check(req.Password, req.Username, req.Email); err != nil {
+		return err
+	}
+
+	reserved, err := s.isUsernameReserved(c.Context(), s.db, req.Username, 0)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if reserved {
+		return errUsernameTaken
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -71,21 +93,33 @@ func (s *Server) createUser(c *fiber.Ctx) error {
 		HashedPassword: hashedPassword,
 	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fiber.NewError(fiber.StatusConflict, "username or email is taken")
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	s.sendVerifyEmailOrLog(c.Context(), user)
+
 	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
 }
 
 type loginUserRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// Limits the tokens to fewer scopes than the role allows. The full set is granted if it is empty.
+	Scopes []string `json:"scopes"`
 }
 
 type loginUserResponse struct {
-	TokenType   string       `json:"token_type"`
-	AccessToken string       `json:"access_token"`
-	User        userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	TokenType             string       `json:"token_type"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	Scopes                []string     `json:"scopes"`
+	User                  userResponse `json:"user"`
 }
 
 func (s *Server) loginUser(c *fiber.Ctx) error {
@@ -97,56 +131,156 @@ func (s *Server) loginUser(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
+	now := s.now()
+	accountKey, ipKey := loginAccountKey(req.Email), loginIPKey(c.IP())
+	if err := s.checkLoginLockout(c, now, accountKey, ipKey); err != nil {
+		audit.Record(c, s.db, audit.EventLogin, audit.OutcomeFailure, 0, 0, "locked out")
+		return err
+	}
+
+	// Unknown emails and wrong passwords get the same response,
+	// so the login can't be used to find out which emails are registered
 	user, err := s.db.GetUserByEmail(c.Context(), req.Email)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		if err != sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		s.hasher.Check(req.Password, s.dummyHash)
+		return s.failLogin(c, now, accountKey, ipKey, 0, "unknown email")
 	}
 
-	err = password.CheckPassword(req.Password, user.HashedPassword)
+	err = s.hasher.Check(req.Password, user.HashedPassword)
 	if err != nil {
-		return fiber.NewError(http.StatusUnauthorized, err.Error())
+		return s.failLogin(c, now, accountKey, ipKey, user.ID, "wrong password")
+	}
+	if user.IsBanned {
+		audit.Record(c, s.db, audit.EventLogin, audit.OutcomeFailure, user.ID, 0, "user is banned")
+		return fiber.NewError(http.StatusForbidden, "user is banned")
 	}
+	s.rehashPasswordOrLog(c.Context(), user, req.Password)
 
-	accessToken, err := s.tokenMaker.CreateToken(user.ID, user.Username, user.Email, s.config.AccessTokenDuration)
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	// Requested scopes are stored as is, nil keeps the session at the full scope set of the role
+	var scopes []string
+	if len(req.Scopes) > 0 {
+		scopes, err = scope.Reduce(req.Scopes, scope.ForRole(user.Role))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(loginUserResponse{
-		TokenType:   middleware.AuthTypeBearer,
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
-	})
+	// The password is only the first factor, the session is started once the code is checked.
+	// The failures of the account are kept till then.
+	if user.IsTotpEnabled {
+		return s.startLoginChallenge(c, user, scopes)
+	}
+
+	if err := s.resetLoginFailures(c, accountKey); err != nil {
+		return err
+	}
+	audit.Record(c, s.db, audit.EventLogin, audit.OutcomeSuccess, user.ID, user.ID, "")
+	return s.startSession(c, user, scopes)
 }
 
-// GET REQUESTS
+// Replaces the stored hash if it was made with an outdated algorithm or parameters.
+// The plain password is only known on login, so it is the only place the hash can be upgraded.
+// Failure is logged instead of failing the login: the old hash keeps working.
+func (s *Server) rehashPasswordOrLog(ctx context.Context, user database.User, plainPassword string) {
+	if !s.hasher.NeedsRehash(user.HashedPassword) {
+		return
+	}
 
-type verifyEmailRequest struct {
-	Email string `json:"email" validate:"required,email"`
+	hashedPassword, err := s.hasher.Hash(plainPassword)
+	if err == nil {
+		_, err = s.db.RehashUserPassword(ctx, database.RehashUserPasswordParams{
+			ID:                user.ID,
+			OldHashedPassword: user.HashedPassword,
+			NewHashedPassword: hashedPassword,
+		})
+	}
+	if err != nil {
+		log.Printf("cannot rehash password of user %d: %v", user.ID, err)
+	}
 }
 
-func (s *Server) verifyEmail(c *fiber.Ctx) error {
-	req := new(verifyEmailRequest)
-	if err := c.BodyParser(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+// Tokens of a new session
+type sessionTokens struct {
+	session        database.Session
+	accessToken    string
+	accessPayload  *token.Payload
+	refreshToken   string
+	refreshPayload *token.Payload
+}
+
+// Creates a session for the user who has passed authentication, either by logging in or through an OAuth client.
+// The tokens get the given scopes, or the full scope set of the role if they are nil.
+// The session is written with q, so it can be a part of a transaction.
+func (s *Server) createSession(c *fiber.Ctx, q database.Querier, user database.User, scopes []string, clientID sql.NullString) (*sessionTokens, error) {
+	granted := scope.Granted(scopes, user.Role)
+
+	// Deactivated users come back by logging in
+	if user.IsDeactivated {
+		_, err := q.UpdateUserDeactivated(c.Context(), database.UpdateUserDeactivatedParams{
+			ID:            user.ID,
+			IsDeactivated: false,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
-	if err := s.validator.Validate(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.ID, user.Username, user.Email, user.Role, granted, uuid.Nil, s.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := q.CreateSession(c.Context(), database.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		UserID:       user.ID,
+		RefreshToken: refreshToken,
+		UserAgent:    c.Get(fiber.HeaderUserAgent),
+		ClientIp:     c.IP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+		Scopes:       scopes,
+		ClientID:     clientID,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := s.db.GetUserByEmail(c.Context(), req.Email)
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.ID, user.Username, user.Email, user.Role, granted, session.ID, s.config.AccessTokenDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionTokens{session, accessToken, accessPayload, refreshToken, refreshPayload}, nil
+}
+
+// Starts a session for the logged in user and responds with its tokens
+func (s *Server) startSession(c *fiber.Ctx, user database.User, scopes []string) error {
+	tokens, err := s.createSession(c, s.db, user, scopes, sql.NullString{})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fiber.NewError(fiber.StatusOK, "Email is not registered yet")
-		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
-	return fiber.NewError(fiber.StatusBadRequest, "Email is registered already")
+	return respondWithSession(c, user, tokens)
 }
 
+// Responds with the tokens of the new session
+func respondWithSession(c *fiber.Ctx, user database.User, tokens *sessionTokens) error {
+	return c.Status(fiber.StatusCreated).JSON(loginUserResponse{
+		SessionID:             tokens.session.ID,
+		TokenType:             middleware.AuthTypeBearer,
+		AccessToken:           tokens.accessToken,
+		AccessTokenExpiresAt:  tokens.accessPayload.ExpiredAt,
+		RefreshToken:          tokens.refreshToken,
+		RefreshTokenExpiresAt: tokens.refreshPayload.ExpiredAt,
+		Scopes:                tokens.accessPayload.Scopes,
+		User:                  newUserResponse(user),
+	})
+}
+
+// GET REQUESTS
+
 func (s *Server) listUsers(c *fiber.Ctx) error {
 	first, err := strconv.Atoi(c.Query("first"))
 	if err != nil {
@@ -202,11 +336,16 @@ func (s *Server) updateUserPassword(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
-	if err := password.CheckPassword(req.OldPassword, oldUser.HashedPassword); err != nil {
+	if err := s.hasher.Check(req.OldPassword, oldUser.HashedPassword); err != nil {
+		audit.Record(c, s.db, audit.EventPasswordChange, audit.OutcomeFailure, sessID, sessID, "wrong old password")
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	hashedPassword, err := password.HashPassword(req.NewPassword)
+	if err := s.policy.Check(req.NewPassword, oldUser.Username, oldUser.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
@@ -218,6 +357,7 @@ func (s *Server) updateUserPassword(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
+	audit.Record(c, s.db, audit.EventPasswordChange, audit.OutcomeSuccess, sessID, sessID, "")
 
 	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
 }
@@ -243,6 +383,7 @@ func (s *Server) updateUserAvatar(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+	audit.Record(c, s.db, audit.EventAvatarChange, audit.OutcomeSuccess, userID, userID, "")
 
 	return c.Status(fiber.StatusCreated).JSON(user)
 }
@@ -310,6 +451,49 @@ func (s *Server) updateUserBio(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(newUserResponse(user))
 }
 
+type deactivateUserRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// Hides the user from the search and logs them out everywhere until they log in again
+func (s *Server) deactivateUser(c *fiber.Ctx) error {
+	req := new(deactivateUserRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	userID := c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID
+	user, err := s.db.GetUserByID(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := s.hasher.Check(req.Password, user.HashedPassword); err != nil {
+		audit.Record(c, s.db, audit.EventAccountDeactivate, audit.OutcomeFailure, user.ID, user.ID, "wrong password")
+		return fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		_, err := q.UpdateUserDeactivated(c.Context(), database.UpdateUserDeactivatedParams{
+			ID:            user.ID,
+			IsDeactivated: true,
+		})
+		if err != nil {
+			return err
+		}
+		return q.BlockUserSessions(c.Context(), user.ID)
+	})
+	if err != nil {
+		return txError(err)
+	}
+	audit.Record(c, s.db, audit.EventAccountDeactivate, audit.OutcomeSuccess, user.ID, user.ID, "")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
 // DELETE REQUESTS
 
 type deleteUserRequest struct {
@@ -331,29 +515,32 @@ func (s *Server) deleteUser(c *fiber.Ctx) error {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
 
-	if err := password.CheckPassword(req.Password, user.HashedPassword); err != nil {
+	if err := s.hasher.Check(req.Password, user.HashedPassword); err != nil {
+		audit.Record(c, s.db, audit.EventAccountDelete, audit.OutcomeFailure, user.ID, user.ID, "wrong password")
 		return fiber.NewError(http.StatusUnauthorized, err.Error())
 	}
 
-	err = s.db.DeleteJokesByAuthor(c.Context(), user.Username)
-	if err != nil {
-		return fiber.NewError(http.StatusInternalServerError, err.Error())
-	}
-	err = s.db.DeleteUser(c.Context(), int32(sessID))
+	// Jokes and reactions of the user are deleted along with it,
+	// the reactions are taken out of the counters of the jokes first
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		err := q.RemoveUserReactionCounts(c.Context(), int32(sessID))
+		if err != nil {
+			return err
+		}
+		return q.DeleteUser(c.Context(), int32(sessID))
+	})
 	if err != nil {
-		return fiber.NewError(http.StatusInternalServerError, err.Error())
+		return txError(err)
 	}
+	// Events outlive the account, so it can be traced back later
+	audit.Record(c, s.db, audit.EventAccountDelete, audit.OutcomeSuccess, user.ID, user.ID, user.Username)
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
 // !DANGEROUS FUNCTION FOR TEST ONLY!
 func (s *Server) deleteAllUsers(c *fiber.Ctx) error {
-	err := s.db.DeleteAllJokes(c.Context())
-	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-	}
-	err = s.db.DeleteAllUsers(c.Context())
+	err := s.db.DeleteAllUsers(c.Context())
 	if err != nil {
 		return fiber.NewError(http.StatusInternalServerError, err.Error())
 	}
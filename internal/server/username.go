@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+)
+
+var errUsernameTaken = fiber.NewError(fiber.StatusConflict, "username is taken")
+
+// Checks if the error is a violation of a unique constraint
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Checks if the error is a violation of a foreign key constraint
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// Checks if the username is an old username of another user, still in its grace period.
+// Zero userID is used for new users.
+func (s *Server) isUsernameReserved(ctx context.Context, q database.Querier, username string, userID int32) (bool, error) {
+	return q.IsUsernameReserved(ctx, database.IsUsernameReservedParams{
+		OldUsername: username,
+		ChangedAt:   s.now().Add(-s.config.OldUsernameGracePeriod),
+		UserID:      userID,
+	})
+}
+
+// Returns the current username of the user who had the given one within the grace period.
+// ok is false if the username is in use or wasn't changed.
+func (s *Server) renamedUsername(ctx context.Context, username string) (newUsername string, ok bool, err error) {
+	_, err = s.db.GetUserByName(ctx, username)
+	if err != sql.ErrNoRows {
+		return "", false, err
+	}
+
+	newUsername, err = s.db.GetRenamedUsername(ctx, database.GetRenamedUsernameParams{
+		OldUsername: username,
+		ChangedAt:   s.now().Add(-s.config.OldUsernameGracePeriod),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return newUsername, true, nil
+}
+
+// PUT REQUESTS
+
+type updateUsernameRequest struct {
+	Username string `json:"username" validate:"required,username"`
+}
+
+type updateUsernameResponse struct {
+	AccessToken          string       `json:"access_token"`
+	AccessTokenExpiresAt time.Time    `json:"access_token_expires_at"`
+	User                 userResponse `json:"user"`
+}
+
+// Changes the username at most once per cooldown. Tokens are checked by the user id, so the issued ones keep working.
+// The access token of the request is replaced with one that carries the new username,
+// the tokens of other sessions get it once they are renewed.
+func (s *Server) updateUsername(c *fiber.Ctx) error {
+	req := new(updateUsernameRequest)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err := s.validator.Validate(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	authPayload := c.Locals(middleware.AuthPayloadKey).(*token.Payload)
+	now := s.now()
+
+	user, err := s.db.GetUserByID(c.Context(), authPayload.UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if user.Username == req.Username {
+		return fiber.NewError(fiber.StatusBadRequest, "it is your username already")
+	}
+
+	// The checks run in the transaction too, so concurrent changes can't skip the cooldown or take the same username
+	var updatedUser database.User
+	err = s.db.ExecTx(c.Context(), func(q database.Querier) error {
+		lastChange, err := q.GetLastUsernameChange(c.Context(), user.ID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if nextChange := lastChange.Add(s.config.UsernameChangeCooldown); err == nil && now.Before(nextChange) {
+			retryAfter := int(math.Ceil(nextChange.Sub(now).Seconds()))
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return fiber.NewError(fiber.StatusTooManyRequests, "username was changed recently, try again later")
+		}
+
+		reserved, err := s.isUsernameReserved(c.Context(), q, req.Username, user.ID)
+		if err != nil {
+			return err
+		}
+		if reserved {
+			return errUsernameTaken
+		}
+
+		updatedUser, err = q.UpdateUsername(c.Context(), database.UpdateUsernameParams{
+			ID:       user.ID,
+			Username: req.Username,
+		})
+		if err != nil {
+			if isUniqueViolation(err) {
+				return errUsernameTaken
+			}
+			return err
+		}
+		return q.CreateUsernameHistory(c.Context(), database.CreateUsernameHistoryParams{
+			UserID:      user.ID,
+			OldUsername: user.Username,
+			ChangedAt:   now,
+		})
+	})
+	if err != nil {
+		return txError(err)
+	}
+	audit.Record(c, s.db, audit.EventUsernameChange, audit.OutcomeSuccess, user.ID, user.ID, user.Username)
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
+		updatedUser.ID,
+		updatedUser.Username,
+		updatedUser.Email,
+		updatedUser.Role,
+		authPayload.Scopes,
+		authPayload.SessionID,
+		s.config.AccessTokenDuration,
+	)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(updateUsernameResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		User:                 newUserResponse(updatedUser),
+	})
+}
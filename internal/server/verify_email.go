@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/middleware"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+const verifyEmailSubject = "Welcome to Flugo"
+
+// Creates a one-time verification code for the user's email and sends the verification link to it.
+// Only the hash of the code is stored, like the password reset tokens.
+func (s *Server) sendVerifyEmail(ctx context.Context, user database.User) error {
+	secretCode, err := random.SecureString(32)
+	if err != nil {
+		return err
+	}
+
+	verifyEmail, err := s.db.CreateVerifyEmail(ctx, database.CreateVerifyEmailParams{
+		UserID:    user.ID,
+		Email:     user.Email,
+		CodeHash:  password.HashToken(secretCode),
+		ExpiredAt: time.Now().Add(s.config.VerifyEmailDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/users/verify_email?%s", s.config.AppURL, url.Values{
+		"id":   {strconv.FormatInt(verifyEmail.ID, 10)},
+		"code": {secretCode},
+	}.Encode())
+	content := fmt.Sprintf(
+		"Hello %s,\n\nThank you for registering on Flugo!\nPlease verify your email address by following this link:\n%s\n\nThe link expires in %s.",
+		user.Username, link, s.config.VerifyEmailDuration,
+	)
+
+	return s.mailer.SendEmail([]string{user.Email}, verifyEmailSubject, content)
+}
+
+// Logs instead of failing: the user is created already and can ask for another email
+func (s *Server) sendVerifyEmailOrLog(ctx context.Context, user database.User) {
+	if err := s.sendVerifyEmail(ctx, user); err != nil {
+		log.Printf("cannot send verification email to user %d: %v", user.ID, err)
+	}
+}
+
+// requireVerifiedEmail rejects users who haven't verified their email yet.
+// It does nothing unless REQUIRE_VERIFIED_EMAIL is on.
+func (s *Server) requireVerifiedEmail(c *fiber.Ctx) error {
+	if !s.config.RequireVerifiedEmail {
+		return c.Next()
+	}
+
+	user, err := s.db.GetUserByID(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if !user.IsEmailVerified {
+		return fiber.NewError(http.StatusForbidden, "email is not verified")
+	}
+	return c.Next()
+}
+
+// POST REQUESTS
+
+func (s *Server) resendVerifyEmail(c *fiber.Ctx) error {
+	user, err := s.db.GetUserByID(c.Context(), c.Locals(middleware.AuthPayloadKey).(*token.Payload).UserID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if user.IsEmailVerified {
+		return fiber.NewError(http.StatusBadRequest, "email is verified already")
+	}
+
+	if err := s.sendVerifyEmail(c.Context(), user); err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// GET REQUESTS
+
+func (s *Server) verifyEmailCode(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Query("id"), 10, 64)
+	if id <= 0 || err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong verification id")
+	}
+	code := c.Query("code")
+	if code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Provided wrong verification code")
+	}
+
+	user, err := s.db.VerifyEmail(c.Context(), database.VerifyEmailParams{
+		ID:       id,
+		CodeHash: password.HashToken(code),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fiber.NewError(fiber.StatusBadRequest, "verification link is invalid, used or expired")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(newUserResponse(user))
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Types of the recorded events
+const (
+	EventLogin             = "login"
+	EventAuthFailure       = "auth_failure"
+	EventPasswordChange    = "password_change"
+	EventUsernameChange    = "username_change"
+	EventPasswordReset     = "password_reset"
+	EventAvatarChange      = "avatar_change"
+	EventAccountDelete     = "account_delete"
+	EventAccountDeactivate = "account_deactivate"
+	EventRoleChange        = "role_change"
+	EventBan               = "ban"
+	EventUnban             = "unban"
+	EventLockoutClear      = "lockout_clear"
+)
+
+// Outcomes of the recorded events
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Longer user agents are cut, so a client can't bloat the log
+const maxUserAgentLength = 512
+
+// Returns the user agent as text Postgres accepts, cut to maxUserAgentLength bytes.
+// Clients can send any bytes, so invalid UTF-8 and NUL, which text can't hold, are replaced.
+func cleanUserAgent(userAgent string) string {
+	userAgent = strings.ReplaceAll(strings.ToValidUTF8(userAgent, "\uFFFD"), "\x00", "\uFFFD")
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+	// Cut before the rune the limit falls into
+	end := maxUserAgentLength
+	for end > 0 && !utf8.RuneStart(userAgent[end]) {
+		end--
+	}
+	return userAgent[:end]
+}
+
+// Checks if the type is one of the known event types
+func IsValidType(t string) bool {
+	switch t {
+	case EventLogin, EventAuthFailure, EventPasswordChange, EventUsernameChange, EventPasswordReset, EventAvatarChange,
+		EventAccountDelete, EventAccountDeactivate, EventRoleChange, EventBan, EventUnban, EventLockoutClear:
+		return true
+	}
+	return false
+}
+
+// EventWriter is the part of database.Queries that writes audit events
+type EventWriter interface {
+	CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error
+}
+
+// Record writes the event of the request with its IP and user agent.
+// userID is the account the event is about and actorID is the user who has done it,
+// zero is stored as NULL for unknown users.
+// Failure to write is logged instead of failing the request it records.
+func Record(c *fiber.Ctx, w EventWriter, eventType, outcome string, userID, actorID int32, details string) {
+	err := w.CreateAuditEvent(c.Context(), database.CreateAuditEventParams{
+		Type:      eventType,
+		Outcome:   outcome,
+		UserID:    sql.NullInt32{Int32: userID, Valid: userID != 0},
+		ActorID:   sql.NullInt32{Int32: actorID, Valid: actorID != 0},
+		ClientIp:  c.IP(),
+		UserAgent: cleanUserAgent(c.Get(fiber.HeaderUserAgent)),
+		Details:   details,
+	})
+	if err != nil {
+		log.Printf("cannot record %s audit event: %v", eventType, err)
+	}
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWriter struct {
+	events []database.CreateAuditEventParams
+}
+
+func (w *fakeWriter) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	w.events = append(w.events, arg)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	w := new(fakeWriter)
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		Record(c, w, EventLogin, OutcomeFailure, 7, 0, "wrong password")
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", strings.Repeat("a", 1000))
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	require.Len(t, w.events, 1)
+	event := w.events[0]
+	require.Equal(t, EventLogin, event.Type)
+	require.Equal(t, OutcomeFailure, event.Outcome)
+	require.True(t, event.UserID.Valid)
+	require.Equal(t, int32(7), event.UserID.Int32)
+	// Unknown actor is stored as NULL
+	require.False(t, event.ActorID.Valid)
+	require.NotEmpty(t, event.ClientIp)
+	require.Len(t, event.UserAgent, maxUserAgentLength)
+	require.Equal(t, "wrong password", event.Details)
+
+	// Invalid UTF-8 would fail the insert
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("User-Agent", "agent\xff\xfe")
+	_, err = app.Test(req)
+	require.NoError(t, err)
+	require.Len(t, w.events, 2)
+	require.Equal(t, "agent\uFFFD", w.events[1].UserAgent)
+}
+
+func TestCleanUserAgent(t *testing.T) {
+	require.Equal(t, "curl/8.0", cleanUserAgent("curl/8.0"))
+	require.Equal(t, "bad \uFFFD byte \uFFFD nul", cleanUserAgent("bad \xff byte \x00 nul"))
+
+	// Two-byte runes crossing the limit aren't cut in half
+	userAgent := cleanUserAgent("a" + strings.Repeat("é", maxUserAgentLength))
+	require.True(t, utf8.ValidString(userAgent))
+	require.Len(t, userAgent, maxUserAgentLength-1)
+
+	userAgent = cleanUserAgent(strings.Repeat("\xff", maxUserAgentLength))
+	require.True(t, utf8.ValidString(userAgent))
+	require.LessOrEqual(t, len(userAgent), maxUserAgentLength)
+}
+
+func TestIsValidType(t *testing.T) {
+	require.True(t, IsValidType(EventLogin))
+	require.True(t, IsValidType(EventAuthFailure))
+	require.False(t, IsValidType("unknown"))
+	require.False(t, IsValidType(""))
+}
@@ -14,11 +14,49 @@ const (
 // Contains all configuration variables
 // The values are read from api.env file
 type Config struct {
-	PORT                string        `mapstructure:"PORT"`
-	DatabaseDriver      string        `mapstructure:"DATABASE_DRIVER"`
-	DatabaseUrl         string        `mapstructure:"DATABASE_URL"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	PORT                   string        `mapstructure:"PORT"`
+	ProxyHeader            string        `mapstructure:"PROXY_HEADER"`
+	TrustedProxies         []string      `mapstructure:"TRUSTED_PROXIES"`
+	DatabaseDriver         string        `mapstructure:"DATABASE_DRIVER"`
+	DatabaseUrl            string        `mapstructure:"DATABASE_URL"`
+	AutoMigrate            bool          `mapstructure:"AUTO_MIGRATE"`
+	TokenMaker             string        `mapstructure:"TOKEN_MAKER"`
+	TokenSymmetricKey      string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenAsymmetricKey     string        `mapstructure:"TOKEN_ASYMMETRIC_KEY"`
+	TokenPrivateKeyFile    string        `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`
+	TokenPublicKeyFiles    []string      `mapstructure:"TOKEN_PUBLIC_KEY_FILES"`
+	PasswordHasher         string        `mapstructure:"PASSWORD_HASHER"`
+	Argon2Memory           uint32        `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations       uint32        `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism      uint8         `mapstructure:"ARGON2_PARALLELISM"`
+	BcryptCost             int           `mapstructure:"BCRYPT_COST"`
+	PasswordMinLength      int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength      int           `mapstructure:"PASSWORD_MAX_LENGTH"`
+	PasswordMinCharClasses int           `mapstructure:"PASSWORD_MIN_CHAR_CLASSES"`
+	PasswordBlocklistFile  string        `mapstructure:"PASSWORD_BLOCKLIST_FILE"`
+	LoginTracker           string        `mapstructure:"LOGIN_TRACKER"`
+	LoginFreeAttempts      int           `mapstructure:"LOGIN_FREE_ATTEMPTS"`
+	LoginIPFreeAttempts    int           `mapstructure:"LOGIN_IP_FREE_ATTEMPTS"`
+	LoginLockoutBaseDelay  time.Duration `mapstructure:"LOGIN_LOCKOUT_BASE_DELAY"`
+	LoginLockoutMaxDelay   time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX_DELAY"`
+	LoginAttemptsWindow    time.Duration `mapstructure:"LOGIN_ATTEMPTS_WINDOW"`
+	OAuthCodeDuration      time.Duration `mapstructure:"OAUTH_CODE_DURATION"`
+	AccessTokenDuration    time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration   time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	AdminEmail             string        `mapstructure:"ADMIN_EMAIL"`
+	AppURL                 string        `mapstructure:"APP_URL"`
+	SMTPHost               string        `mapstructure:"SMTP_HOST"`
+	SMTPPort               int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername           string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword           string        `mapstructure:"SMTP_PASSWORD"`
+	EmailSenderAddress     string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	VerifyEmailDuration    time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	PasswordResetDuration  time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	RequireVerifiedEmail   bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	TOTPIssuer             string        `mapstructure:"TOTP_ISSUER"`
+	LoginChallengeDuration time.Duration `mapstructure:"LOGIN_CHALLENGE_DURATION"`
+	UsernameChangeCooldown time.Duration `mapstructure:"USERNAME_CHANGE_COOLDOWN"`
+	OldUsernameGracePeriod time.Duration `mapstructure:"OLD_USERNAME_GRACE_PERIOD"`
 }
 
 func LoadConfig(path string) (Config, error) {
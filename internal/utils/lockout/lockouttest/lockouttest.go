@@ -0,0 +1,95 @@
+// Package lockouttest holds the checks every lockout.Tracker implementation has to pass
+package lockouttest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/lockout"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/stretchr/testify/require"
+)
+
+// Policy is the policy the trackers under test have to be created with
+var Policy = lockout.Policy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     10 * time.Second,
+	Window:       time.Hour,
+}
+
+// TestTracker runs the checks against the trackers made by newTracker.
+// The keys are random, so a tracker may keep its attempts between the runs.
+func TestTracker(t *testing.T, newTracker func(t *testing.T) lockout.Tracker) {
+	ctx := context.Background()
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Backoff", func(t *testing.T) {
+		tracker := newTracker(t)
+		key, other := randomKey(), randomKey()
+
+		for i := 0; i < Policy.FreeAttempts; i++ {
+			lockedUntil, err := tracker.Fail(ctx, key, now)
+			require.NoError(t, err)
+			require.False(t, lockedUntil.After(now))
+		}
+
+		lockedUntil, err := tracker.Fail(ctx, key, now)
+		require.NoError(t, err)
+		require.WithinDuration(t, now.Add(time.Second), lockedUntil, 0)
+
+		lockedUntil, err = tracker.Fail(ctx, key, now)
+		require.NoError(t, err)
+		require.WithinDuration(t, now.Add(2*time.Second), lockedUntil, 0)
+
+		lockedUntil, err = tracker.LockedUntil(ctx, key, now)
+		require.NoError(t, err)
+		require.WithinDuration(t, now.Add(2*time.Second), lockedUntil, 0)
+
+		// Other keys are not affected
+		lockedUntil, err = tracker.LockedUntil(ctx, other, now)
+		require.NoError(t, err)
+		require.False(t, lockedUntil.After(now))
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		tracker := newTracker(t)
+		key := randomKey()
+
+		for i := 0; i <= Policy.FreeAttempts; i++ {
+			_, err := tracker.Fail(ctx, key, now)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tracker.Reset(ctx, key))
+
+		lockedUntil, err := tracker.LockedUntil(ctx, key, now)
+		require.NoError(t, err)
+		require.False(t, lockedUntil.After(now))
+
+		// The counter starts over
+		lockedUntil, err = tracker.Fail(ctx, key, now)
+		require.NoError(t, err)
+		require.False(t, lockedUntil.After(now))
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		tracker := newTracker(t)
+		key := randomKey()
+
+		for i := 0; i < Policy.FreeAttempts; i++ {
+			_, err := tracker.Fail(ctx, key, now)
+			require.NoError(t, err)
+		}
+
+		// Failures older than the window are forgotten
+		later := now.Add(Policy.Window + time.Minute)
+		lockedUntil, err := tracker.Fail(ctx, key, later)
+		require.NoError(t, err)
+		require.False(t, lockedUntil.After(later))
+	})
+}
+
+func randomKey() string {
+	return "test:" + random.RandomString(12)
+}
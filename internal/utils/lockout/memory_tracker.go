@@ -0,0 +1,83 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type attempt struct {
+	failures     int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+}
+
+// MemoryTracker keeps the attempts in memory. Each server instance counts its own attempts,
+// so PostgresTracker should be used when there are several of them.
+type MemoryTracker struct {
+	policy Policy
+
+	mu        sync.Mutex
+	attempts  map[string]*attempt
+	lastSweep time.Time
+}
+
+func NewMemoryTracker(policy Policy) Tracker {
+	return &MemoryTracker{
+		policy:   policy,
+		attempts: make(map[string]*attempt),
+	}
+}
+
+func (t *MemoryTracker) LockedUntil(_ context.Context, key string, _ time.Time) (time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if a, ok := t.attempts[key]; ok {
+		return a.lockedUntil, nil
+	}
+	return time.Time{}, nil
+}
+
+func (t *MemoryTracker) Fail(_ context.Context, key string, now time.Time) (time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	a, ok := t.attempts[key]
+	if !ok || a.lastFailedAt.Before(now.Add(-t.policy.Window)) {
+		a = &attempt{}
+		t.attempts[key] = a
+	}
+	a.failures++
+	a.lastFailedAt = now
+
+	if lockedUntil := now.Add(t.policy.Delay(a.failures)); lockedUntil.After(a.lockedUntil) {
+		a.lockedUntil = lockedUntil
+	}
+	return a.lockedUntil, nil
+}
+
+func (t *MemoryTracker) Reset(_ context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.attempts, key)
+	return nil
+}
+
+// Drops the attempts that are neither counted nor locked anymore, at most once per window
+func (t *MemoryTracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < t.policy.Window {
+		return
+	}
+	t.lastSweep = now
+
+	windowStart := now.Add(-t.policy.Window)
+	for key, a := range t.attempts {
+		if a.lastFailedAt.Before(windowStart) && a.lockedUntil.Before(now) {
+			delete(t.attempts, key)
+		}
+	}
+}
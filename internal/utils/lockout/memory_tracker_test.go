@@ -0,0 +1,14 @@
+package lockout_test
+
+import (
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/utils/lockout"
+	"github.com/abc_valera/flugo/internal/utils/lockout/lockouttest"
+)
+
+func TestMemoryTracker(t *testing.T) {
+	lockouttest.TestTracker(t, func(t *testing.T) lockout.Tracker {
+		return lockout.NewMemoryTracker(lockouttest.Policy)
+	})
+}
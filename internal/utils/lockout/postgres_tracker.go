@@ -0,0 +1,98 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+)
+
+// AttemptStore is the part of database.Queries the PostgresTracker uses
+type AttemptStore interface {
+	GetLoginAttempt(ctx context.Context, key string) (database.LoginAttempt, error)
+	RecordLoginFailure(ctx context.Context, arg database.RecordLoginFailureParams) (database.LoginAttempt, error)
+	LockLoginAttempt(ctx context.Context, arg database.LockLoginAttemptParams) error
+	DeleteLoginAttempt(ctx context.Context, key string) error
+	DeleteStaleLoginAttempts(ctx context.Context, before time.Time) error
+}
+
+// PostgresTracker keeps the attempts in the login_attempts table, so they are shared by all server instances
+type PostgresTracker struct {
+	policy Policy
+	store  AttemptStore
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresTracker(store AttemptStore, policy Policy) Tracker {
+	return &PostgresTracker{
+		policy: policy,
+		store:  store,
+	}
+}
+
+func (t *PostgresTracker) LockedUntil(ctx context.Context, key string, _ time.Time) (time.Time, error) {
+	a, err := t.store.GetLoginAttempt(ctx, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return a.LockedUntil, nil
+}
+
+func (t *PostgresTracker) Fail(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	t.sweep(ctx, now)
+
+	// The counter is incremented atomically, so concurrent failures are all counted
+	a, err := t.store.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    now,
+		WindowStart: now.Add(-t.policy.Window),
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	delay := t.policy.Delay(int(a.Failures))
+	if delay == 0 {
+		return a.LockedUntil, nil
+	}
+
+	lockedUntil := now.Add(delay)
+	err = t.store.LockLoginAttempt(ctx, database.LockLoginAttemptParams{
+		Key:         key,
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if a.LockedUntil.After(lockedUntil) {
+		return a.LockedUntil, nil
+	}
+	return lockedUntil, nil
+}
+
+func (t *PostgresTracker) Reset(ctx context.Context, key string) error {
+	return t.store.DeleteLoginAttempt(ctx, key)
+}
+
+// Deletes the rows that are neither counted nor locked anymore, at most once per window per instance
+func (t *PostgresTracker) sweep(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	if now.Sub(t.lastSweep) < t.policy.Window {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSweep = now
+	t.mu.Unlock()
+
+	if err := t.store.DeleteStaleLoginAttempts(ctx, now.Add(-t.policy.Window)); err != nil {
+		log.Printf("cannot delete stale login attempts: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Different types of trackers
+const (
+	TrackerMemory   = "memory"
+	TrackerPostgres = "postgres"
+)
+
+// Tracker counts failed attempts per key, like an email or an IP address,
+// and locks the key out for longer after each failure over the free attempts.
+// Times are passed in by the callers, so the trackers can be tested with a fixed clock.
+type Tracker interface {
+	// LockedUntil returns the time the key is locked until. It is in the past if the key isn't locked.
+	LockedUntil(ctx context.Context, key string, now time.Time) (time.Time, error)
+	// Fail records a failed attempt and returns the time the key is locked until after it
+	Fail(ctx context.Context, key string, now time.Time) (time.Time, error)
+	// Reset forgets the failures of the key and lifts its lockout
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy sets how fast the lockout grows
+type Policy struct {
+	// Failures allowed before the key is locked
+	FreeAttempts int
+	// Lockout after the first failure over the free attempts. It doubles after each next failure.
+	BaseDelay time.Duration
+	// Longest lockout
+	MaxDelay time.Duration
+	// Failures are forgotten once there are none for this long
+	Window time.Duration
+}
+
+// Delay returns the lockout after the given number of failures in a row
+func (p Policy) Delay(failures int) time.Duration {
+	over := failures - p.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < over && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
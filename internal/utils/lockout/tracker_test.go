@@ -0,0 +1,37 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     10 * time.Second,
+	Window:       time.Hour,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	delays := []time.Duration{0, 0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for failures, delay := range delays {
+		require.Equal(t, delay, testPolicy.Delay(failures), "failures %d", failures)
+	}
+	require.Equal(t, 10*time.Second, testPolicy.Delay(1000))
+}
+
+func TestMemoryTrackerSweep(t *testing.T) {
+	tracker := NewMemoryTracker(testPolicy).(*MemoryTracker)
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := tracker.Fail(context.Background(), "old", now)
+	require.NoError(t, err)
+
+	_, err = tracker.Fail(context.Background(), "new", now.Add(2*testPolicy.Window))
+	require.NoError(t, err)
+	require.Len(t, tracker.attempts, 1)
+	require.Contains(t, tracker.attempts, "new")
+}
@@ -0,0 +1,35 @@
+package mail
+
+import "sync"
+
+// Message is an email sent by the FakeMailer
+type Message struct {
+	To      []string
+	Subject string
+	Content string
+}
+
+// FakeMailer keeps sent emails in memory instead of sending them.
+// It is used in tests and when no SMTP server is configured.
+type FakeMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewFakeMailer() *FakeMailer {
+	return &FakeMailer{}
+}
+
+func (m *FakeMailer) SendEmail(to []string, subject, content string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, Message{To: to, Subject: subject, Content: content})
+	return nil
+}
+
+// Returns all emails sent so far
+func (m *FakeMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,6 @@
+package mail
+
+// Interface for sending emails
+type Mailer interface {
+	SendEmail(to []string, subject, content string) error
+}
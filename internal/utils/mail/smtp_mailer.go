@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	// from is used in the From header, sender is the bare address used in the envelope
+	from   string
+	sender string
+}
+
+// Returns new SMTPMailer. Authentication is skipped if the username is empty.
+func NewSMTPMailer(host string, port int, username, password, from string) (Mailer, error) {
+	if host == "" {
+		return nil, errors.New("smtp host is not provided")
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, fmt.Sprint(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     sender.String(),
+		sender:   sender.Address,
+	}, nil
+}
+
+func (m *SMTPMailer) SendEmail(to []string, subject, content string) error {
+	if len(to) == 0 {
+		return errors.New("no recipients provided")
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	if err := smtp.SendMail(m.addr, auth, m.sender, to, buildMessage(m.from, to, subject, content)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func buildMessage(from string, to []string, subject, content string) []byte {
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(content, "\n", "\r\n"))
+	return []byte(msg.String())
+}
@@ -0,0 +1,153 @@
+package mail
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// received is what the test SMTP server got from the client
+type received struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer starts a minimal SMTP server which accepts a single message
+func startSMTPServer(t *testing.T) (host string, port int, result <-chan received) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	ch := make(chan received, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var msg received
+		write("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO", "HELO":
+				write("250-localhost")
+				write("250 AUTH PLAIN")
+			case "AUTH":
+				decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+				msg.auth = string(decoded)
+				write("235 2.7.0 Authentication successful")
+			case "MAIL":
+				msg.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+				write("250 OK")
+			case "RCPT":
+				msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+				write("250 OK")
+			case "DATA":
+				write("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				msg.data = data.String()
+				write("250 OK")
+			case "QUIT":
+				write("221 Bye")
+				ch <- msg
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return "127.0.0.1", addr.Port, ch
+}
+
+func TestSMTPMailer(t *testing.T) {
+	host, port, result := startSMTPServer(t)
+
+	mailer, err := NewSMTPMailer(host, port, "flugo", "secret", "Flugo <noreply@flugo.com>")
+	require.NoError(t, err)
+
+	err = mailer.SendEmail([]string{"user@gmail.com"}, "Welcome to Flugo", "Hello!\nVerify your email.")
+	require.NoError(t, err)
+
+	msg := <-result
+	require.Equal(t, "\x00flugo\x00secret", msg.auth)
+	require.Equal(t, "noreply@flugo.com", msg.from)
+	require.Equal(t, []string{"user@gmail.com"}, msg.to)
+	require.Contains(t, msg.data, "From: \"Flugo\" <noreply@flugo.com>\r\n")
+	require.Contains(t, msg.data, "Subject: Welcome to Flugo\r\n")
+	require.Contains(t, msg.data, "To: user@gmail.com\r\n")
+	require.Contains(t, msg.data, "\r\n\r\nHello!\r\nVerify your email.")
+}
+
+func TestSMTPMailerWithoutAuth(t *testing.T) {
+	host, port, result := startSMTPServer(t)
+
+	mailer, err := NewSMTPMailer(host, port, "", "", "noreply@flugo.com")
+	require.NoError(t, err)
+
+	err = mailer.SendEmail([]string{"a@gmail.com", "b@gmail.com"}, "Subject", "Content")
+	require.NoError(t, err)
+
+	msg := <-result
+	require.Empty(t, msg.auth)
+	require.Equal(t, []string{"a@gmail.com", "b@gmail.com"}, msg.to)
+}
+
+func TestSMTPMailerUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	mailer, err := NewSMTPMailer("127.0.0.1", port, "", "", "noreply@flugo.com")
+	require.NoError(t, err)
+
+	err = mailer.SendEmail([]string{"user@gmail.com"}, "Subject", "Content")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), strconv.Itoa(port))
+}
+
+func TestSMTPMailerInvalidSender(t *testing.T) {
+	mailer, err := NewSMTPMailer("127.0.0.1", 25, "", "", "not an address")
+	require.Error(t, err)
+	require.Nil(t, mailer)
+}
+
+func TestFakeMailer(t *testing.T) {
+	mailer := NewFakeMailer()
+
+	err := mailer.SendEmail([]string{"user@gmail.com"}, "Subject", "Content")
+	require.NoError(t, err)
+
+	messages := mailer.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, Message{To: []string{"user@gmail.com"}, Subject: "Subject", Content: "Content"}, messages[0])
+}
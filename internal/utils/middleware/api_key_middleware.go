@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/password"
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// APIKeyUser is used by the auth middleware to find the user of an active API key
+type APIKeyUser interface {
+	UseAPIKey(ctx context.Context, keyHash string) (database.UseAPIKeyRow, error)
+}
+
+// API keys are stored hashed, so the key is looked up by its hash.
+// The payload is made up for the request, it has no session and never expires.
+// It is returned along with the error if the user of the key is banned.
+func verifyAPIKey(c *fiber.Ctx, keys APIKeyUser, apiKey string) (*token.Payload, error) {
+	key, err := keys.UseAPIKey(c.Context(), password.HashToken(apiKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fiber.NewError(http.StatusUnauthorized, "invalid api key")
+		}
+		return nil, fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	payload := &token.Payload{
+		ID:        uuid.New(),
+		SessionID: uuid.Nil,
+		UserID:    key.UserID,
+		Username:  key.Username,
+		Email:     key.Email,
+		Role:      key.Role,
+		Scopes:    scope.Granted(key.Scopes, key.Role),
+		IssuedAt:  time.Now(),
+		APIKeyID:  key.ID,
+	}
+	if key.IsBanned {
+		return payload, fiber.NewError(http.StatusForbidden, "user is banned")
+	}
+	return payload, nil
+}
+
+// RequireSession rejects requests made with an API key or a token of an OAuth client.
+// It guards account management, so neither a leaked key nor a third-party app
+// can be used to take over the account or to grant access to others.
+// It must be used after the auth middleware.
+func RequireSession(c *fiber.Ctx) error {
+	payload, ok := c.Locals(AuthPayloadKey).(*token.Payload)
+	if !ok {
+		return fiber.NewError(http.StatusUnauthorized, "authorization is not provided")
+	}
+	if payload.APIKeyID != 0 || payload.ClientID != "" {
+		return fiber.NewError(http.StatusForbidden, "api keys and oauth clients can't be used for this request, log in instead")
+	}
+	return c.Next()
+}
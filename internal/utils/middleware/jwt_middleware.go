@@ -1,20 +1,43 @@
 package middleware
 
 import (
+	"context"
+	"database/sql"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/abc_valera/flugo/internal/database"
+	"github.com/abc_valera/flugo/internal/utils/audit"
 	"github.com/abc_valera/flugo/internal/utils/token"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 const (
 	AuthHeaderKey  = "authorization"
 	AuthTypeBearer = "bearer"
+	AuthTypeAPIKey = "apikey"
 	AuthPayloadKey = "auth_payload"
 )
 
-func NewAuthMiddleware(tokenMaker token.Maker) fiber.Handler {
+// SessionGetter is used by the auth middleware to check
+// that the session of an access token wasn't revoked
+type SessionGetter interface {
+	GetSession(ctx context.Context, id uuid.UUID) (database.Session, error)
+}
+
+// AuthStore is the part of database.Queries the auth middleware uses
+type AuthStore interface {
+	SessionGetter
+	APIKeyUser
+	audit.EventWriter
+}
+
+// NewAuthMiddleware accepts access tokens as "Bearer <token>" and API keys as "ApiKey <key>".
+// Both put the same *token.Payload into c.Locals(AuthPayloadKey).
+// Rejected credentials are recorded in the audit log, requests without any are not.
+func NewAuthMiddleware(tokenMaker token.Maker, store AuthStore) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get(AuthHeaderKey)
 		if len(authHeader) == 0 {
@@ -23,21 +46,69 @@ func NewAuthMiddleware(tokenMaker token.Maker) fiber.Handler {
 
 		fields := strings.Fields(authHeader)
 		if len(fields) < 2 {
-			return fiber.NewError(http.StatusUnauthorized, "invalid authorization")
+			return authFailure(c, store, nil, fiber.NewError(http.StatusUnauthorized, "invalid authorization"))
 		}
 
-		authType := strings.ToLower(fields[0])
-		if authType != AuthTypeBearer {
-			return fiber.NewError(http.StatusUnauthorized, "this authorization type is not supported")
+		var payload *token.Payload
+		var err error
+		switch strings.ToLower(fields[0]) {
+		case AuthTypeBearer:
+			payload, err = verifyAccessToken(c, tokenMaker, store, fields[1])
+		case AuthTypeAPIKey:
+			payload, err = verifyAPIKey(c, store, fields[1])
+		default:
+			return authFailure(c, store, nil, fiber.NewError(http.StatusUnauthorized, "this authorization type is not supported"))
 		}
-
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
 		if err != nil {
-			return fiber.NewError(http.StatusUnauthorized, err.Error())
+			return authFailure(c, store, payload, err)
 		}
 		c.Locals(AuthPayloadKey, payload)
 
 		return c.Next()
 	}
 }
+
+// Records the rejected credentials and returns the error.
+// The payload is known if the credentials belong to a user, but can't be used anymore.
+func authFailure(c *fiber.Ctx, events audit.EventWriter, payload *token.Payload, err error) error {
+	// Server errors say nothing about the credentials,
+	// and expired tokens are the normal flow of clients renewing them
+	if e, ok := err.(*fiber.Error); ok && (e.Code >= http.StatusInternalServerError || e.Message == token.ErrExpiredToken.Error()) {
+		return err
+	}
+
+	var userID int32
+	if payload != nil {
+		userID = payload.UserID
+	}
+	audit.Record(c, events, audit.EventAuthFailure, audit.OutcomeFailure, userID, 0, err.Error())
+	return err
+}
+
+// The payload is returned along with the error if the token is valid, but its session can't be used
+func verifyAccessToken(c *fiber.Ctx, tokenMaker token.Maker, sessions SessionGetter, accessToken string) (*token.Payload, error) {
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, fiber.NewError(http.StatusUnauthorized, err.Error())
+	}
+
+	// Refresh tokens aren't bound to a session and can't be used as access tokens
+	if payload.SessionID == uuid.Nil {
+		return nil, fiber.NewError(http.StatusUnauthorized, token.ErrInvalidToken.Error())
+	}
+	session, err := sessions.GetSession(c.Context(), payload.SessionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return payload, fiber.NewError(http.StatusUnauthorized, "session not found")
+		}
+		return nil, fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	if session.IsBlocked {
+		return payload, fiber.NewError(http.StatusUnauthorized, "session is revoked")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return payload, fiber.NewError(http.StatusUnauthorized, "session has expired")
+	}
+	payload.ClientID = session.ClientID.String
+	return payload, nil
+}
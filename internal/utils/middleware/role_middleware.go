@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole lets the request through only if the authorized user has one of the given roles.
+// It must be used after the auth middleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		payload, ok := c.Locals(AuthPayloadKey).(*token.Payload)
+		if !ok {
+			return fiber.NewError(http.StatusUnauthorized, "authorization is not provided")
+		}
+
+		for _, role := range roles {
+			if payload.Role == role {
+				return c.Next()
+			}
+		}
+		return fiber.NewError(http.StatusForbidden, "insufficient role")
+	}
+}
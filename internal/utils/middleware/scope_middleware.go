@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/abc_valera/flugo/internal/utils/token"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireScopes lets the request through only if the token or API key has all the given scopes.
+// Tokens issued before scopes were introduced have null scopes and get the full scope set of their role,
+// while tokens with an empty list of scopes get nothing.
+// It must be used after the auth middleware.
+func RequireScopes(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		payload, ok := c.Locals(AuthPayloadKey).(*token.Payload)
+		if !ok {
+			return fiber.NewError(http.StatusUnauthorized, "authorization is not provided")
+		}
+
+		granted := payload.Scopes
+		if granted == nil {
+			granted = scope.ForRole(payload.Role)
+		}
+
+		var missing []string
+		for _, s := range scopes {
+			if !scope.Contains(granted, s) {
+				missing = append(missing, s)
+			}
+		}
+		if len(missing) > 0 {
+			return fiber.NewError(http.StatusForbidden, "insufficient scope, required: "+strings.Join(missing, " "))
+		}
+		return c.Next()
+	}
+}
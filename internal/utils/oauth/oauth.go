@@ -0,0 +1,129 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Error codes of RFC 6749 section 5.2 and 4.1.2.1
+const (
+	ErrInvalidRequest          = "invalid_request"
+	ErrInvalidClient           = "invalid_client"
+	ErrInvalidGrant            = "invalid_grant"
+	ErrUnauthorizedClient      = "unauthorized_client"
+	ErrUnsupportedGrantType    = "unsupported_grant_type"
+	ErrUnsupportedResponseType = "unsupported_response_type"
+	ErrInvalidScope            = "invalid_scope"
+	ErrAccessDenied            = "access_denied"
+)
+
+// Grant types the token endpoint accepts
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+)
+
+const (
+	ResponseTypeCode = "code"
+	// Only S256 is supported, the plain method doesn't protect the code from interception
+	CodeChallengeS256 = "S256"
+)
+
+// Error is the error response of the token, introspection and revocation endpoints
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func NewError(code, description string) *Error {
+	return &Error{code, description}
+}
+
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// S256Challenge returns the code challenge of the verifier (RFC 7636 section 4.2)
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE checks the code verifier sent to the token endpoint against the challenge sent to the authorization endpoint
+func VerifyPKCE(verifier, challenge, method string) bool {
+	if method != CodeChallengeS256 || !ValidCodeVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(S256Challenge(verifier)), []byte(challenge)) == 1
+}
+
+// ValidCodeVerifier checks the length and the characters of the verifier (RFC 7636 section 4.1)
+func ValidCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, r := range verifier {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum && !strings.ContainsRune("-._~", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseScope splits the space-delimited scope parameter
+func ParseScope(s string) []string {
+	return strings.Fields(s)
+}
+
+// FormatScope joins the scopes into the scope parameter
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// ValidRedirectURI checks a redirect URI a client registers. It must be absolute and have no fragment.
+// Plain http is allowed only for loopback addresses, custom schemes are allowed for mobile apps.
+func ValidRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Fragment != "" || strings.Contains(uri, "#") {
+		return false
+	}
+
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	case "javascript", "data", "file":
+		return false
+	}
+	return true
+}
+
+// RedirectURL adds the query parameters to the redirect URI of the client
+func RedirectURL(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := strings.Repeat("a1-._~", 8)
+	challenge := S256Challenge(verifier)
+	require.Len(t, challenge, 43)
+
+	require.True(t, VerifyPKCE(verifier, challenge, CodeChallengeS256))
+	require.False(t, VerifyPKCE(verifier+"x", challenge, CodeChallengeS256))
+	// The plain method is not supported
+	require.False(t, VerifyPKCE(verifier, verifier, "plain"))
+	// Too short verifiers are rejected even if they match
+	require.False(t, VerifyPKCE("short", S256Challenge("short"), CodeChallengeS256))
+}
+
+func TestValidCodeVerifier(t *testing.T) {
+	require.True(t, ValidCodeVerifier(strings.Repeat("A", 43)))
+	require.True(t, ValidCodeVerifier(strings.Repeat("z", 128)))
+	require.False(t, ValidCodeVerifier(strings.Repeat("A", 42)))
+	require.False(t, ValidCodeVerifier(strings.Repeat("A", 129)))
+	require.False(t, ValidCodeVerifier(strings.Repeat("A", 42)+"+"))
+}
+
+func TestScope(t *testing.T) {
+	require.Equal(t, []string{"profile:read", "jokes:write"}, ParseScope("  profile:read jokes:write "))
+	require.Empty(t, ParseScope(""))
+	require.Equal(t, "profile:read jokes:write", FormatScope([]string{"profile:read", "jokes:write"}))
+}
+
+func TestValidRedirectURI(t *testing.T) {
+	valid := []string{
+		"https://app.example.com/callback",
+		"http://localhost:8080/callback",
+		"http://127.0.0.1/callback",
+		"com.example.app:/oauth",
+	}
+	for _, uri := range valid {
+		require.True(t, ValidRedirectURI(uri), uri)
+	}
+
+	invalid := []string{
+		"",
+		"/callback",
+		"http://app.example.com/callback",
+		"https://app.example.com/callback#fragment",
+		"https:///callback",
+		"javascript:alert(1)",
+	}
+	for _, uri := range invalid {
+		require.False(t, ValidRedirectURI(uri), uri)
+	}
+}
+
+func TestRedirectURL(t *testing.T) {
+	redirect := RedirectURL("https://app.example.com/callback?app=1", url.Values{
+		"code":  {"abc"},
+		"state": {"x y"},
+	})
+
+	u, err := url.Parse(redirect)
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", u.Host)
+	require.Equal(t, "1", u.Query().Get("app"))
+	require.Equal(t, "abc", u.Query().Get("code"))
+	require.Equal(t, "x y", u.Query().Get("state"))
+}
@@ -0,0 +1,62 @@
+package password
+
+import (
+	"errors"
+)
+
+// Different types of hashers
+const (
+	HasherArgon2id = "argon2id"
+	HasherBcrypt   = "bcrypt"
+)
+
+var (
+	ErrMismatchedPassword = errors.New("password doesn't match the hash")
+	// Returned by Check when the hash was made by another algorithm
+	ErrUnknownHash = errors.New("unknown password hash format")
+)
+
+// Hasher is an interface for hashing and checking passwords.
+// Hashes are self-describing strings (PHC or modular crypt format), so hashes
+// of different algorithms and parameters can be stored side by side.
+type Hasher interface {
+	// Hash returns encoded hash of the password with a random salt
+	Hash(password string) (string, error)
+	// Check returns nil if the password matches the encoded hash
+	Check(password, encodedHash string) error
+	// NeedsRehash reports whether the hash was made by another algorithm or with other parameters,
+	// so it should be replaced by a new hash once the password is known
+	NeedsRehash(encodedHash string) bool
+}
+
+// MultiHasher hashes passwords with the primary hasher, but checks the hashes of all the given hashers.
+// It allows moving users to a new algorithm as they log in.
+type MultiHasher struct {
+	primary Hasher
+	hashers []Hasher
+}
+
+func NewMultiHasher(primary Hasher, legacy ...Hasher) Hasher {
+	return &MultiHasher{
+		primary: primary,
+		hashers: append([]Hasher{primary}, legacy...),
+	}
+}
+
+func (h *MultiHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *MultiHasher) Check(password, encodedHash string) error {
+	for _, hasher := range h.hashers {
+		err := hasher.Check(password, encodedHash)
+		if err != ErrUnknownHash {
+			return err
+		}
+	}
+	return ErrUnknownHash
+}
+
+func (h *MultiHasher) NeedsRehash(encodedHash string) bool {
+	return h.primary.NeedsRehash(encodedHash)
+}
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+)
+
+// Cheap parameters, so the tests stay fast
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	password := random.RandomString(8)
+
+	hash, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	require.False(t, hasher.NeedsRehash(hash))
+
+	require.NoError(t, hasher.Check(password, hash))
+	require.Equal(t, ErrMismatchedPassword, hasher.Check(random.RandomString(8), hash))
+
+	// Salt is random
+	otherHash, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+
+	// Long passwords are not truncated like in bcrypt
+	long := strings.Repeat("a", 100)
+	hash, err = hasher.Hash(long)
+	require.NoError(t, err)
+	require.Equal(t, ErrMismatchedPassword, hasher.Check(long[:72], hash))
+}
+
+func TestArgon2idHasherParams(t *testing.T) {
+	password := random.RandomString(8)
+	hash, err := NewArgon2idHasher(testArgon2idParams).Hash(password)
+	require.NoError(t, err)
+
+	// Hashes made with older parameters still match, but need to be rehashed
+	stronger := NewArgon2idHasher(Argon2idParams{Memory: 2048, Iterations: 2, Parallelism: 1})
+	require.NoError(t, stronger.Check(password, hash))
+	require.True(t, stronger.NeedsRehash(hash))
+
+	_, err = NewArgon2idHasher(Argon2idParams{Memory: 1024, Iterations: 0, Parallelism: 1}).Hash(password)
+	require.Error(t, err)
+}
+
+func TestArgon2idHasherInvalidHash(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	require.Equal(t, ErrUnknownHash, hasher.Check("password", "$2a$10$abcdefghijklmnopqrstuv"))
+	require.Error(t, hasher.Check("password", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$aGFzaA"))
+	require.Error(t, hasher.Check("password", "$argon2id$v=19$m=1024,t=1$c2FsdA$aGFzaA"))
+	require.Error(t, hasher.Check("password", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$"))
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	password := random.RandomString(8)
+
+	hash, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.False(t, hasher.NeedsRehash(hash))
+	require.True(t, NewBcryptHasher(5).NeedsRehash(hash))
+
+	require.NoError(t, hasher.Check(password, hash))
+	require.Equal(t, ErrMismatchedPassword, hasher.Check(random.RandomString(8), hash))
+	require.Equal(t, ErrUnknownHash, hasher.Check(password, "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$aGFzaA"))
+
+	_, err = hasher.Hash(strings.Repeat("a", 73))
+	require.Error(t, err)
+}
+
+func TestMultiHasher(t *testing.T) {
+	bcryptHasher := NewBcryptHasher(4)
+	hasher := NewMultiHasher(NewArgon2idHasher(testArgon2idParams), bcryptHasher)
+	password := random.RandomString(8)
+
+	// Legacy hashes are checked, but have to be moved to the primary algorithm
+	legacyHash, err := bcryptHasher.Hash(password)
+	require.NoError(t, err)
+	require.NoError(t, hasher.Check(password, legacyHash))
+	require.Equal(t, ErrMismatchedPassword, hasher.Check(random.RandomString(8), legacyHash))
+	require.True(t, hasher.NeedsRehash(legacyHash))
+
+	hash, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hash, "$argon2id$"))
+	require.NoError(t, hasher.Check(password, hash))
+	require.False(t, hasher.NeedsRehash(hash))
+
+	require.Equal(t, ErrUnknownHash, hasher.Check(password, "plaintext"))
+}
@@ -1,19 +1,13 @@
 package password
 
-import (
-	"fmt"
-
-	"golang.org/x/crypto/bcrypt"
-)
+var defaultHasher = NewMultiHasher(NewArgon2idHasher(DefaultArgon2idParams), NewBcryptHasher(DefaultBcryptCost))
 
+// HashPassword hashes the password with argon2id and the default parameters
 func HashPassword(password string) (string, error) {
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
-	}
-	return string(hashPassword), err
+	return defaultHasher.Hash(password)
 }
 
+// CheckPassword checks the password against argon2id or bcrypt hash
 func CheckPassword(password, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return defaultHasher.Check(password, hashedPassword)
 }
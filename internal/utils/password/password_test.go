@@ -4,7 +4,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
-	"golang.org/x/crypto/bcrypt"
 
 	"github.com/abc_valera/flugo/internal/utils/random"
 )
@@ -21,5 +20,14 @@ func TestPassword(t *testing.T) {
 
 	wrongPassword := random.RandomString(8)
 	err = CheckPassword(wrongPassword, hashedPassword)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.EqualError(t, err, ErrMismatchedPassword.Error())
+}
+
+func TestHashToken(t *testing.T) {
+	token := random.RandomString(32)
+
+	hash := HashToken(token)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashToken(token))
+	require.NotEqual(t, hash, HashToken(random.RandomString(32)))
 }
@@ -0,0 +1,136 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Names of the policy rules, reported back to the clients
+const (
+	RuleMinLength  = "min_length"
+	RuleMaxLength  = "max_length"
+	RuleCharClass  = "char_classes"
+	RuleUserInfo   = "user_info"
+	RuleBlocklist  = "blocklist"
+	minUserInfoLen = 3
+)
+
+// Violation describes a rule the password breaks
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError lists all the rules the password breaks
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password is too weak: " + strings.Join(messages, "; ")
+}
+
+// Policy sets the requirements for new passwords. Zero values turn the rules off.
+type Policy struct {
+	// Lengths are counted in characters, not bytes
+	MinLength int
+	MaxLength int
+	// Number of character classes (lowercase, uppercase, digits, symbols) the password has to mix
+	MinCharClasses int
+	// Lowercased common and breached passwords
+	Blocklist map[string]struct{}
+}
+
+// Check returns *PolicyError if the password breaks any rule.
+// userInputs are the username, email and alike, which the password must not contain.
+func (p *Policy) Check(password string, userInputs ...string) error {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("must be at least %d characters long", p.MinLength)})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{RuleMaxLength, fmt.Sprintf("must be at most %d characters long", p.MaxLength)})
+	}
+	if p.MinCharClasses > 0 && charClasses(password) < p.MinCharClasses {
+		violations = append(violations, Violation{RuleCharClass, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.MinCharClasses)})
+	}
+
+	lower := strings.ToLower(password)
+	for _, input := range userInputs {
+		if containsUserInput(lower, input) {
+			violations = append(violations, Violation{RuleUserInfo, "must not contain the username or email"})
+			break
+		}
+	}
+	if _, ok := p.Blocklist[lower]; ok {
+		violations = append(violations, Violation{RuleBlocklist, "is too common"})
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{violations}
+	}
+	return nil
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// The email is checked by its local part, as the domain is often a common word
+func containsUserInput(lowerPassword, input string) bool {
+	input = strings.ToLower(input)
+	if at := strings.LastIndex(input, "@"); at >= 0 {
+		input = input[:at]
+	}
+	if utf8.RuneCountInString(input) < minUserInfoLen {
+		return false
+	}
+	return strings.Contains(lowerPassword, input)
+}
+
+// LoadBlocklist reads passwords from the file, one per line.
+// Empty lines and lines starting with # are skipped.
+func LoadBlocklist(file string) (map[string]struct{}, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open password blocklist: %w", err)
+	}
+	defer f.Close()
+
+	blocklist := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read password blocklist: %w", err)
+	}
+	return blocklist, nil
+}
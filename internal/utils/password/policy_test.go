@@ -0,0 +1,84 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rules(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+	policyErr, ok := err.(*PolicyError)
+	require.True(t, ok)
+
+	names := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		names[i] = v.Rule
+	}
+	return names
+}
+
+func TestPolicy(t *testing.T) {
+	policy := &Policy{
+		MinLength:      10,
+		MaxLength:      20,
+		MinCharClasses: 3,
+		Blocklist:      map[string]struct{}{"password123!": {}},
+	}
+
+	testCases := []struct {
+		name     string
+		password string
+		rules    []string
+	}{
+		{"Valid", "Correct-horse-7", nil},
+		{"TooShort", "Sh0rt!", []string{RuleMinLength}},
+		{"TooLong", "Way-too-long-passw0rd-here", []string{RuleMaxLength}},
+		{"FewCharClasses", "onlylowercase", []string{RuleCharClass}},
+		{"UnicodeLength", "Пароль-9ывапр", nil},
+		{"Username", "xx-Valera-2023", []string{RuleUserInfo}},
+		{"EmailLocalPart", "Abc.mail-2023", []string{RuleUserInfo}},
+		{"Blocklist", "Password123!", []string{RuleBlocklist}},
+		{"Many", "valera", []string{RuleMinLength, RuleCharClass, RuleUserInfo}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(tc.password, "valera", "abc.mail@gmail.com")
+			require.Equal(t, tc.rules, rules(t, err))
+		})
+	}
+}
+
+func TestPolicyShortUserInputs(t *testing.T) {
+	// Short usernames would reject too many passwords
+	policy := &Policy{}
+	require.NoError(t, policy.Check("abcdefgh", "ab", "c@gmail.com"))
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "blocklist.txt")
+	err := os.WriteFile(file, []byte("# common passwords\nqwerty\n\n  LetMeIn \n"), 0o600)
+	require.NoError(t, err)
+
+	blocklist, err := LoadBlocklist(file)
+	require.NoError(t, err)
+	require.Len(t, blocklist, 2)
+	require.Contains(t, blocklist, "qwerty")
+	require.Contains(t, blocklist, "letmein")
+
+	_, err = LoadBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+}
+
+// The blocklist shipped with the server
+func TestDefaultBlocklist(t *testing.T) {
+	blocklist, err := LoadBlocklist("blocklist.txt")
+	require.NoError(t, err)
+	require.Contains(t, blocklist, "123456")
+	require.Contains(t, blocklist, "password")
+}
@@ -0,0 +1,13 @@
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken hashes high-entropy secrets like reset tokens before they are stored.
+// Unlike passwords they can't be brute-forced, so a fast hash is enough and allows lookups by the hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,17 @@
+package reaction
+
+// Reactions a user can leave on a joke, one per joke
+const (
+	Laugh = "laugh"
+	Groan = "groan"
+	Love  = "love"
+)
+
+// Checks if the reaction is one of the known reactions
+func IsValid(r string) bool {
+	switch r {
+	case Laugh, Groan, Love:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package role
+
+// Roles a user can have. Every new user gets the User role.
+const (
+	User      = "user"
+	Moderator = "moderator"
+	Admin     = "admin"
+)
+
+// Checks if the role is one of the known roles
+func IsValid(r string) bool {
+	switch r {
+	case User, Moderator, Admin:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package scope
+
+import (
+	"fmt"
+
+	"github.com/abc_valera/flugo/internal/utils/role"
+)
+
+// Scopes limit what a token or an API key can be used for.
+// The role still decides what the user is allowed to do at all.
+const (
+	ProfileRead   = "profile:read"
+	ProfileWrite  = "profile:write"
+	JokesWrite    = "jokes:write"
+	JokesModerate = "jokes:moderate"
+	Admin         = "admin"
+)
+
+// ForRole returns the full scope set of the role. Tokens get it unless fewer scopes are requested.
+func ForRole(r string) []string {
+	scopes := []string{ProfileRead, ProfileWrite, JokesWrite}
+	switch r {
+	case role.Moderator:
+		scopes = append(scopes, JokesModerate)
+	case role.Admin:
+		scopes = append(scopes, JokesModerate, Admin)
+	}
+	return scopes
+}
+
+// Reduce checks that the requested scopes are a subset of the allowed ones and returns them without duplicates.
+// Nothing requested means all the allowed scopes.
+func Reduce(requested, allowed []string) ([]string, error) {
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	reduced := make([]string, 0, len(requested))
+	for _, s := range requested {
+		if !Contains(allowed, s) {
+			return nil, fmt.Errorf("scope is unknown or not allowed: %s", s)
+		}
+		if !Contains(reduced, s) {
+			reduced = append(reduced, s)
+		}
+	}
+	return reduced, nil
+}
+
+// Contains reports whether the scope is in the set
+func Contains(scopes []string, s string) bool {
+	for _, scope := range scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Granted returns the scopes a session or an API key has now. Stored nil stands for the full set of the role,
+// other stored scopes are cut to the role, so a demoted user loses the scopes of the old role.
+func Granted(stored []string, r string) []string {
+	allowed := ForRole(r)
+	if stored == nil {
+		return allowed
+	}
+
+	granted := make([]string, 0, len(stored))
+	for _, s := range stored {
+		if Contains(allowed, s) {
+			granted = append(granted, s)
+		}
+	}
+	return granted
+}
@@ -0,0 +1,42 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForRole(t *testing.T) {
+	require.Equal(t, []string{ProfileRead, ProfileWrite, JokesWrite}, ForRole(role.User))
+	require.Equal(t, []string{ProfileRead, ProfileWrite, JokesWrite, JokesModerate}, ForRole(role.Moderator))
+	require.Equal(t, []string{ProfileRead, ProfileWrite, JokesWrite, JokesModerate, Admin}, ForRole(role.Admin))
+}
+
+func TestReduce(t *testing.T) {
+	allowed := ForRole(role.User)
+
+	scopes, err := Reduce(nil, allowed)
+	require.NoError(t, err)
+	require.Equal(t, allowed, scopes)
+
+	scopes, err = Reduce([]string{JokesWrite, JokesWrite, ProfileRead}, allowed)
+	require.NoError(t, err)
+	require.Equal(t, []string{JokesWrite, ProfileRead}, scopes)
+
+	// Users can't grant themselves more than their role allows
+	_, err = Reduce([]string{JokesWrite, Admin}, allowed)
+	require.Error(t, err)
+
+	_, err = Reduce([]string{"jokes:everything"}, allowed)
+	require.Error(t, err)
+}
+
+func TestGranted(t *testing.T) {
+	require.Equal(t, ForRole(role.Admin), Granted(nil, role.Admin))
+	require.Equal(t, []string{JokesWrite, Admin}, Granted([]string{JokesWrite, Admin}, role.Admin))
+
+	// The scopes of the old role are lost after a demotion
+	require.Equal(t, []string{JokesWrite}, Granted([]string{JokesWrite, Admin}, role.User))
+	require.Empty(t, Granted([]string{Admin}, role.User))
+}
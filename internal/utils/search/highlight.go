@@ -0,0 +1,21 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// Marks of the matches in the snippets of the database. They are private use characters,
+// so they aren't in the texts anyway and are taken out of them if someone puts them there.
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
+
+var highlightReplacer = strings.NewReplacer(HighlightStart, "<b>", HighlightStop, "</b>")
+
+// HighlightHTML turns the snippet into HTML where the matches are the only markup:
+// the text is escaped and the marks become <b> and </b>
+func HighlightHTML(snippet string) string {
+	return highlightReplacer.Replace(html.EscapeString(snippet))
+}
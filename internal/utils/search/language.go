@@ -0,0 +1,36 @@
+package search
+
+// Languages of the jokes. They name the Postgres text search configurations
+// used to stem the words of the jokes and of the queries.
+// Simple doesn't stem the words at all, which suits the languages without a configuration.
+const (
+	Simple     = "simple"
+	Danish     = "danish"
+	Dutch      = "dutch"
+	English    = "english"
+	Finnish    = "finnish"
+	French     = "french"
+	German     = "german"
+	Hungarian  = "hungarian"
+	Italian    = "italian"
+	Norwegian  = "norwegian"
+	Portuguese = "portuguese"
+	Romanian   = "romanian"
+	Russian    = "russian"
+	Spanish    = "spanish"
+	Swedish    = "swedish"
+	Turkish    = "turkish"
+)
+
+// Language of the jokes that don't have one set
+const DefaultLanguage = English
+
+// Checks if the language is one of the known languages
+func IsLanguage(l string) bool {
+	switch l {
+	case Simple, Danish, Dutch, English, Finnish, French, German, Hungarian,
+		Italian, Norwegian, Portuguese, Romanian, Russian, Spanish, Swedish, Turkish:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Term is a word or a phrase that the matching jokes have to contain
+type Term struct {
+	// Words of the phrase in order, a single one for a word
+	Words []string
+	// The last word is matched as a prefix
+	Prefix bool
+}
+
+// Query is the terms of a search, all of which have to match
+type Query []Term
+
+// Parse reads the query typed by a user.
+// Words in double quotes are searched as a phrase, and a word ending with * as a prefix.
+// Everything but letters and digits separates the words, so the query can't break the tsquery syntax.
+// A word with separators inside it, like "knock-knock", is searched as a phrase.
+func Parse(q string) Query {
+	var query Query
+	for i, part := range strings.Split(q, `"`) {
+		// The parts in between the quotes are the phrases, an unclosed quote lasts to the end
+		if i%2 == 1 {
+			query = query.add(part)
+			continue
+		}
+		for _, token := range strings.Fields(part) {
+			query = query.add(token)
+		}
+	}
+	return query
+}
+
+// Adds the text as a term if it has any words
+func (q Query) add(text string) Query {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return q
+	}
+	return append(q, Term{
+		Words:  words,
+		Prefix: strings.HasSuffix(strings.TrimSpace(text), "*"),
+	})
+}
+
+// TSQuery returns the query in the syntax of the Postgres to_tsquery function
+func (q Query) TSQuery() string {
+	terms := make([]string, 0, len(q))
+	for _, term := range q {
+		tsTerm := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			tsTerm += ":*"
+		}
+		if len(term.Words) > 1 {
+			tsTerm = "(" + tsTerm + ")"
+		}
+		terms = append(terms, tsTerm)
+	}
+	return strings.Join(terms, " & ")
+}
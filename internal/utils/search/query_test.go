@@ -0,0 +1,43 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		q       string
+		tsquery string
+	}{
+		{"words", "Cats  dogs", "cats & dogs"},
+		{"prefix", "knock kno*", "knock & kno:*"},
+		{"phrase", `"walks into a bar" priest`, "(walks <-> into <-> a <-> bar) & priest"},
+		{"prefix phrase", `"knock kno*"`, "(knock <-> kno:*)"},
+		{"unclosed quote", `bar "walks into`, "bar & (walks <-> into)"},
+		{"separators", "knock-knock", "(knock <-> knock)"},
+		{"unicode", "Шутка über", "шутка & über"},
+		{"tsquery syntax", `a & !b | c:* <-> (d)`, "a & b & c:* & d"},
+		{"no words", `"" * & !`, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.tsquery, Parse(tc.q).TSQuery())
+		})
+	}
+}
+
+func TestIsLanguage(t *testing.T) {
+	require.True(t, IsLanguage(English))
+	require.True(t, IsLanguage(Simple))
+	require.False(t, IsLanguage("klingon"))
+	require.False(t, IsLanguage(""))
+}
+
+func TestHighlightHTML(t *testing.T) {
+	snippet := `<script>alert("joke")</script> ` + HighlightStart + "penguin" + HighlightStop + " & co"
+	require.Equal(t, `&lt;script&gt;alert(&#34;joke&#34;)&lt;/script&gt; <b>penguin</b> &amp; co`, HighlightHTML(snippet))
+}
@@ -0,0 +1,77 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// KeySetProvider is implemented by makers whose tokens can be verified by other services
+type KeySetProvider interface {
+	JWKS() JWKSet
+}
+
+// AsymmetricJWTMaker signs tokens with EdDSA or RS256, depending on the type of the signing key.
+// Every token carries the id of its key in the kid header.
+type AsymmetricJWTMaker struct {
+	keyring *Keyring
+}
+
+func NewAsymmetricJWTMaker(keyring *Keyring) (Maker, error) {
+	if keyring == nil {
+		return nil, errors.New("keyring is not provided")
+	}
+	return &AsymmetricJWTMaker{keyring}, nil
+}
+
+func (maker *AsymmetricJWTMaker) CreateToken(UserID int32, username, email, role string, scopes []string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(UserID, username, email, role, scopes, sessionID, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := maker.keyring.SigningKey()
+	jwtToken := jwt.NewWithClaims(key.Method, payload)
+	jwtToken.Header["kid"] = key.ID
+	token, err := jwtToken.SignedString(key.privateKey)
+	return token, payload, err
+}
+
+func (maker *AsymmetricJWTMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		key, err := maker.keyring.Get(kid)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		// The algorithm is bound to the key, the header can't pick another one
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return key.PublicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+func (maker *AsymmetricJWTMaker) JWKS() JWKSet {
+	return maker.keyring.JWKS()
+}
@@ -0,0 +1,126 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newEd25519PEM(t *testing.T) []byte {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func newRSAPEM(t *testing.T, bits int) []byte {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+}
+
+func publicPEM(t *testing.T, privatePEM []byte) []byte {
+	key, err := parseKey(privatePEM)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newAsymmetricJWTMaker(t *testing.T, signingKeyPEM []byte, verificationKeysPEM ...[]byte) Maker {
+	keyring, err := NewKeyring(signingKeyPEM, verificationKeysPEM...)
+	require.NoError(t, err)
+	maker, err := NewAsymmetricJWTMaker(keyring)
+	require.NoError(t, err)
+	return maker
+}
+
+func TestAsymmetricJWTMakerEdDSA(t *testing.T) {
+	testMaker(t, func(t *testing.T) Maker {
+		return newAsymmetricJWTMaker(t, newEd25519PEM(t))
+	})
+}
+
+func TestAsymmetricJWTMakerRS256(t *testing.T) {
+	testMaker(t, func(t *testing.T) Maker {
+		return newAsymmetricJWTMaker(t, newRSAPEM(t, 2048))
+	})
+}
+
+func TestAsymmetricJWTMakerRotation(t *testing.T) {
+	oldKey := newEd25519PEM(t)
+	oldMaker := newAsymmetricJWTMaker(t, oldKey)
+
+	token, _, err := oldMaker.CreateToken(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	// The old key is rotated out, but its public part is still trusted
+	newMaker := newAsymmetricJWTMaker(t, newRSAPEM(t, 2048), publicPEM(t, oldKey))
+	payload, err := newMaker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	jwks := newMaker.(KeySetProvider).JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, "RS256", jwks.Keys[0].Alg)
+	require.Equal(t, "RSA", jwks.Keys[0].Kty)
+	require.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+	require.Equal(t, "OKP", jwks.Keys[1].Kty)
+	require.NotEqual(t, jwks.Keys[0].Kid, jwks.Keys[1].Kid)
+
+	// Once the old key is dropped from the keyring its tokens are rejected
+	droppedMaker := newAsymmetricJWTMaker(t, newRSAPEM(t, 2048))
+	payload, err = droppedMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestAsymmetricJWTMakerHMACConfusion(t *testing.T) {
+	// A token signed with HS256 must be rejected even if its kid is known
+	signingKey := newEd25519PEM(t)
+	keyring, err := NewKeyring(signingKey)
+	require.NoError(t, err)
+
+	hmacMaker, err := NewJWTMaker(random.RandomString(32))
+	require.NoError(t, err)
+	token, _, err := hmacMaker.CreateToken(1, random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	maker, err := NewAsymmetricJWTMaker(keyring)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestKeyringInvalidKeys(t *testing.T) {
+	_, err := NewKeyring([]byte(random.RandomString(64)))
+	require.Error(t, err)
+
+	_, err = NewKeyring(publicPEM(t, newEd25519PEM(t)))
+	require.Error(t, err)
+
+	_, err = NewKeyring(newRSAPEM(t, 1024))
+	require.Error(t, err)
+}
+
+func TestKeyThumbprint(t *testing.T) {
+	// Example key from RFC 7638, section 3.1
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+
+	publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	require.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint(publicKey))
+}
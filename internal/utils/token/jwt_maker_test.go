@@ -4,31 +4,34 @@ import (
 	"testing"
 	"time"
 
-	"github.com/abc_valera/flugo/internal/utils"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 func TestJWTMaker(t *testing.T) {
-	maker, err := NewJWTMaker(utils.RandomString(32))
-	require.NoError(t, err)
+	testMaker(t, func(t *testing.T) Maker {
+		maker, err := NewJWTMaker(random.RandomString(32))
+		require.NoError(t, err)
+		return maker
+	})
+}
 
-	UserID := int32(utils.RandomInt(1, 1000))
-	username := utils.RandomUsername()
-	email := utils.RandomEmail()
-	duration := time.Minute
-	issuedAt := time.Now()
-	expiredAt := issuedAt.Add(duration)
+func TestJWTMakerAlgNone(t *testing.T) {
+	payload, err := NewPayload(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+	require.NoError(t, err)
 
-	token, err := maker.CreateToken(UserID, username, email, duration)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
 	require.NoError(t, err)
-	require.NotEmpty(t, token)
 
-	payload, err := maker.VerifyToken(token)
+	maker, err := NewJWTMaker(random.RandomString(32))
 	require.NoError(t, err)
-	require.NotEmpty(t, payload)
 
-	require.NotZero(t, payload.ID)
-	require.Equal(t, username, payload.Username)
-	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
-	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+	payload, err = maker.VerifyToken(token)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
 }
@@ -0,0 +1,215 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const minRSAKeyBits = 2048
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a single key of the Keyring.
+// Only the signing key of the keyring has a private part.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PublicKey  crypto.PublicKey
+	privateKey crypto.PrivateKey
+}
+
+// Keyring holds the key tokens are signed with and all the public keys tokens are verified with.
+// Public keys of rotated out signing keys stay in the keyring,
+// so tokens signed before the rotation remain valid until they expire.
+type Keyring struct {
+	signingKey *Key
+	keys       map[string]*Key
+	// keeps JWKS output stable
+	order []string
+}
+
+// Returns new Keyring from PEM encoded keys.
+// The signing key must be a private key, verification keys may be public or private ones.
+func NewKeyring(signingKeyPEM []byte, verificationKeysPEM ...[]byte) (*Keyring, error) {
+	signingKey, err := parseKey(signingKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if signingKey.privateKey == nil {
+		return nil, errors.New("signing key must be a private key")
+	}
+
+	keyring := &Keyring{signingKey: signingKey, keys: make(map[string]*Key)}
+	keyring.add(signingKey)
+	for _, keyPEM := range verificationKeysPEM {
+		key, err := parseKey(keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		keyring.add(&Key{ID: key.ID, Method: key.Method, PublicKey: key.PublicKey})
+	}
+	return keyring, nil
+}
+
+// Returns new Keyring from PEM files
+func LoadKeyring(signingKeyFile string, verificationKeyFiles ...string) (*Keyring, error) {
+	signingKeyPEM, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read signing key: %w", err)
+	}
+
+	verificationKeysPEM := make([][]byte, 0, len(verificationKeyFiles))
+	for _, file := range verificationKeyFiles {
+		keyPEM, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read verification key: %w", err)
+		}
+		verificationKeysPEM = append(verificationKeysPEM, keyPEM)
+	}
+
+	return NewKeyring(signingKeyPEM, verificationKeysPEM...)
+}
+
+func (k *Keyring) add(key *Key) {
+	if _, ok := k.keys[key.ID]; ok {
+		return
+	}
+	k.keys[key.ID] = key
+	k.order = append(k.order, key.ID)
+}
+
+// Returns the key new tokens are signed with
+func (k *Keyring) SigningKey() *Key {
+	return k.signingKey
+}
+
+// Returns the key with the given id
+func (k *Keyring) Get(id string) (*Key, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is a set of JSON Web Keys served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Returns all public keys of the keyring
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(k.order))}
+	for _, id := range k.order {
+		jwk := newJWK(k.keys[id].PublicKey)
+		jwk.Kid = id
+		jwk.Use = "sig"
+		jwk.Alg = k.keys[id].Method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func newJWK(publicKey crypto.PublicKey) JWK {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(key)}
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	}
+	return JWK{}
+}
+
+// Returns the JWK thumbprint (RFC 7638) of the public key. It is used as the key id.
+func thumbprint(publicKey crypto.PublicKey) string {
+	jwk := newJWK(publicKey)
+
+	// Members must be in lexicographic order, which json.Marshal does for maps
+	members := map[string]string{"kty": jwk.Kty}
+	if jwk.Kty == "OKP" {
+		members["crv"] = jwk.Crv
+		members["x"] = jwk.X
+	} else {
+		members["n"] = jwk.N
+		members["e"] = jwk.E
+	}
+	data, _ := json.Marshal(members)
+
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Parses a PEM encoded Ed25519 or RSA key
+func parseKey(keyPEM []byte) (*Key, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("key must be PEM encoded")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := new(Key)
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method, key.PublicKey, key.privateKey = jwt.SigningMethodEdDSA, k.Public(), k
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	case *rsa.PrivateKey:
+		key.Method, key.PublicKey, key.privateKey = jwt.SigningMethodRS256, &k.PublicKey, k
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	default:
+		return nil, fmt.Errorf("unsupported key type: %T", parsed)
+	}
+
+	if rsaKey, ok := key.PublicKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("invalid key size: rsa keys must be at least %d bits", minRSAKeyBits)
+	}
+
+	key.ID = thumbprint(key.PublicKey)
+	return key, nil
+}
@@ -1,9 +1,21 @@
 package token
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported token makers, selected with the TOKEN_MAKER config variable
+const (
+	MakerJWT           = "jwt"
+	MakerJWTAsymmetric = "jwt_asymmetric"
+	MakerPasetoLocal   = "paseto_local"
+	MakerPasetoPublic  = "paseto_public"
+)
 
 // Interface for managing tokens
 type Maker interface {
-	CreateToken(UserID int32, username, email string, duration time.Duration) (string, error)
+	CreateToken(UserID int32, username, email, role string, scopes []string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 	VerifyToken(token string) (*Payload, error)
 }
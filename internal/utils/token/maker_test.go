@@ -0,0 +1,118 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/abc_valera/flugo/internal/utils/scope"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// testMaker is a conformance suite every Maker implementation has to pass.
+// newMaker must return a new Maker with a fresh random key on every call.
+func testMaker(t *testing.T, newMaker func(t *testing.T) Maker) {
+	t.Run("ValidToken", func(t *testing.T) {
+		maker := newMaker(t)
+
+		UserID := int32(random.RandomInt(1, 1000))
+		username := random.RandomUsername()
+		email := random.RandomEmail()
+		userRole := role.Moderator
+		scopes := []string{scope.JokesWrite, scope.JokesModerate}
+		sessionID := uuid.New()
+		duration := time.Minute
+		issuedAt := time.Now()
+		expiredAt := issuedAt.Add(duration)
+
+		token, createdPayload, err := maker.CreateToken(UserID, username, email, userRole, scopes, sessionID, duration)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		require.NotEmpty(t, createdPayload)
+
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.NotEmpty(t, payload)
+
+		require.NotZero(t, payload.ID)
+		require.Equal(t, createdPayload.ID, payload.ID)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.Equal(t, UserID, payload.UserID)
+		require.Equal(t, username, payload.Username)
+		require.Equal(t, email, payload.Email)
+		require.Equal(t, userRole, payload.Role)
+		require.Equal(t, scopes, payload.Scopes)
+		require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+		require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+	})
+
+	t.Run("NoScopes", func(t *testing.T) {
+		maker := newMaker(t)
+
+		// Tokens without scopes grant nothing, unlike the tokens issued before the scopes
+		token, _, err := maker.CreateToken(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, []string{}, uuid.New(), time.Minute)
+		require.NoError(t, err)
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.NotNil(t, payload.Scopes)
+		require.Empty(t, payload.Scopes)
+
+		token, _, err = maker.CreateToken(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+		require.NoError(t, err)
+		payload, err = maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.Nil(t, payload.Scopes)
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		maker := newMaker(t)
+
+		token, _, err := maker.CreateToken(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), -time.Minute)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+
+		payload, err := maker.VerifyToken(token)
+		require.Error(t, err)
+		require.EqualError(t, err, ErrExpiredToken.Error())
+		require.Nil(t, payload)
+	})
+
+	t.Run("TamperedToken", func(t *testing.T) {
+		maker := newMaker(t)
+
+		token, _, err := maker.CreateToken(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+		require.NoError(t, err)
+
+		tampered := []byte(token)
+		last := len(tampered) - 2
+		if tampered[last] == 'A' {
+			tampered[last] = 'B'
+		} else {
+			tampered[last] = 'A'
+		}
+
+		payload, err := maker.VerifyToken(string(tampered))
+		require.Error(t, err)
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	})
+
+	t.Run("ForeignKey", func(t *testing.T) {
+		token, _, err := newMaker(t).CreateToken(int32(random.RandomInt(1, 1000)), random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+		require.NoError(t, err)
+
+		payload, err := newMaker(t).VerifyToken(token)
+		require.Error(t, err)
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	})
+
+	t.Run("Garbage", func(t *testing.T) {
+		payload, err := newMaker(t).VerifyToken(random.RandomString(64))
+		require.Error(t, err)
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	})
+}
@@ -0,0 +1,56 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/abc_valera/flugo/internal/utils/random"
+	"github.com/abc_valera/flugo/internal/utils/role"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoLocalMaker(t *testing.T) {
+	testMaker(t, func(t *testing.T) Maker {
+		maker, err := NewPasetoLocalMaker(random.RandomString(32))
+		require.NoError(t, err)
+		return maker
+	})
+}
+
+func TestPasetoLocalMakerKeySize(t *testing.T) {
+	maker, err := NewPasetoLocalMaker(random.RandomString(31))
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	testMaker(t, func(t *testing.T) Maker {
+		maker, err := NewPasetoPublicMaker(paseto.NewV4AsymmetricSecretKey().ExportHex())
+		require.NoError(t, err)
+		return maker
+	})
+}
+
+func TestPasetoPublicMakerFromSeed(t *testing.T) {
+	secretKey := paseto.NewV4AsymmetricSecretKey()
+
+	maker, err := NewPasetoPublicMaker(secretKey.ExportSeedHex())
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(1, random.RandomUsername(), random.RandomEmail(), role.User, nil, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	// A token signed with the seed must be verifiable with the full key
+	fullMaker, err := NewPasetoPublicMaker(secretKey.ExportHex())
+	require.NoError(t, err)
+	_, err = fullMaker.VerifyToken(token)
+	require.NoError(t, err)
+}
+
+func TestPasetoPublicMakerInvalidKey(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(random.RandomString(64))
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
@@ -14,14 +14,25 @@ var (
 
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
+	SessionID uuid.UUID `json:"session_id"`
 	UserID    int32     `json:"user_id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	// Limits what the token can be used for, see the scope package.
+	// It is null only in the tokens issued before the scopes, an empty list grants nothing.
+	Scopes    []string  `json:"scopes"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// Set only by the auth middleware for requests made with an API key instead of a token
+	APIKeyID int64 `json:"api_key_id,omitempty"`
+	// Set only by the auth middleware for tokens issued to an OAuth client
+	ClientID string `json:"client_id,omitempty"`
 }
 
-func NewPayload(UserID int32, username, email string, duration time.Duration) (*Payload, error) {
+// Returns new Payload. Access tokens are bound to the session they were issued for,
+// refresh tokens start a new session themselves and are created with uuid.Nil sessionID.
+func NewPayload(UserID int32, username, email, role string, scopes []string, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -29,9 +40,12 @@ func NewPayload(UserID int32, username, email string, duration time.Duration) (*
 
 	payload := &Payload{
 		ID:        tokenID,
+		SessionID: sessionID,
 		UserID:    UserID,
 		Username:  username,
 		Email:     email,
+		Role:      role,
+		Scopes:    scopes,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
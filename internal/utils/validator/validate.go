@@ -1,10 +1,18 @@
 package validator
 
 import (
+	"regexp"
+
 	v "github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
 )
 
+// Usernames are a part of URLs, so they are limited to URL-safe characters
+var usernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,32}$`)
+
+// Tags are a part of URLs too, they are stored lowercase
+var tagRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
 type CustomValidator interface {
 	Validate(s interface{}) error
 }
@@ -13,6 +21,20 @@ type FlugoValidator struct {
 	Validator *v.Validate
 }
 
+// Returns FlugoValidator with the custom tags registered:
+// "username" checks the format of usernames,
+// "tag" checks the format of joke tags
+func NewFlugoValidator() *FlugoValidator {
+	validate := v.New()
+	validate.RegisterValidation("username", func(fl v.FieldLevel) bool {
+		return usernameRegexp.MatchString(fl.Field().String())
+	})
+	validate.RegisterValidation("tag", func(fl v.FieldLevel) bool {
+		return tagRegexp.MatchString(fl.Field().String())
+	})
+	return &FlugoValidator{validate}
+}
+
 func (fv *FlugoValidator) Validate(s interface{}) error {
 	if err := fv.Validator.Struct(s); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
@@ -0,0 +1,35 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsernameTag(t *testing.T) {
+	type request struct {
+		Username string `validate:"required,username"`
+	}
+	fv := NewFlugoValidator()
+
+	for _, username := range []string{"abc", "abc_valera", "Jo-Ke42", "a23456789012345678901234567890bc"} {
+		require.NoError(t, fv.Validate(request{username}), username)
+	}
+	for _, username := range []string{"", "ab", "a234567890123456789012345678901bc", "with space", "slash/name", "dot.name", "ünï"} {
+		require.Error(t, fv.Validate(request{username}), username)
+	}
+}
+
+func TestTagTag(t *testing.T) {
+	type request struct {
+		Tags []string `validate:"dive,tag"`
+	}
+	fv := NewFlugoValidator()
+
+	for _, tag := range []string{"a", "puns", "dad-jokes", "web3", "a2345678901234567890123456789012"} {
+		require.NoError(t, fv.Validate(request{[]string{tag}}), tag)
+	}
+	for _, tag := range []string{"", "-puns", "Puns", "dad jokes", "dad_jokes", "a23456789012345678901234567890123", "ünï"} {
+		require.Error(t, fv.Validate(request{[]string{tag}}), tag)
+	}
+}